@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"laziest/internal/binding"
+	"laziest/internal/config"
+	"laziest/internal/editor"
+	"laziest/internal/shell"
+)
+
+func cmdEdit(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Error: name required")
+		fmt.Fprintln(os.Stderr, "Usage: lz edit <name>")
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if _, err := cfg.GetCommandByName(args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	editCommand(cfg, args[0])
+}
+
+// editCommand opens a saved command in $EDITOR and saves the result
+// Used by 'lz edit' and the picker's 'v' key
+func editCommand(cfg *config.Config, name string) {
+	name, ok := copyFromLibrary(cfg, name)
+	if !ok {
+		return
+	}
+
+	cmd, err := cfg.GetCommandByName(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	entry := editor.Entry{
+		Name:        cmd.Name,
+		Tags:        cmd.Tags,
+		Description: cmd.Description,
+		Command:     cmd.Command,
+		Cwd:         cmd.Cwd,
+		Env:         cmd.Env,
+	}
+
+	edited, cancelled, err := editor.Edit(entry, func(e editor.Entry) error {
+		return validateEntry(cfg, name, e)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	if cancelled {
+		fmt.Println("No changes.")
+		return
+	}
+
+	// Warn about any issues with bindings
+	bindings, _ := binding.Parse(edited.Command)
+	for _, b := range bindings {
+		for _, warning := range binding.Validate(b) {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+	}
+
+	if err := cfg.UpdateCommand(name, edited.Name, edited.Command, edited.Tags); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	if err := cfg.UpdateCommandDetails(edited.Name, edited.Description, edited.Cwd, edited.Env); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	if err := cfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := shell.UpdateAliases(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	if edited.Name != name {
+		fmt.Printf("Modified '%s' -> '%s'\n", name, edited.Name)
+	} else {
+		fmt.Printf("Modified '%s'\n", edited.Name)
+	}
+}
+
+// validateEntry checks an edited entry before it is saved
+// Errors are shown at the top of the re-opened editor file
+func validateEntry(cfg *config.Config, originalName string, e editor.Entry) error {
+	if !config.IsValidName(e.Name) {
+		return fmt.Errorf("invalid alias name '%s': must start with a letter and contain only letters, numbers, and underscores", e.Name)
+	}
+	if e.Name != originalName {
+		if _, err := cfg.GetCommandByName(e.Name); err == nil {
+			return fmt.Errorf("command '%s' already exists", e.Name)
+		}
+	}
+	for _, tag := range e.Tags {
+		if !config.IsValidTag(tag) {
+			return fmt.Errorf("invalid tag '%s': must contain only letters, numbers, and underscores", tag)
+		}
+	}
+	if _, err := binding.Parse(e.Command); err != nil {
+		return err
+	}
+	return nil
+}
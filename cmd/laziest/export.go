@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"laziest/internal/config"
+	"laziest/internal/pack"
+)
+
+func cmdExport(args []string) {
+	tags, args := parseTagsFlag(args)
+	output := ""
+	name := ""
+	sign := false
+	var names []string
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-o", "--output", "-n", "--name":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "Error: %s requires a value\n", args[i])
+				os.Exit(1)
+			}
+			if args[i] == "-o" || args[i] == "--output" {
+				output = args[i+1]
+			} else {
+				name = args[i+1]
+			}
+			i++
+		case "--sign":
+			sign = true
+		default:
+			if strings.HasPrefix(args[i], "-") {
+				fmt.Fprintf(os.Stderr, "Error: unknown option '%s'\n", args[i])
+				fmt.Fprintln(os.Stderr, "Usage: lz export [-t <tags>] [names...] [-o <file>] [-n <pack name>] [--sign]")
+				os.Exit(1)
+			}
+			names = append(names, args[i])
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Named commands plus everything with one of the tags; all if neither
+	// Library commands are only exported when named, since they are shared already
+	var selected []config.Command
+	included := make(map[string]bool)
+	for _, n := range names {
+		cmd, err := cfg.GetCommandByName(n)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !included[n] {
+			included[n] = true
+			selected = append(selected, *cmd)
+		}
+	}
+	for _, tag := range tags {
+		for _, cmd := range cfg.GetCommandsByTag(tag) {
+			if !included[cmd.Name] && cmd.Library == "" {
+				included[cmd.Name] = true
+				selected = append(selected, cmd)
+			}
+		}
+	}
+	if len(names) == 0 && len(tags) == 0 {
+		selected = cfg.PersonalCommands()
+	}
+
+	if len(selected) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no commands to export")
+		os.Exit(1)
+	}
+
+	if name == "" {
+		switch {
+		case len(tags) == 1:
+			name = tags[0]
+		case output != "" && output != "-":
+			name = strings.TrimSuffix(filepath.Base(output), filepath.Ext(output))
+		}
+	}
+
+	// Names can still clash, e.g. a personal ml_train and the library's ml/train
+	p := pack.New(name, selected)
+	if err := p.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: can't export these commands together: %v\n", err)
+		os.Exit(1)
+	}
+	data, err := p.Marshal()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if sign {
+		if data, err = signDocument(data); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if output == "" || output == "-" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Exported %d command(s) to %s\n", len(selected), output)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"laziest/internal/picker"
+	"laziest/internal/shellhist"
+)
+
+// historyLimit is how many recent shell commands 'lz add --from-history' offers
+const historyLimit = 500
+
+// pickFromHistory lets the user choose a recent command from shell history
+// Returns the command and false if cancelled or no history is available
+func pickFromHistory() (string, bool) {
+	entries, err := shellhist.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return "", false
+	}
+
+	recent := shellhist.Recent(entries, historyLimit)
+	if len(recent) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: shell history is empty")
+		return "", false
+	}
+
+	// Multi-line entries are shown on one line; labels map back to entries
+	labels := make([]string, 0, len(recent))
+	byLabel := make(map[string]string, len(recent))
+	for _, entry := range recent {
+		label := strings.ReplaceAll(entry, "\n", " ⏎ ")
+		if _, dup := byLabel[label]; dup {
+			continue
+		}
+		byLabel[label] = entry
+		labels = append(labels, label)
+	}
+
+	result := picker.PickString(labels, "Select a command from history:", false, false)
+	if result.Action != picker.ActionSelect {
+		return "", false
+	}
+	return byLabel[result.Value], true
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"laziest/internal/config"
+	"laziest/internal/keys"
+	"laziest/internal/pack"
+	"laziest/internal/picker"
+	"laziest/internal/project"
+	"laziest/internal/shell"
+)
+
+func cmdImport(args []string) {
+	dir := "."
+	yes := false
+	dryRun := false
+	strategy := pack.StrategySkip
+	prefix := ""
+	allowUntrusted := false
+	packOption := "" // A pack-only option given, to reject for projects
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-y" || arg == "--yes":
+			yes = true
+		case arg == "--dry-run":
+			dryRun = true
+		case arg == "--allow-untrusted":
+			allowUntrusted = true
+			packOption = arg
+		case arg == "--on-conflict" || arg == "--prefix":
+			packOption = arg
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "Error: %s requires a value\n", arg)
+				os.Exit(1)
+			}
+			i++
+			if arg == "--prefix" {
+				prefix = args[i]
+				break
+			}
+			var err error
+			if strategy, err = pack.ParseStrategy(args[i]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		case strings.HasPrefix(arg, "-"):
+			fmt.Fprintf(os.Stderr, "Error: unknown option '%s'\n", arg)
+			fmt.Fprintln(os.Stderr, "Usage: lz import [dir] [--dry-run] [-y]")
+			fmt.Fprintln(os.Stderr, "       lz import <pack.json> [--on-conflict skip|rename|overwrite] [--prefix <p>] [--allow-untrusted] [--dry-run] [-y]")
+			os.Exit(1)
+		default:
+			dir = arg
+		}
+	}
+
+	// A file is a pack, a directory is a project
+	info, err := os.Stat(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !info.IsDir() {
+		importPack(dir, strategy, prefix, dryRun, yes, allowUntrusted)
+		return
+	}
+	if packOption != "" {
+		fmt.Fprintf(os.Stderr, "Error: %s only applies to pack files, and %s is a directory\n", packOption, dir)
+		os.Exit(1)
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	tasks, err := project.Detect(abs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	source := "project:" + abs
+	if len(tasks) == 0 && len(cfg.GetCommandsBySource(source)) == 0 {
+		fmt.Printf("No Makefile, package.json, justfile or pyproject.toml tasks found in %s\n", abs)
+		return
+	}
+
+	result, err := cfg.SyncSource(source, projectCommands(abs, tasks))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(result.Added)+len(result.Updated)+len(result.Removed) == 0 {
+		fmt.Println("Already up to date.")
+		printSkipped(result.Skipped)
+		return
+	}
+
+	fmt.Printf("Project %s:\n", abs)
+	for _, name := range result.Added {
+		cmd, _ := cfg.GetCommandByName(name)
+		fmt.Printf("  + %s  %s\n", name, cmd.Command)
+	}
+	for _, name := range result.Updated {
+		cmd, _ := cfg.GetCommandByName(name)
+		fmt.Printf("  ~ %s  %s\n", name, cmd.Command)
+	}
+	for _, name := range result.Removed {
+		fmt.Printf("  - %s\n", name)
+	}
+	printSkipped(result.Skipped)
+
+	if dryRun {
+		return
+	}
+	if !yes {
+		answer, ok := picker.PromptYesNo("Apply these changes?")
+		if !ok || !answer {
+			fmt.Println("Cancelled.")
+			return
+		}
+	}
+
+	if err := cfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := shell.UpdateAliases(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	fmt.Printf("Added %d, updated %d, removed %d command(s)\n", len(result.Added), len(result.Updated), len(result.Removed))
+}
+
+// projectCommands turns detected tasks into commands named "<project>_<task>",
+// tagged with the project name and pinned to its directory
+// Task names found by more than one tool also get the tool in the name.
+func projectCommands(dir string, tasks []project.Task) []config.Command {
+	name := shell.ImportName(filepath.Base(dir))
+
+	count := make(map[string]int)
+	for _, t := range tasks {
+		count[t.Name]++
+	}
+
+	cmds := make([]config.Command, 0, len(tasks))
+	seen := make(map[string]bool)
+	for _, t := range tasks {
+		cmdName := shell.ImportName(name + "_" + t.Name)
+		if count[t.Name] > 1 {
+			cmdName = shell.ImportName(name + "_" + t.Tool + "_" + t.Name)
+		}
+		if seen[cmdName] {
+			continue
+		}
+		seen[cmdName] = true
+
+		cmds = append(cmds, config.Command{
+			Name:        cmdName,
+			Command:     t.Command,
+			Tags:        []string{name},
+			Description: t.Description,
+			Cwd:         dir,
+		})
+	}
+	return cmds
+}
+
+// printSkipped reports tasks whose names are taken by other commands
+func printSkipped(names []string) {
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "Skipping '%s': a command with that name already exists\n", name)
+	}
+}
+
+// importPack merges a pack file into the config
+// Packs must be signed by a trusted key unless allowUntrusted is set.
+func importPack(path string, strategy pack.Strategy, prefix string, dryRun, yes, allowUntrusted bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	verifyPack(path, data, allowUntrusted)
+
+	p, err := pack.Parse(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", path, err)
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if prefix == "" {
+		prefix = "pack_"
+		if p.Name != "" {
+			prefix = shell.ImportName(p.Name) + "_"
+		}
+	}
+
+	changes := p.Plan(cfg, strategy, prefix)
+
+	title := path
+	if p.Name != "" {
+		title = fmt.Sprintf("'%s' (%s)", p.Name, path)
+	}
+	fmt.Printf("Pack %s, %d command(s):\n", title, len(p.Commands))
+
+	counts := make(map[pack.Action]int)
+	for _, ch := range changes {
+		counts[ch.Action]++
+		switch ch.Action {
+		case pack.ActionAdd:
+			fmt.Printf("  + %s  %s\n", ch.Name, ch.Command.Command)
+		case pack.ActionRename:
+			fmt.Printf("  + %s  %s  (renamed from '%s')\n", ch.Name, ch.Command.Command, ch.Command.Name)
+		case pack.ActionOverwrite:
+			fmt.Printf("  ~ %s  %s  (overwrites existing)\n", ch.Name, ch.Command.Command)
+		case pack.ActionSkip:
+			fmt.Printf("  = %s  (skipped, name already used)\n", ch.Name)
+		}
+	}
+	if counts[pack.ActionUnchanged] > 0 {
+		fmt.Printf("  %d already saved\n", counts[pack.ActionUnchanged])
+	}
+
+	changed := counts[pack.ActionAdd] + counts[pack.ActionRename] + counts[pack.ActionOverwrite]
+	if dryRun || changed == 0 {
+		if changed == 0 {
+			fmt.Println("Nothing to import.")
+		}
+		return
+	}
+
+	if !yes {
+		answer, ok := picker.PromptYesNo("Import these commands?")
+		if !ok || !answer {
+			fmt.Println("Cancelled.")
+			return
+		}
+	}
+
+	if err := pack.Apply(cfg, changes); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := cfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := shell.UpdateAliases(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	fmt.Printf("Imported %d command(s)\n", changed)
+}
+
+// verifyPack checks a pack's signature against the trusted keys, exiting if
+// it is unsigned, untrusted or tampered with and allowUntrusted is not set
+func verifyPack(path string, data []byte, allowUntrusted bool) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	trusted, err := keys.LoadTrusted(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	signer, err := keys.Verify(data, trusted)
+	switch {
+	case err == nil:
+		fmt.Printf("Signed by '%s' (%s)\n", signer.Name, signer.Fingerprint())
+	case allowUntrusted:
+		fmt.Fprintf(os.Stderr, "Warning: %s: %v; importing anyway (--allow-untrusted)\n", path, err)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", path, err)
+		if errors.Is(err, keys.ErrUntrusted) {
+			fmt.Fprintln(os.Stderr, "If you trust the author, add their key with 'lz keys trust <key> [name]'.")
+		}
+		fmt.Fprintln(os.Stderr, "Packs run arbitrary commands. Use --allow-untrusted to import it anyway.")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"laziest/internal/config"
+	"laziest/internal/picker"
+	"laziest/internal/shell"
+)
+
+func cmdImportAliases(args []string) {
+	tag := ""
+	commentOut := false
+	var files []string
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-t", "--tag":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: -t requires a tag")
+				os.Exit(1)
+			}
+			tag = args[i+1]
+			i++
+		case "--comment-out":
+			commentOut = true
+		default:
+			if strings.HasPrefix(args[i], "-") {
+				fmt.Fprintf(os.Stderr, "Error: unknown option '%s'\n", args[i])
+				fmt.Fprintln(os.Stderr, "Usage: lz import-aliases [-t <tag>] [--comment-out] [rc files...]")
+				os.Exit(1)
+			}
+			files = append(files, args[i])
+		}
+	}
+
+	if tag != "" && !config.IsValidTag(tag) {
+		fmt.Fprintf(os.Stderr, "Error: invalid tag '%s': must contain only letters, numbers, and underscores\n", tag)
+		os.Exit(1)
+	}
+
+	if len(files) == 0 {
+		var err error
+		files, err = shell.RCFiles()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Later definitions win, as they do when the shell reads the files.
+	// Every definition is kept in defs so all of them can be commented out.
+	var found, defs []shell.RCAlias
+	index := make(map[string]int)
+	for _, file := range files {
+		aliases, err := shell.ReadRCAliases(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		defs = append(defs, aliases...)
+		for _, a := range aliases {
+			if i, ok := index[a.Name]; ok {
+				found[i] = a
+				continue
+			}
+			index[a.Name] = len(found)
+			found = append(found, a)
+		}
+	}
+
+	if len(found) == 0 {
+		fmt.Printf("No aliases found in %s\n", strings.Join(files, ", "))
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Mark conflicts with saved commands; select everything that's new
+	maxName := 0
+	for _, a := range found {
+		maxName = max(maxName, len(a.Name))
+	}
+	items := make([]picker.MultiItem, len(found))
+	for i, a := range found {
+		var notes []string
+		name := shell.ImportName(a.Name)
+		selected := true
+		if existing, err := cfg.GetCommandByName(name); err == nil {
+			selected = false
+			if existing.Command == a.Command {
+				notes = append(notes, "already saved")
+			} else {
+				notes = append(notes, fmt.Sprintf("conflicts with '%s'", name))
+			}
+		} else if name != a.Name {
+			notes = append(notes, fmt.Sprintf("saved as '%s'", name))
+		}
+		if a.Function {
+			notes = append(notes, "function")
+		}
+
+		items[i] = picker.MultiItem{
+			Label:    fmt.Sprintf("%-*s  %s", maxName, a.Name, a.Command),
+			Selected: selected,
+		}
+		if len(notes) > 0 {
+			items[i].Note = "(" + strings.Join(notes, ", ") + ")"
+		}
+	}
+
+	chosen, ok := picker.PickMulti(items, fmt.Sprintf("Import aliases from %d file(s):", len(files)))
+	if !ok || len(chosen) == 0 {
+		fmt.Println("Cancelled.")
+		return
+	}
+
+	if tag == "" {
+		input, cancelled := picker.PromptInput("Tag for imported commands (empty for none): ", "imported")
+		if cancelled {
+			fmt.Println("Cancelled.")
+			return
+		}
+		tag = strings.TrimSpace(input)
+		if tag != "" && !config.IsValidTag(tag) {
+			fmt.Fprintf(os.Stderr, "Error: invalid tag '%s': must contain only letters, numbers, and underscores\n", tag)
+			os.Exit(1)
+		}
+	}
+	var tags []string
+	if tag != "" {
+		tags = []string{tag}
+	}
+
+	// Add the chosen aliases, asking for a new name on conflicts
+	imported := make(map[string]string) // rc name -> name saved in lz
+	added := 0
+	for _, i := range chosen {
+		a := found[i]
+		name := shell.ImportName(a.Name)
+
+		if existing, err := cfg.GetCommandByName(name); err == nil {
+			if existing.Command == a.Command {
+				imported[a.Name] = name
+				continue
+			}
+			newName, cancelled := picker.PromptInput(fmt.Sprintf("'%s' already exists. New name (empty to skip): ", name), "")
+			if cancelled || newName == "" {
+				continue
+			}
+			name = newName
+		}
+
+		if !config.IsValidName(name) {
+			fmt.Fprintf(os.Stderr, "Skipping '%s': invalid alias name '%s'\n", a.Name, name)
+			continue
+		}
+		if err := cfg.AddCommand(name, a.Command, tags); err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping '%s': %v\n", a.Name, err)
+			continue
+		}
+		imported[a.Name] = name
+		added++
+	}
+
+	if added > 0 {
+		if err := cfg.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		if err := shell.UpdateAliases(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	fmt.Printf("Imported %d command(s)\n", added)
+
+	if len(imported) == 0 {
+		return
+	}
+	if !commentOut {
+		answer, ok := picker.PromptYesNo("Comment out the imported definitions in your rc files?")
+		if !ok || !answer {
+			return
+		}
+	}
+	commentOutImported(defs, imported)
+}
+
+// commentOutImported comments out every rc line defining an imported name,
+// warning about lines kept because they also do something else
+func commentOutImported(defs []shell.RCAlias, imported map[string]string) {
+	lines, kept := shell.ImportedLines(defs, imported)
+	renamed := make(map[string]bool)
+	for _, a := range defs {
+		if saved, ok := imported[a.Name]; ok && saved != a.Name && !renamed[a.Name] {
+			renamed[a.Name] = true
+			fmt.Printf("Left '%s' in your rc files, since it was saved in lz as '%s'\n", a.Name, saved)
+		}
+	}
+	for _, a := range kept {
+		fmt.Fprintf(os.Stderr, "Warning: left %s:%d as is, since it does more than define imported aliases; '%s' is still defined there\n", a.File, a.Line, a.Name)
+	}
+
+	done := make(map[string]bool)
+	for _, a := range defs {
+		file := a.File
+		if done[file] || len(lines[file]) == 0 {
+			continue
+		}
+		done[file] = true
+		if err := shell.CommentOutAliases(file, lines[file]); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		fmt.Printf("Commented out %d line(s) in %s\n", len(lines[file]), file)
+	}
+}
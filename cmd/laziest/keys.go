@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+
+	"laziest/internal/config"
+	"laziest/internal/keys"
+)
+
+func cmdKeys(args []string) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	sub := "list"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+
+	switch sub {
+	case "list", "ls":
+		listKeys(dir)
+
+	case "generate", "gen":
+		force := len(args) > 0 && args[0] == "--force"
+		old, oldErr := keys.LoadSigner(dir)
+		key, err := keys.Generate(dir, defaultKeyName(), force)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		// Packs you sign yourself are trusted on this machine, with the new
+		// key taking the replaced one's place
+		if oldErr == nil {
+			err = keys.Replace(dir, keys.PublicKey(old, ""), key)
+		} else {
+			err = keys.Trust(dir, key)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		fmt.Printf("Generated signing key %s\n", key.Fingerprint())
+		fmt.Println("Share your public key so others can trust your packs:")
+		fmt.Println()
+		fmt.Println("  " + key.String())
+
+	case "show":
+		private, err := keys.LoadSigner(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(keys.PublicKey(private, defaultKeyName()).String())
+
+	case "trust":
+		if len(args) == 0 {
+			fmt.Fprintln(os.Stderr, "Usage: lz keys trust <public key> [name]")
+			os.Exit(1)
+		}
+		key, err := keys.ParseKey(strings.Join(args, " "))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := keys.Trust(dir, key); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Trusted %s %s\n", key.Fingerprint(), key.Name)
+
+	case "untrust":
+		if len(args) != 1 {
+			fmt.Fprintln(os.Stderr, "Usage: lz keys untrust <name|fingerprint>")
+			os.Exit(1)
+		}
+		key, err := keys.Untrust(dir, args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Removed %s %s\n", key.Fingerprint(), key.Name)
+
+	case "sign":
+		if len(args) == 0 {
+			fmt.Fprintln(os.Stderr, "Usage: lz keys sign <file.json>...")
+			os.Exit(1)
+		}
+		for _, path := range args {
+			data, err := os.ReadFile(path)
+			if err == nil {
+				data, err = signDocument(data)
+			}
+			if err == nil {
+				err = os.WriteFile(path, data, 0644)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s: %v\n", path, err)
+				os.Exit(1)
+			}
+			fmt.Printf("Signed %s\n", path)
+		}
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown keys command: %s\n", sub)
+		fmt.Fprintln(os.Stderr, "Usage: lz keys [list | generate [--force] | show | trust <key> [name] | untrust <name> | sign <files...>]")
+		os.Exit(1)
+	}
+}
+
+// listKeys prints the signing key and the trusted keys
+func listKeys(dir string) {
+	if private, err := keys.LoadSigner(dir); err == nil {
+		fmt.Printf("Signing key: %s\n", keys.PublicKey(private, "").Fingerprint())
+	} else {
+		fmt.Printf("Signing key: none (%v)\n", err)
+	}
+
+	trusted, err := keys.LoadTrusted(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(trusted) == 0 {
+		fmt.Println("No trusted keys. Add one with 'lz keys trust <key> [name]'")
+		return
+	}
+	fmt.Println()
+	fmt.Println("Trusted keys:")
+	for _, k := range trusted {
+		fmt.Printf("  %s  %s\n", k.Fingerprint(), k.Name)
+	}
+}
+
+// signDocument signs a JSON document with the user's signing key
+func signDocument(data []byte) ([]byte, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	private, err := keys.LoadSigner(dir)
+	if err != nil {
+		return nil, err
+	}
+	return keys.Sign(data, private)
+}
+
+// defaultKeyName names a new key after the user and machine, e.g. "alice@laptop"
+func defaultKeyName() string {
+	name := "lz"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		name += "@" + strings.Split(host, ".")[0]
+	}
+	return name
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"laziest/internal/config"
+	"laziest/internal/picker"
+	"laziest/internal/shell"
+)
+
+func cmdLibrary(args []string) {
+	if len(args) == 0 || args[0] == "list" || args[0] == "ls" {
+		listLibraries()
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "add":
+		allowUntrusted := false
+		var rest []string
+		for _, arg := range args[1:] {
+			if arg == "--allow-untrusted" {
+				allowUntrusted = true
+			} else {
+				rest = append(rest, arg)
+			}
+		}
+		if len(rest) < 1 {
+			fmt.Fprintln(os.Stderr, "Usage: lz library add <dir> [name] [--allow-untrusted]")
+			os.Exit(1)
+		}
+		dir := expandHome(rest[0])
+		name := shell.ImportName(filepath.Base(dir))
+		if len(rest) > 1 {
+			name = rest[1]
+		}
+		if err := cfg.AddLibrary(name, dir, allowUntrusted); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, msg := range cfg.LibraryErrors {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
+		}
+		saveLibraries(cfg)
+		fmt.Printf("Added library '%s' with %d command(s)\n", name, countLibrary(cfg, name))
+
+	case "remove", "rm":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "Usage: lz library remove <name>")
+			os.Exit(1)
+		}
+		if err := cfg.RemoveLibrary(args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		saveLibraries(cfg)
+		fmt.Printf("Removed library '%s'\n", args[1])
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown library command: %s\n", args[0])
+		fmt.Fprintln(os.Stderr, "Usage: lz library [list | add <dir> [name] [--allow-untrusted] | remove <name>]")
+		os.Exit(1)
+	}
+}
+
+// listLibraries prints the configured libraries and any loading problems
+func listLibraries() {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(cfg.Libraries) == 0 {
+		fmt.Println("No libraries configured.")
+		fmt.Println("Add one with 'lz library add <dir> [name]'")
+		return
+	}
+
+	fmt.Println()
+	for _, lib := range cfg.Libraries {
+		note := ""
+		if lib.AllowUntrusted {
+			note = "  (signatures not checked)"
+		}
+		fmt.Printf("  %-16s %-4d %s%s\n", lib.Name, countLibrary(cfg, lib.Name), lib.Path, note)
+	}
+	fmt.Println()
+	for _, msg := range cfg.LibraryErrors {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
+	}
+}
+
+// saveLibraries saves the config and regenerates aliases after a library change
+func saveLibraries(cfg *config.Config) {
+	if err := cfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := shell.UpdateAliases(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// countLibrary returns the number of commands loaded from a library
+func countLibrary(cfg *config.Config, name string) int {
+	n := 0
+	for _, cmd := range cfg.Commands {
+		if cmd.Library == name {
+			n++
+		}
+	}
+	return n
+}
+
+// isLibraryCommand reports whether name is a read-only library command
+func isLibraryCommand(cfg *config.Config, name string) bool {
+	cmd, err := cfg.GetCommandByName(name)
+	return err == nil && cmd.Library != ""
+}
+
+// listName returns a command's name with a marker for library commands
+func listName(cmd config.Command) string {
+	if cmd.Library != "" {
+		return cmd.Name + " (lib)"
+	}
+	return cmd.Name
+}
+
+// copyFromLibrary returns the name of a command that can be changed
+// Personal commands are returned as-is; library commands are read-only, so
+// the user is offered a personal copy. Returns false if no copy was made.
+func copyFromLibrary(cfg *config.Config, name string) (string, bool) {
+	found, err := cfg.GetCommandByName(name)
+	if err != nil || found.Library == "" {
+		return name, true
+	}
+	cmd := *found
+
+	fmt.Printf("'%s' is from the '%s' library and is read-only.\n", cmd.Name, cmd.Library)
+	answer, ok := picker.PromptYesNo("Copy it to your commands?")
+	if !ok || !answer {
+		return "", false
+	}
+
+	suggested := cmd.BaseName()
+	if _, err := cfg.GetCommandByName(suggested); err == nil {
+		suggested = cmd.Library + "_" + suggested
+	}
+	newName, cancelled := picker.PromptInput("Name: ", suggested)
+	if cancelled || newName == "" {
+		return "", false
+	}
+	if !config.IsValidName(newName) {
+		fmt.Fprintf(os.Stderr, "Error: invalid alias name '%s'\n", newName)
+		return "", false
+	}
+
+	if err := cfg.AddCommand(newName, cmd.Command, cmd.Tags); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return "", false
+	}
+	if err := cfg.UpdateCommandDetails(newName, cmd.Description, cmd.Cwd, cmd.Env); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return "", false
+	}
+	if err := cfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := shell.UpdateAliases(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	fmt.Printf("Copied '%s' to '%s'\n", cmd.Name, newName)
+	return newName, true
+}
+
+// warnLibraryErrors points to lz library list when libraries had problems
+// loading, such as a missing or bad signature, so their commands don't just
+// seem to be missing
+func warnLibraryErrors(cfg *config.Config) {
+	if n := len(cfg.LibraryErrors); n > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d library problem(s), see 'lz library list'\n", n)
+	}
+}
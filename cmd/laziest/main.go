@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -33,6 +34,22 @@ func main() {
 		cmdAdd(os.Args[2:])
 	case "add-raw", "ar":
 		cmdAddRaw(os.Args[2:])
+	case "edit":
+		cmdEdit(os.Args[2:])
+	case "rebuild":
+		cmdRebuild(os.Args[2:])
+	case "suggest":
+		cmdSuggest(os.Args[2:])
+	case "export":
+		cmdExport(os.Args[2:])
+	case "import":
+		cmdImport(os.Args[2:])
+	case "keys":
+		cmdKeys(os.Args[2:])
+	case "library", "lib":
+		cmdLibrary(os.Args[2:])
+	case "import-aliases":
+		cmdImportAliases(os.Args[2:])
 	case "run", "r":
 		cmdRun(os.Args[2:])
 	case "last":
@@ -60,11 +77,28 @@ func printUsage() {
 Usage:
   lz                           Interactive command picker
   lz list [-t <tag>]           Interactive picker, optionally filter by tag
-  lz add "<cmd>"               Interactive command builder from example
+  lz add [--scan-help] "<cmd>" Interactive command builder from example
+  lz add --from-history        Pick a recent shell command to build from
+  lz suggest [--min <count>]   Suggest commands worth saving from shell history
+  lz import [dir] [-y]         Sync Makefile, npm, just and pyproject tasks as commands
+  lz export [-t <tags>] [names...] [-o <file>] [--sign]
+                               Export commands as a shareable pack
+  lz import <pack.json> [--on-conflict skip|rename|overwrite] [--dry-run]
+                               Merge a signed pack into your commands
+  lz library [add <dir> [name] | remove <name>]
+                               Manage read-only team command libraries
+  lz keys [generate | show | trust <key> | untrust <name> | sign <file>]
+                               Manage pack signing and trusted keys
+  lz import-aliases [-t <tag>] [--comment-out] [files...]
+                               Import aliases and functions from shell rc files
   lz add-raw <name> <cmd> [-t <tags>]  Add command with manual binding syntax
   lz run <name> [--extra <args>]   Run command by name
+  lz run <name> --print        Resolve bindings and print the command instead
+  lz run <name> --set k=v      Give the binding for flag --k (or position k) a value
   lz run -t <tag> [--extra <args>] Pick and run a command with that tag
   lz last                      Pick and run from recent commands
+  lz edit <name>               Edit a command in $EDITOR
+  lz rebuild <name>            Re-run the interactive builder on a command
   lz remove <name>             Remove a command
   lz tags                      List all tags with command counts
   lz init                      One-time setup: add source line to shell rc
@@ -74,11 +108,16 @@ Usage:
 Adding commands (interactive builder - recommended):
   lz add "python train.py --config /configs/model.yaml --epochs 100"
   
-  Walks through each flag and asks how to handle it:
+  Walks through each flag and argument and asks how to handle it:
   - Keep static: Flag value stays as-is
   - Directory picker: Browse and select a path at runtime
   - Value list: Choose from predefined options at runtime
+  - Custom input: Type a value at runtime
   - Optional boolean: Include or skip the flag at runtime
+  - Secret: Masked input, kept out of output and history
+
+  --scan-help runs "<cmd> --help" (3s limit) to suggest documented choices
+  and offer flags missing from the example as optional bindings.
 
 Adding commands (manual syntax):
   lz add-raw deploy "kubectl apply -f ." -t DevOps,K8s
@@ -90,9 +129,20 @@ Tags:
 
 Dynamic bindings (for add-raw):
   Directory binding:  {%/path/to/dir%} or {%/path/to/dir:*.yaml%}
+  File filters:       {%/src:*.go,!*_test.go%}, src/**/*.go, hidden, follow, gitignore, sort=mtime
+  Pick directories:   {%/experiments:dirs,depth=1%}, leaf, marker=config.yaml
+  Browse a tree:      {%/checkpoints:*.pt,browse%}, add up to allow leaving the directory
   Value binding:      {%[val1,val2,val3]%}
   Custom input:       {%[val1,val2,...]%} - allows custom value via [Custom] option
+  Number range:       {%?--seed:range(0,10)%}, range(0,100,10), range(1,64,*2)
+  Typed input:        {%?--epochs:int(1,1000)%}, float(0,1), regex:<re>, path, url, date
   Optional binding:   {%?...%} or {%?--flag:...%}
+  From environment:   {%?env:AWS_PROFILE|[dev,prod]%} - asks only when unset
+  Secret input:       {%?secret%}, {%?--token=secret%} or {%?secret:ENV_VAR%} (drop ? to require)
+  Git:                {%?--branch:git:branches%}, remote-branches, tags, remotes, changed, stashes
+  SSH hosts:          {%?ssh:hosts%} from ~/.ssh/config, or ssh:all-hosts to add known_hosts
+  Kubernetes:         {%?--context:kube:contexts%}, kube:clusters, kube:users, kube:namespaces
+  Processes/ports:    {%?--pid:proc:pids:python*%}, proc:ports, proc:tcp-ports, proc:udp-ports
   
   Commands with bindings prompt for selection at runtime.
   Optional bindings show [Skip] option. Press 's' to skip.
@@ -107,6 +157,9 @@ Interactive picker keys:
   ↑/↓ or j/k   Navigate
   Enter        Select and run
   e            Add extra args then run
+  m            Modify name, command and tags inline
+  v            Open the command in $EDITOR
+  b            Rebuild the command with the interactive builder
   c            Enter custom value (when ... in binding)
   s            Skip optional binding
   q or Esc     Cancel
@@ -124,6 +177,9 @@ Examples:
 }
 
 func cmdInit() {
+	if cfg, err := config.Load(); err == nil {
+		warnLibraryErrors(cfg)
+	}
 	updated, err := shell.Init()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
@@ -177,53 +233,44 @@ func cmdLast() {
 		}
 	}
 
-	// Show picker
-	result := picker.Pick(items, "Recent commands:")
+	// Show picker; history entries can only be run again
+	result := picker.PickWith(items, "Recent commands:", picker.PickOptions{SelectOnly: true})
 
-	if result.Action == picker.ActionCancel {
+	if result.Action != picker.ActionSelect {
 		return
 	}
 
-	// The selected item's Command field has the actual command to run
-	selectedCmd := result.Value
-	// Find the actual command from entries by matching the display name
-	var actualItem picker.Item
-	for _, item := range items {
-		if item.Name == selectedCmd {
-			actualItem = item
+	// Find the entry by matching the display name
+	var entry *config.HistoryEntry
+	for i, item := range items {
+		if item.Name == result.Value {
+			entry = &entries[i]
 			break
 		}
 	}
 
-	if actualItem.Command == "" {
+	if entry == nil {
 		fmt.Fprintln(os.Stderr, "Error: could not find selected command")
 		os.Exit(1)
 	}
 
-	// Execute the command
-	fmt.Printf("Running: %s\n", actualItem.Command)
-	fmt.Println(strings.Repeat("-", 40))
-
-	shellPath := os.Getenv("SHELL")
-	if shellPath == "" {
-		shellPath = "/bin/sh"
+	// Run in the saved command's directory/environment if it still exists
+	var saved *config.Command
+	if cfg, err := config.Load(); err == nil {
+		saved, _ = cfg.GetCommandByName(entry.Name)
 	}
 
-	execCmd := exec.Command(shellPath, "-c", actualItem.Command)
-	execCmd.Stdin = os.Stdin
-	execCmd.Stdout = os.Stdout
-	execCmd.Stderr = os.Stderr
+	// Secret bindings are kept in history, so ask for them again
+	r := resolveBindings(entry.Command, nil)
 
-	if err := execCmd.Run(); err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			os.Exit(exitErr.ExitCode())
-		}
-		fmt.Fprintf(os.Stderr, "Error executing command: %v\n", err)
-		os.Exit(1)
-	}
+	// Execute the command
+	fmt.Printf("Running: %s\n", r.display)
+	fmt.Println(strings.Repeat("-", 40))
 
-	// Update execution time.
-	config.AddHistoryEntry(actualItem.Command, actualItem.Name)
+	// Update execution time, under the saved command's name so it still resolves
+	config.AddHistoryEntry(entry.Command, entry.Name)
+
+	execute(r.command, saved, r.env)
 }
 
 func formatRelativeTime(t time.Time) string {
@@ -308,8 +355,8 @@ func cmdList(filterTags []string) {
 	maxNameLen := 0
 	maxTagLen := 0
 	for _, cmd := range commands {
-		if len(cmd.Name) > maxNameLen {
-			maxNameLen = len(cmd.Name)
+		if len(listName(cmd)) > maxNameLen {
+			maxNameLen = len(listName(cmd))
 		}
 		tagStr := formatTags(cmd.Tags)
 		if len(tagStr) > maxTagLen {
@@ -322,9 +369,9 @@ func cmdList(filterTags []string) {
 	for _, cmd := range commands {
 		tagStr := formatTags(cmd.Tags)
 		if tagStr != "" {
-			fmt.Printf("  %-*s  %-*s  %s\n", maxNameLen, cmd.Name, maxTagLen, tagStr, cmd.Command)
+			fmt.Printf("  %-*s  %-*s  %s\n", maxNameLen, listName(cmd), maxTagLen, tagStr, cmd.Command)
 		} else {
-			fmt.Printf("  %-*s  %-*s  %s\n", maxNameLen, cmd.Name, maxTagLen, "", cmd.Command)
+			fmt.Printf("  %-*s  %-*s  %s\n", maxNameLen, listName(cmd), maxTagLen, "", cmd.Command)
 		}
 	}
 	fmt.Println()
@@ -336,6 +383,7 @@ func cmdInteractiveList(filterTags []string) {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		os.Exit(1)
 	}
+	warnLibraryErrors(cfg)
 
 	if len(cfg.Commands) == 0 {
 		fmt.Println("No commands saved.")
@@ -376,7 +424,7 @@ func cmdInteractiveList(filterTags []string) {
 		// Build picker items
 		items := make([]picker.Item, len(commands))
 		for i, cmd := range commands {
-			items[i] = picker.Item{Name: cmd.Name, Command: cmd.Command, Tags: cmd.Tags}
+			items[i] = picker.Item{Name: cmd.Name, Command: cmd.Command, Tags: cmd.Tags, Library: cmd.Library}
 		}
 
 		// Show picker
@@ -391,6 +439,10 @@ func cmdInteractiveList(filterTags []string) {
 
 		// Handle delete action
 		if result.Action == picker.ActionDelete {
+			if isLibraryCommand(cfg, result.Value) {
+				copyFromLibrary(cfg, result.Value)
+				continue
+			}
 			if err := cfg.RemoveCommandByName(result.Value); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
@@ -407,11 +459,37 @@ func cmdInteractiveList(filterTags []string) {
 			continue
 		}
 
+		// Handle edit action
+		if result.Action == picker.ActionEdit {
+			editCommand(cfg, result.Value)
+			// Loop back to picker
+			continue
+		}
+
+		// Handle rebuild action
+		if result.Action == picker.ActionRebuild {
+			rebuildCommand(cfg, result.Value)
+			// Loop back to picker
+			continue
+		}
+
 		// Handle modify action
 		if result.Action == picker.ActionModify {
+			// Library commands are changed through a personal copy
+			if isLibraryCommand(cfg, result.Value) {
+				name, ok := copyFromLibrary(cfg, result.Value)
+				if !ok {
+					continue
+				}
+				if result.NewName == result.Value {
+					result.NewName = name
+				}
+				result.Value = name
+			}
+
 			// Validate new name if changed
 			if result.NewName != result.Value {
-				if !isValidAliasName(result.NewName) {
+				if !config.IsValidName(result.NewName) {
 					fmt.Fprintf(os.Stderr, "Error: invalid alias name '%s'\n", result.NewName)
 					fmt.Fprintln(os.Stderr, "Name must start with a letter and contain only letters, numbers, and underscores")
 					continue
@@ -466,105 +544,14 @@ func cmdInteractiveList(filterTags []string) {
 			os.Exit(1)
 		}
 
-		// Resolve bindings and run
-		finalCommand := cmd.Command
-		extraArgs := ""
-
 		// Handle extra args from picker
+		extraArgs := ""
 		if result.Action == picker.ActionSelectWithExtra {
 			extraArgs = result.Extra
 		}
 
-		// Parse and resolve any bindings
-		bindings, err := binding.Parse(cmd.Command)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error parsing bindings: %v\n", err)
-			os.Exit(1)
-		}
-
-		for _, b := range bindings {
-			var selected string
-			prompt := binding.ExtractPromptContext(finalCommand, b)
-
-			if b.Type == binding.BindingDirectory {
-				// List files and show picker
-				files, err := binding.ListFiles(b)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-					os.Exit(1)
-				}
-
-				bindResult := picker.PickString(files, prompt, b.Optional, false)
-				if bindResult.Action == picker.ActionCancel {
-					os.Exit(0) // User cancelled
-				}
-				if bindResult.Action == picker.ActionSkip {
-					// Remove binding and flag from command
-					finalCommand = binding.RemoveWithFlag(finalCommand, b)
-					continue
-				}
-				// Use absolute path
-				selected = binding.GetAbsolutePath(b, bindResult.Value)
-
-			} else if b.Type == binding.BindingBooleanFlag {
-				// Handle optional boolean flag - ask yes/no to include
-				include, ok := picker.PromptYesNo(prompt)
-				if !ok {
-					os.Exit(0) // User cancelled
-				}
-				if !include {
-					// User chose not to include - remove the flag
-					finalCommand = binding.RemoveWithFlag(finalCommand, b)
-					continue
-				}
-				// User chose to include - resolve with empty value (just the flag)
-				selected = ""
-
-			} else { // BindingValues
-				bindResult := picker.PickString(b.Values, prompt, b.Optional, b.AllowCustom)
-				if bindResult.Action == picker.ActionCancel {
-					os.Exit(0) // User cancelled
-				}
-				if bindResult.Action == picker.ActionSkip {
-					// Remove binding and flag from command
-					finalCommand = binding.RemoveWithFlag(finalCommand, b)
-					continue
-				}
-				selected = bindResult.Value
-			}
-
-			finalCommand = binding.Resolve(finalCommand, b, selected)
-		}
-
-		// Append extra args if provided
-		if extraArgs != "" {
-			finalCommand = finalCommand + " " + extraArgs
-		}
-
-		// Save to history for 'lz !!'
-		config.AddHistoryEntry(finalCommand, cmd.Name)
-
-		fmt.Printf("Running: %s\n", finalCommand)
-		fmt.Println(strings.Repeat("-", 40))
-
-		// Determine which shell to use
-		shellPath := os.Getenv("SHELL")
-		if shellPath == "" {
-			shellPath = "/bin/sh"
-		}
-
-		execCmd := exec.Command(shellPath, "-c", finalCommand)
-		execCmd.Stdin = os.Stdin
-		execCmd.Stdout = os.Stdout
-		execCmd.Stderr = os.Stderr
-
-		if err := execCmd.Run(); err != nil {
-			if exitErr, ok := err.(*exec.ExitError); ok {
-				os.Exit(exitErr.ExitCode())
-			}
-			fmt.Fprintf(os.Stderr, "Error executing command: %v\n", err)
-			os.Exit(1)
-		}
+		// Resolve bindings and run
+		run(resolveBindings(cmd.Command, nil).withExtra(extraArgs), cmd)
 		return
 	}
 }
@@ -583,7 +570,7 @@ func cmdAddRaw(args []string) {
 	name := remaining[0]
 
 	// Validate name (must be valid for shell alias)
-	if !isValidAliasName(name) {
+	if !config.IsValidName(name) {
 		fmt.Fprintf(os.Stderr, "Error: invalid alias name '%s'\n", name)
 		fmt.Fprintln(os.Stderr, "Name must start with a letter and contain only letters, numbers, and underscores")
 		os.Exit(1)
@@ -654,9 +641,40 @@ func cmdAddRaw(args []string) {
 }
 
 func cmdAdd(args []string) {
+	// Builder options come before the example command, so the example's
+	// own flags are never mistaken for ours
+	var opts builder.Options
+	fromHistory := false
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		switch args[0] {
+		case "--scan-help":
+			opts.ScanHelp = true
+		case "--from-history":
+			fromHistory = true
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unknown option '%s'\n", args[0])
+			os.Exit(1)
+		}
+		args = args[1:]
+	}
+
+	if fromHistory {
+		if len(args) > 0 {
+			fmt.Fprintln(os.Stderr, "Error: --from-history takes no example command")
+			os.Exit(1)
+		}
+		command, ok := pickFromHistory()
+		if !ok {
+			fmt.Println("Cancelled.")
+			return
+		}
+		args = []string{command}
+	}
+
 	if len(args) < 1 {
 		fmt.Fprintln(os.Stderr, "Error: example command required")
-		fmt.Fprintln(os.Stderr, "Usage: lz add \"<example command>\"")
+		fmt.Fprintln(os.Stderr, "Usage: lz add [--scan-help] \"<example command>\"")
+		fmt.Fprintln(os.Stderr, "   or: lz add [--scan-help] --from-history")
 		fmt.Fprintln(os.Stderr, "")
 		fmt.Fprintln(os.Stderr, "Example:")
 		fmt.Fprintln(os.Stderr, "  lz add \"python train.py --config /configs/model.yaml --epochs 100\"")
@@ -667,7 +685,7 @@ func cmdAdd(args []string) {
 	exampleCmd := strings.Join(args, " ")
 
 	// Run interactive builder
-	result := builder.BuildCommand(exampleCmd)
+	result := builder.BuildCommand(exampleCmd, opts)
 	if result.Cancelled {
 		fmt.Println("Cancelled.")
 		return
@@ -699,7 +717,7 @@ func cmdAdd(args []string) {
 	}
 
 	// Validate name
-	if !isValidAliasName(name) {
+	if !config.IsValidName(name) {
 		fmt.Fprintf(os.Stderr, "Error: invalid alias name '%s'\n", name)
 		fmt.Fprintln(os.Stderr, "Name must start with a letter and contain only letters, numbers, and underscores")
 		os.Exit(1)
@@ -762,14 +780,41 @@ func cmdRun(args []string) {
 	// Parse extra args first
 	args, extraArgs := parseExtraArgs(args)
 
+	// --print shows the resolved command instead of running it, and
+	// --set key=value gives a binding its value without asking
+	printOnly := false
+	set := make(map[string]string)
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--print":
+			printOnly = true
+		case args[i] == "--set":
+			i++
+			if i == len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --set needs key=value")
+				os.Exit(1)
+			}
+			key, value, ok := strings.Cut(args[i], "=")
+			if !ok || key == "" {
+				fmt.Fprintf(os.Stderr, "Error: --set needs key=value, got '%s'\n", args[i])
+				os.Exit(1)
+			}
+			set[key] = value
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+
 	// Parse tags flag
-	tags, remaining := parseTagsFlag(args)
+	tags, remaining := parseTagsFlag(rest)
 
 	cfg, err := config.Load()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		os.Exit(1)
 	}
+	warnLibraryErrors(cfg)
 
 	if len(cfg.Commands) == 0 {
 		fmt.Fprintln(os.Stderr, "No commands saved. Use 'lz add \"<command>\"' to add one.")
@@ -806,13 +851,17 @@ func cmdRun(args []string) {
 			// Show picker
 			items := make([]picker.Item, len(matches))
 			for i, m := range matches {
-				items[i] = picker.Item{Name: m.Name, Command: m.Command, Tags: m.Tags}
+				items[i] = picker.Item{Name: m.Name, Command: m.Command, Tags: m.Tags, Library: m.Library}
 			}
 
 			result := picker.Pick(items, fmt.Sprintf("Select command [%s]:", strings.Join(tags, ", ")))
 
 			// Handle delete action
 			if result.Action == picker.ActionDelete {
+				if isLibraryCommand(cfg, result.Value) {
+					copyFromLibrary(cfg, result.Value)
+					continue
+				}
 				if err := cfg.RemoveCommandByName(result.Value); err != nil {
 					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 					os.Exit(1)
@@ -829,11 +878,37 @@ func cmdRun(args []string) {
 				continue
 			}
 
+			// Handle edit action
+			if result.Action == picker.ActionEdit {
+				editCommand(cfg, result.Value)
+				// Loop back to picker
+				continue
+			}
+
+			// Handle rebuild action
+			if result.Action == picker.ActionRebuild {
+				rebuildCommand(cfg, result.Value)
+				// Loop back to picker
+				continue
+			}
+
 			// Handle modify action
 			if result.Action == picker.ActionModify {
+				// Library commands are changed through a personal copy
+				if isLibraryCommand(cfg, result.Value) {
+					name, ok := copyFromLibrary(cfg, result.Value)
+					if !ok {
+						continue
+					}
+					if result.NewName == result.Value {
+						result.NewName = name
+					}
+					result.Value = name
+				}
+
 				// Validate new name if changed
 				if result.NewName != result.Value {
-					if !isValidAliasName(result.NewName) {
+					if !config.IsValidName(result.NewName) {
 						fmt.Fprintf(os.Stderr, "Error: invalid alias name '%s'\n", result.NewName)
 						fmt.Fprintln(os.Stderr, "Name must start with a letter and contain only letters, numbers, and underscores")
 						continue
@@ -908,91 +983,54 @@ func cmdRun(args []string) {
 		os.Exit(1)
 	}
 
-	// Execute the command
-	finalCommand := cmd.Command
-
-	// Parse and resolve any bindings
-	bindings, err := binding.Parse(cmd.Command)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing bindings: %v\n", err)
-		os.Exit(1)
-	}
-
-	for _, b := range bindings {
-		var selected string
-		prompt := binding.ExtractPromptContext(finalCommand, b)
-
-		if b.Type == binding.BindingDirectory {
-			// List files and show picker
-			files, err := binding.ListFiles(b)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
-			}
-
-			result := picker.PickString(files, prompt, b.Optional, false)
-			if result.Action == picker.ActionCancel {
-				os.Exit(0) // User cancelled
-			}
-			if result.Action == picker.ActionSkip {
-				// Remove binding and flag from command
-				finalCommand = binding.RemoveWithFlag(finalCommand, b)
-				continue
-			}
-			// Use absolute path
-			selected = binding.GetAbsolutePath(b, result.Value)
-
-		} else if b.Type == binding.BindingBooleanFlag {
-			// Handle optional boolean flag - ask yes/no to include
-			include, ok := picker.PromptYesNo(prompt)
-			if !ok {
-				os.Exit(0) // User cancelled
-			}
-			if !include {
-				// User chose not to include - remove the flag
-				finalCommand = binding.RemoveWithFlag(finalCommand, b)
-				continue
-			}
-			// User chose to include - resolve with empty value (just the flag)
-			selected = ""
-
-		} else { // BindingValues
-			result := picker.PickString(b.Values, prompt, b.Optional, b.AllowCustom)
-			if result.Action == picker.ActionCancel {
-				os.Exit(0) // User cancelled
-			}
-			if result.Action == picker.ActionSkip {
-				// Remove binding and flag from command
-				finalCommand = binding.RemoveWithFlag(finalCommand, b)
-				continue
-			}
-			selected = result.Value
-		}
-
-		finalCommand = binding.Resolve(finalCommand, b, selected)
-	}
-
-	// Append extra args if provided
-	if extraArgs != "" {
-		finalCommand = finalCommand + " " + extraArgs
+	r := resolveBindings(cmd.Command, set).withExtra(extraArgs)
+	if printOnly {
+		fmt.Println(r.display)
+		return
 	}
+	run(r, cmd)
+}
 
+// run saves a resolved command to history and executes it
+func run(r resolution, saved *config.Command) {
 	// Save to history for 'lz last'
-	config.AddHistoryEntry(finalCommand, cmd.Name)
+	config.AddHistoryEntry(r.history, saved.Name)
 
-	fmt.Printf("Running: %s\n", finalCommand)
+	fmt.Printf("Running: %s\n", r.display)
 	fmt.Println(strings.Repeat("-", 40))
 
-	shellPath := os.Getenv("SHELL")
-	if shellPath == "" {
-		shellPath = "/bin/sh"
-	}
+	execute(r.command, saved, r.env)
+}
 
-	execCmd := exec.Command(shellPath, "-c", finalCommand)
+// execute runs a resolved command through the user's shell
+// Uses the saved command's working directory and environment when set,
+// passes secrets as extra environment variables, and exits with the command's exit code if it fails
+func execute(command string, saved *config.Command, secrets map[string]string) {
+	execCmd := exec.Command(commandShell(), "-c", command)
 	execCmd.Stdin = os.Stdin
 	execCmd.Stdout = os.Stdout
 	execCmd.Stderr = os.Stderr
 
+	if saved != nil {
+		if saved.Cwd != "" {
+			execCmd.Dir = expandHome(saved.Cwd)
+		}
+		if len(saved.Env) > 0 {
+			execCmd.Env = os.Environ()
+			for k, v := range saved.Env {
+				execCmd.Env = append(execCmd.Env, k+"="+v)
+			}
+		}
+	}
+	if len(secrets) > 0 {
+		if execCmd.Env == nil {
+			execCmd.Env = os.Environ()
+		}
+		for k, v := range secrets {
+			execCmd.Env = append(execCmd.Env, k+"="+v)
+		}
+	}
+
 	if err := execCmd.Run(); err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			os.Exit(exitErr.ExitCode())
@@ -1002,6 +1040,26 @@ func cmdRun(args []string) {
 	}
 }
 
+// commandShell returns the shell commands are run with: $SHELL, or /bin/sh
+func commandShell() string {
+	if shellPath := os.Getenv("SHELL"); shellPath != "" {
+		return shellPath
+	}
+	return "/bin/sh"
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
 func cmdRemove(args []string) {
 	if len(args) < 1 {
 		fmt.Fprintln(os.Stderr, "Error: name required")
@@ -1017,6 +1075,11 @@ func cmdRemove(args []string) {
 		os.Exit(1)
 	}
 
+	if isLibraryCommand(cfg, name) {
+		copyFromLibrary(cfg, name)
+		return
+	}
+
 	if err := cfg.RemoveCommandByName(name); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
@@ -1070,24 +1133,3 @@ func formatTags(tags []string) string {
 	}
 	return "[" + strings.Join(tags, ", ") + "]"
 }
-
-func isValidAliasName(name string) bool {
-	if len(name) == 0 {
-		return false
-	}
-
-	// Must start with letter or underscore
-	first := name[0]
-	if !((first >= 'a' && first <= 'z') || (first >= 'A' && first <= 'Z') || first == '_') {
-		return false
-	}
-
-	// Rest must be alphanumeric or underscore
-	for _, c := range name[1:] {
-		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_') {
-			return false
-		}
-	}
-
-	return true
-}
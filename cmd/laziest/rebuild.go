@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"laziest/internal/binding"
+	"laziest/internal/builder"
+	"laziest/internal/config"
+	"laziest/internal/shell"
+)
+
+func cmdRebuild(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Error: name required")
+		fmt.Fprintln(os.Stderr, "Usage: lz rebuild <name>")
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if _, err := cfg.GetCommandByName(args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	rebuildCommand(cfg, args[0])
+}
+
+// rebuildCommand walks a saved command through the interactive builder
+// Used by 'lz rebuild' and the picker's 'b' key
+func rebuildCommand(cfg *config.Config, name string) {
+	name, ok := copyFromLibrary(cfg, name)
+	if !ok {
+		return
+	}
+
+	cmd, err := cfg.GetCommandByName(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	result, err := builder.Rebuild(cmd.Command)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	if result.Cancelled {
+		fmt.Println("Cancelled.")
+		return
+	}
+	if result.Command == cmd.Command {
+		fmt.Println("No changes.")
+		return
+	}
+
+	fmt.Println(strings.Repeat("-", 40))
+	fmt.Printf("\033[1mGenerated command:\033[0m\n  %s\n\n", result.Command)
+
+	bindings, err := binding.Parse(result.Command)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	for _, b := range bindings {
+		for _, warning := range binding.Validate(b) {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+	}
+
+	if err := cfg.UpdateCommand(name, name, result.Command, cmd.Tags); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	if err := cfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := shell.UpdateAliases(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	fmt.Printf("Modified '%s'\n", name)
+}
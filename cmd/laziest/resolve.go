@@ -0,0 +1,354 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"laziest/internal/binding"
+	"laziest/internal/picker"
+	"laziest/internal/source"
+)
+
+// resolution is a command with its bindings filled in
+type resolution struct {
+	command string            // Command to execute
+	display string            // Shown in "Running:" and --print, with secrets redacted
+	history string            // Saved to history, with secret bindings left in place
+	env     map[string]string // Secret values, passed to the command's environment
+}
+
+// resolveBindings asks for a value for each binding in command
+// Values in set (from --set) are used without asking, keyed by binding.Key or
+// by the binding's position from 1. Secret values never enter the command
+// text: they are passed as environment variables and referenced from the
+// command. Exits if the user cancels or a set value is invalid.
+func resolveBindings(command string, set map[string]string) resolution {
+	r := resolution{command: command, display: command, history: command}
+
+	bindings, err := binding.Parse(command)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing bindings: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Commands saved before a keyword existed may mean the directory it shadows
+	for _, b := range bindings {
+		if dir := binding.ShadowedDirectory(b); dir != "" {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", binding.ShadowWarning(b, dir))
+		}
+	}
+
+	preset, err := presetValues(command, bindings, set)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Apply the same change to every form of the command
+	remove := func(b binding.Binding) {
+		r.command = binding.RemoveWithFlag(r.command, b)
+		r.display = binding.RemoveWithFlag(r.display, b)
+		r.history = binding.RemoveWithFlag(r.history, b)
+	}
+
+	secrets := 0
+	for i, b := range bindings {
+		var selected string
+		prompt := binding.ExtractPromptContext(r.display, b)
+		envValue, fromEnv := binding.EnvValue(b)
+		if fromEnv && b.Type == binding.BindingInput {
+			if err := b.Input.Check(envValue); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: ignoring $%s: %v\n", b.Env, err)
+				fromEnv = false
+			}
+		}
+
+		if value, ok := preset[i]; ok {
+			// Given with --set, already checked
+			skip := value == "" && b.Optional
+			if b.Type == binding.BindingBooleanFlag {
+				include, _ := parseYesNo(value)
+				skip, value = !include, ""
+			}
+			if skip {
+				remove(b)
+				continue
+			}
+			selected = value
+
+		} else if fromEnv && b.Type != binding.BindingSecret {
+			// Set in the environment or has a default: no need to ask
+			selected = envValue
+
+		} else if b.Type == binding.BindingDirectory {
+			var result picker.PickResult
+			if b.Find.Browse {
+				result = browse(b, prompt)
+			} else {
+				// List files and show picker
+				files, err := binding.ListFiles(b)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+
+				// Directory pickers can also create a new directory
+				result = picker.PickStringWith(files, prompt, picker.StringOptions{
+					Optional:     b.Optional,
+					AllowCustom:  b.Find.Dirs,
+					CustomLabel:  "[New directory]",
+					CustomPrompt: fmt.Sprintf("New directory in %s: ", b.Path),
+				})
+			}
+			if result.Action == picker.ActionCancel {
+				os.Exit(0) // User cancelled
+			}
+			if result.Action == picker.ActionSkip {
+				// Remove binding and flag from command
+				remove(b)
+				continue
+			}
+			if result.Action == picker.ActionCustom {
+				selected, err = binding.CreateDir(b, result.Value)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Printf("Created %s\n", selected)
+			} else {
+				// Use absolute path
+				selected = binding.GetAbsolutePath(b, result.Value)
+			}
+
+		} else if b.Type == binding.BindingBooleanFlag {
+			// Handle optional boolean flag - ask yes/no to include
+			include, ok := picker.PromptYesNo(prompt)
+			if !ok {
+				os.Exit(0) // User cancelled
+			}
+			if !include {
+				// User chose not to include - remove the flag
+				remove(b)
+				continue
+			}
+			// User chose to include - resolve with empty value (just the flag)
+			selected = ""
+
+		} else if b.Type == binding.BindingSecret {
+			if b.Optional {
+				prompt += " (empty to skip)"
+			}
+			value, cancelled := envValue, false
+			if !fromEnv {
+				value, cancelled = picker.PromptSecret(prompt + " ")
+			}
+			for !cancelled && value == "" && !b.Optional {
+				value, cancelled = picker.PromptSecret(prompt + " ")
+			}
+			if cancelled {
+				os.Exit(0) // User cancelled
+			}
+			if value == "" {
+				remove(b)
+				continue
+			}
+
+			if r.env == nil {
+				r.env = make(map[string]string)
+			}
+			if b.EnvVar != "" {
+				// Only in the environment: drop the placeholder
+				r.env[b.EnvVar] = value
+				r.command = binding.RemoveWithFlag(r.command, b)
+				r.display = b.EnvVar + "=" + binding.Redacted + " " + binding.RemoveWithFlag(r.display, b)
+			} else {
+				secrets++
+				envVar := fmt.Sprintf("LZ_SECRET_%d", secrets)
+				r.env[envVar] = value
+				r.command = binding.ResolveSecret(r.command, b, envVar, filepath.Base(commandShell()) == "fish")
+				r.display = binding.Resolve(r.display, b, binding.Redacted)
+			}
+			// History keeps the binding so the secret is asked for again
+			continue
+
+		} else if b.Type == binding.BindingInput {
+			check := b.Input.Check
+			if b.Optional {
+				prompt += " (empty to skip)"
+				check = func(value string) error {
+					if value == "" {
+						return nil
+					}
+					return b.Input.Check(value)
+				}
+			}
+			value, cancelled := picker.PromptValidated(prompt+" ", "", check)
+			if cancelled {
+				os.Exit(0) // User cancelled
+			}
+			if value == "" {
+				remove(b)
+				continue
+			}
+			selected = value
+
+		} else if b.Type == binding.BindingRange {
+			// Values are generated as they scroll into view
+			result := picker.PickIndexed(b.Range.Len(), b.Range.At, prompt, picker.IndexedOptions{
+				Optional: b.Optional,
+				Validate: b.Range.Check,
+			})
+			if result.Action == picker.ActionCancel {
+				os.Exit(0) // User cancelled
+			}
+			if result.Action == picker.ActionSkip {
+				remove(b)
+				continue
+			}
+			selected = result.Value
+
+		} else if b.Type == binding.BindingSource {
+			// Listed now, e.g. the repository's branches
+			items, err := source.List(b.Source)
+			if err != nil && !b.AllowCustom {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+			if len(items) == 0 && !b.AllowCustom {
+				fmt.Fprintf(os.Stderr, "Error: %s: nothing to pick from\n", b.Source.Text)
+				os.Exit(1)
+			}
+
+			values := make([]string, len(items))
+			hints := make([]string, len(items))
+			current := ""
+			for i, item := range items {
+				values[i], hints[i] = item.Value, item.Hint
+				if item.Current {
+					current = item.Value
+				}
+			}
+			result := picker.PickStringWith(values, prompt, picker.StringOptions{
+				Optional:    b.Optional,
+				AllowCustom: b.AllowCustom,
+				Hints:       hints,
+				Default:     current,
+			})
+			if result.Action == picker.ActionCancel {
+				os.Exit(0) // User cancelled
+			}
+			if result.Action == picker.ActionSkip {
+				remove(b)
+				continue
+			}
+			selected = result.Value
+
+		} else { // BindingValues
+			result := picker.PickString(b.Values, prompt, b.Optional, b.AllowCustom)
+			if result.Action == picker.ActionCancel {
+				os.Exit(0) // User cancelled
+			}
+			if result.Action == picker.ActionSkip {
+				// Remove binding and flag from command
+				remove(b)
+				continue
+			}
+			selected = result.Value
+		}
+
+		r.command = binding.Resolve(r.command, b, selected)
+		r.display = binding.Resolve(r.display, b, selected)
+		r.history = binding.Resolve(r.history, b, selected)
+	}
+
+	return r
+}
+
+// browse shows the file browser for a directory binding with the browse option
+func browse(b binding.Binding, prompt string) picker.PickResult {
+	list := func(dir string) ([]picker.BrowseEntry, error) {
+		entries, err := binding.ReadLevel(b, dir)
+		if err != nil {
+			return nil, err
+		}
+		shown := make([]picker.BrowseEntry, len(entries))
+		for i, e := range entries {
+			shown[i] = picker.BrowseEntry(e)
+		}
+		return shown, nil
+	}
+	return picker.Browse(b.Path, list, prompt, picker.BrowseOptions{
+		Optional: b.Optional,
+		Dirs:     b.Find.Dirs,
+		Up:       b.Find.Up,
+		NewDir:   b.Find.Dirs,
+
+		CheckNewDir: binding.CheckNewDirName,
+	})
+}
+
+// presetValues matches --set values to the bindings they are for and checks them
+// Returns the values by binding index.
+func presetValues(command string, bindings []binding.Binding, set map[string]string) (map[int]string, error) {
+	preset := make(map[int]string)
+	for key, value := range set {
+		found := -1
+		var known []string
+		for i, b := range bindings {
+			name := binding.Key(command, b)
+			if key == name || key == strconv.Itoa(i+1) {
+				found = i
+			}
+			if name != "" {
+				known = append(known, name)
+			}
+		}
+		if found == -1 {
+			if len(bindings) == 0 {
+				return nil, fmt.Errorf("--set %s: the command has no bindings", key)
+			}
+			known = append(known, fmt.Sprintf("1-%d", len(bindings)))
+			return nil, fmt.Errorf("--set %s: no such binding (use %s)", key, strings.Join(known, ", "))
+		}
+
+		b := bindings[found]
+		if value == "" && b.Optional {
+			// Empty skips an optional binding
+		} else if b.Type == binding.BindingBooleanFlag {
+			if _, err := parseYesNo(value); err != nil {
+				return nil, fmt.Errorf("--set %s: %v", key, err)
+			}
+		} else if err := binding.CheckValue(b, value); err != nil {
+			return nil, fmt.Errorf("--set %s: %v", key, err)
+		}
+		preset[found] = value
+	}
+	return preset, nil
+}
+
+// parseYesNo parses the value given for a boolean flag
+func parseYesNo(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "y", "yes", "true", "1":
+		return true, nil
+	case "n", "no", "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("'%s' is not yes or no", value)
+}
+
+// withExtra appends extra arguments to every form of the command
+func (r resolution) withExtra(extra string) resolution {
+	if extra != "" {
+		r.command += " " + extra
+		r.display += " " + extra
+		r.history += " " + extra
+	}
+	return r
+}
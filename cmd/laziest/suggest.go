@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"laziest/internal/binding"
+	"laziest/internal/config"
+	"laziest/internal/picker"
+	"laziest/internal/shell"
+	"laziest/internal/shellhist"
+	"laziest/internal/suggest"
+)
+
+func cmdSuggest(args []string) {
+	var opts suggest.Options
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--min":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --min requires a number")
+				os.Exit(1)
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n < 2 {
+				fmt.Fprintf(os.Stderr, "Error: invalid --min '%s': must be a number of at least 2\n", args[i+1])
+				os.Exit(1)
+			}
+			opts.MinCount = n
+			i++
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unknown option '%s'\n", args[i])
+			fmt.Fprintln(os.Stderr, "Usage: lz suggest [--min <count>]")
+			os.Exit(1)
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	entries, err := shellhist.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	existing := make([]string, len(cfg.Commands))
+	for i, cmd := range cfg.Commands {
+		existing[i] = cmd.Command
+	}
+
+	suggestions := suggest.Analyze(entries, existing, opts)
+	if len(suggestions) == 0 {
+		fmt.Println("No repeated commands found in shell history.")
+		return
+	}
+
+	added := 0
+	for i, s := range suggestions {
+		fmt.Printf("\n\033[1m[%d/%d] Seen %d times\033[0m\n", i+1, len(suggestions), s.Count)
+		for _, example := range s.Examples {
+			fmt.Printf("\033[2m  %s\033[0m\n", strings.ReplaceAll(example, "\n", " ⏎ "))
+		}
+		fmt.Printf("Suggested: %s\n\n", s.Command)
+
+		options := []string{
+			"Save",
+			"Edit the command, then save",
+			"Skip",
+			"Stop",
+		}
+		idx := picker.PickOption("Save this command?", options)
+		if idx == -1 || idx == 3 {
+			break
+		}
+		if idx == 2 {
+			continue
+		}
+
+		command := s.Command
+		if idx == 1 {
+			edited, cancelled := picker.PromptInput("Command: ", command)
+			if cancelled || edited == "" {
+				continue
+			}
+			command = edited
+		}
+
+		if saveSuggestion(cfg, s, command) {
+			added++
+		}
+	}
+
+	if added == 0 {
+		return
+	}
+
+	if err := cfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := shell.UpdateAliases(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	fmt.Printf("\nAdded %d command(s)\n", added)
+}
+
+// saveSuggestion asks for a name and tags and adds the command to cfg
+// Returns false if the user cancelled or the command couldn't be added
+func saveSuggestion(cfg *config.Config, s suggest.Suggestion, command string) bool {
+	bindings, err := binding.Parse(command)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return false
+	}
+	for _, b := range bindings {
+		for _, warning := range binding.Validate(b) {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+	}
+
+	name, cancelled := picker.PromptInput("Command name: ", s.Name)
+	if cancelled || name == "" {
+		return false
+	}
+	if !config.IsValidName(name) {
+		fmt.Fprintf(os.Stderr, "Error: invalid alias name '%s'\n", name)
+		fmt.Fprintln(os.Stderr, "Name must start with a letter and contain only letters, numbers, and underscores")
+		return false
+	}
+
+	tagsInput, _ := picker.PromptInput("Tags (comma-separated, optional): ", "")
+	var tags []string
+	for _, t := range strings.Split(tagsInput, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+
+	if err := cfg.AddCommand(name, command, tags); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return false
+	}
+	fmt.Printf("Added '%s'\n", name)
+	return true
+}
@@ -5,8 +5,9 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
-	"sort"
 	"strings"
+
+	"laziest/internal/source"
 )
 
 // BindingType represents the type of dynamic binding
@@ -16,18 +17,41 @@ const (
 	BindingDirectory BindingType = iota
 	BindingValues
 	BindingBooleanFlag // Optional flag with no value (e.g., {%?--verbose%})
+	BindingSecret      // Masked input kept out of output and history (e.g., {%secret%})
+	BindingInput       // Typed input validated before use (e.g., {%int(1,100)%})
+	BindingRange       // Numeric sequence picked from (e.g., {%range(0,10)%})
+	BindingSource      // Values listed by a built-in source when run (e.g., {%git:branches%})
 )
 
+// Redacted replaces secret values wherever a command is shown
+const Redacted = "****"
+
 // Binding represents a dynamic placeholder in a command
 type Binding struct {
 	Type        BindingType
-	Path        string   // For directory bindings (absolute path)
-	Filter      string   // Glob filter for directory bindings (e.g., "*.yaml")
-	Values      []string // For value bindings
-	Placeholder string   // The original placeholder text e.g. "{%/configs:*.yaml%}"
-	Optional    bool     // True if binding starts with ? (e.g., {%?...%})
-	Flag        string   // Optional flag prefix (e.g., "--debug" from {%--debug:[...]%})
-	AllowCustom bool     // True if binding allows custom input (has ... in values)
+	Path        string       // For directory bindings (absolute path)
+	Filter      string       // Glob filter for directory bindings (e.g., "*.yaml")
+	Values      []string     // For value bindings
+	Placeholder string       // The original placeholder text e.g. "{%/configs:*.yaml%}"
+	Optional    bool         // True if binding starts with ? (e.g., {%?...%})
+	Flag        string       // Optional flag prefix (e.g., "--debug" from {%--debug:[...]%})
+	Joined      bool         // True if flag and value are joined with = (e.g., {%--lr=[...]%} -> --lr=0.1)
+	AllowCustom bool         // True if binding allows custom input (has ... in values)
+	EnvVar      string       // For secret bindings: pass the value in this environment variable instead of argv
+	Env         string       // Use this environment variable's value when set (e.g., {%env:AWS_PROFILE|[dev,prod]%})
+	Default     string       // Literal value used when Env is unset (e.g., {%env:REGION|us-east-1%})
+	Input       *Input       // For typed input bindings: what the value must look like
+	Range       *Range       // For range bindings
+	Find        FindOptions  // For directory bindings: what to list, parsed from Filter
+	Source      *source.Spec // For source bindings: what to list
+}
+
+// envVarPattern matches a valid environment variable name
+var envVarPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// IsEnvVarName reports whether name is a valid environment variable name
+func IsEnvVarName(name string) bool {
+	return envVarPattern.MatchString(name)
 }
 
 // bindingPattern matches {%...%} placeholders
@@ -69,6 +93,7 @@ func parseContent(content, placeholder string) (Binding, error) {
 
 	optional := false
 	flag := ""
+	joined := false
 
 	// Check for optional prefix: ?
 	if strings.HasPrefix(content, "?") {
@@ -79,12 +104,16 @@ func parseContent(content, placeholder string) (Binding, error) {
 		}
 	}
 
-	// Check for flag prefix: --flag: or -f:
+	// Check for flag prefix: --flag: or -f: (or --flag= to emit --flag=value)
 	// Flag must come before [ or /
-	flagPattern := regexp.MustCompile(`^(-{1,2}[\w-]+):\s*`)
+	flagPattern := regexp.MustCompile(`^(-{1,2}[\w-]+)([:=])\s*`)
 	if match := flagPattern.FindStringSubmatch(content); match != nil {
 		flag = match[1]
+		joined = match[2] == "="
 		content = strings.TrimSpace(content[len(match[0]):])
+		if content == "" && joined {
+			return Binding{}, fmt.Errorf("flag joined with = needs a value: %s", placeholder)
+		}
 		if content == "" {
 			// Boolean flag binding: {%?--verbose%} -> just the flag, no value
 			// Must be optional to make sense (include or skip)
@@ -115,6 +144,76 @@ func parseContent(content, placeholder string) (Binding, error) {
 		}, nil
 	}
 
+	// Check if it's an environment binding: env:VAR|fallback
+	if strings.HasPrefix(content, "env:") {
+		return parseEnv(content, placeholder, optional, flag, joined)
+	}
+
+	// Check if it's a typed input: int(1,100), float, regex:..., path, url, date
+	if isInputSpec(content) {
+		input, err := parseInput(content)
+		if err != nil {
+			return Binding{}, fmt.Errorf("%v: %s", err, placeholder)
+		}
+		return Binding{
+			Type:        BindingInput,
+			Placeholder: placeholder,
+			Optional:    optional,
+			Flag:        flag,
+			Joined:      joined,
+			Input:       input,
+		}, nil
+	}
+
+	// Check if it's a range: range(0,10), range(0,100,5) or range(1,64,*2)
+	if isRangeSpec(content) {
+		r, err := parseRange(content)
+		if err != nil {
+			return Binding{}, fmt.Errorf("%v: %s", err, placeholder)
+		}
+		return Binding{
+			Type:        BindingRange,
+			Placeholder: placeholder,
+			Optional:    optional,
+			Flag:        flag,
+			Joined:      joined,
+			Range:       r,
+		}, nil
+	}
+
+	// Check if it's a source: git:branches, or git:branches,... to allow typing a value
+	if spec, custom := strings.CutSuffix(content, ",..."); source.IsSpec(spec) {
+		src, err := source.Parse(spec)
+		if err != nil {
+			return Binding{}, fmt.Errorf("%v: %s", err, placeholder)
+		}
+		return Binding{
+			Type:        BindingSource,
+			Placeholder: placeholder,
+			Optional:    optional,
+			Flag:        flag,
+			Joined:      joined,
+			AllowCustom: custom,
+			Source:      src,
+		}, nil
+	}
+
+	// Check if it's a secret binding: secret or secret:VAR
+	if isSecretSpec(content) {
+		envVar := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(content, "secret"), ":"))
+		if envVar != "" && flag != "" {
+			return Binding{}, fmt.Errorf("secret passed as environment variable can't have a flag: %s", placeholder)
+		}
+		return Binding{
+			Type:        BindingSecret,
+			Placeholder: placeholder,
+			Optional:    optional,
+			Flag:        flag,
+			Joined:      joined,
+			EnvVar:      envVar,
+		}, nil
+	}
+
 	// Check if it's a value binding: [val1,val2,...]
 	if strings.HasPrefix(content, "[") && strings.HasSuffix(content, "]") {
 		inner := content[1 : len(content)-1]
@@ -151,6 +250,7 @@ func parseContent(content, placeholder string) (Binding, error) {
 			Placeholder: placeholder,
 			Optional:    optional,
 			Flag:        flag,
+			Joined:      joined,
 			AllowCustom: allowCustom,
 		}, nil
 	}
@@ -183,16 +283,88 @@ func parseContent(content, placeholder string) (Binding, error) {
 		}
 	}
 
+	find, err := parseFindOptions(filter)
+	if err != nil {
+		return Binding{}, fmt.Errorf("%v: %s", err, placeholder)
+	}
+
 	return Binding{
 		Type:        BindingDirectory,
 		Path:        path,
 		Filter:      filter,
+		Find:        find,
 		Placeholder: placeholder,
 		Optional:    optional,
 		Flag:        flag,
+		Joined:      joined,
 	}, nil
 }
 
+// isSecretSpec reports whether content is a secret binding: secret, or
+// secret:VAR with a valid variable name. Anything else after "secret:" is
+// a filter on a relative directory named secret, e.g. secret:*.yaml, as
+// it was before secrets existed.
+func isSecretSpec(content string) bool {
+	if content == "secret" {
+		return true
+	}
+	envVar, ok := strings.CutPrefix(content, "secret:")
+	return ok && envVarPattern.MatchString(strings.TrimSpace(envVar))
+}
+
+// parseEnv parses an environment binding: env:VAR, env:VAR|default or
+// env:VAR|<binding>, where the fallback is a value list, a range, a typed input,
+// a source, a secret or a directory starting with /, ~ or . that is used when VAR is unset
+func parseEnv(content, placeholder string, optional bool, flag string, joined bool) (Binding, error) {
+	name, fallback, _ := strings.Cut(strings.TrimPrefix(content, "env:"), "|")
+	name = strings.TrimSpace(name)
+	fallback = strings.TrimSpace(fallback)
+	if !envVarPattern.MatchString(name) {
+		return Binding{}, fmt.Errorf("invalid environment variable name '%s': %s", name, placeholder)
+	}
+
+	var b Binding
+	switch {
+	case fallback == "":
+		// No fallback: type a value
+		b = Binding{Type: BindingValues, AllowCustom: true}
+	case strings.HasPrefix(fallback, "[") || isSecretSpec(fallback) || isInputSpec(fallback) || isRangeSpec(fallback) || source.IsSpec(strings.TrimSuffix(fallback, ",...")) ||
+		strings.HasPrefix(fallback, "/") || strings.HasPrefix(fallback, "~") || strings.HasPrefix(fallback, "."):
+		var err error
+		b, err = parseContent(fallback, placeholder)
+		if err != nil {
+			return Binding{}, err
+		}
+		if b.EnvVar != "" && flag != "" {
+			return Binding{}, fmt.Errorf("secret passed as environment variable can't have a flag: %s", placeholder)
+		}
+	default:
+		b = Binding{Type: BindingValues, Default: fallback}
+	}
+
+	b.Placeholder = placeholder
+	b.Optional = optional
+	b.Flag = flag
+	b.Joined = joined
+	b.Env = name
+	return b, nil
+}
+
+// EnvValue returns the value an environment binding resolves to without asking:
+// the variable's value when set and non-empty, otherwise its literal default
+func EnvValue(b Binding) (string, bool) {
+	if b.Env == "" {
+		return "", false
+	}
+	if value := os.Getenv(b.Env); value != "" {
+		return value, true
+	}
+	if b.Default != "" {
+		return b.Default, true
+	}
+	return "", false
+}
+
 // Validate checks if a binding is valid
 // Returns warning messages (not errors) for issues that don't prevent adding
 func Validate(b Binding) []string {
@@ -208,77 +380,41 @@ func Validate(b Binding) []string {
 			warnings = append(warnings, fmt.Sprintf("'%s' is not a directory", b.Path))
 		}
 	}
+	if dir := ShadowedDirectory(b); dir != "" {
+		warnings = append(warnings, ShadowWarning(b, dir))
+	}
 
 	return warnings
 }
 
-// ListFiles returns files in the binding's directory matching the filter
-// Files are returned as relative paths from the directory, sorted alphabetically
-// Searches recursively, skips symlinks
-func ListFiles(b Binding) ([]string, error) {
-	if b.Type != BindingDirectory {
-		return nil, fmt.Errorf("ListFiles called on non-directory binding")
+// ShadowedDirectory returns the directory a bare keyword binding such as
+// {%path%} picked from before the keyword existed, when one by that name is
+// in the working directory, or "" otherwise
+func ShadowedDirectory(b Binding) string {
+	var keyword string
+	switch {
+	case b.Env != "":
+		return ""
+	case b.Type == BindingInput && !strings.ContainsAny(b.Input.Spec, "(:"):
+		keyword = b.Input.Spec
+	case b.Type == BindingSecret && b.EnvVar == "":
+		keyword = "secret"
+	default:
+		return ""
 	}
-
-	info, err := os.Stat(b.Path)
-	if os.IsNotExist(err) {
-		return nil, fmt.Errorf("directory '%s' does not exist", b.Path)
-	}
-	if err != nil {
-		return nil, fmt.Errorf("cannot access '%s': %v", b.Path, err)
-	}
-	if !info.IsDir() {
-		return nil, fmt.Errorf("'%s' is not a directory", b.Path)
-	}
-
-	var files []string
-
-	err = filepath.Walk(b.Path, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // Skip files we can't access
-		}
-
-		// Skip symlinks
-		if info.Mode()&os.ModeSymlink != 0 {
-			return nil
-		}
-
-		// Skip directories
-		if info.IsDir() {
-			return nil
-		}
-
-		// Get relative path
-		relPath, err := filepath.Rel(b.Path, path)
-		if err != nil {
-			return nil
-		}
-
-		// Apply filter if specified
-		if b.Filter != "" {
-			matched, err := filepath.Match(b.Filter, info.Name())
-			if err != nil || !matched {
-				return nil
-			}
-		}
-
-		files = append(files, relPath)
-		return nil
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("error reading directory '%s': %v", b.Path, err)
+	if info, err := os.Stat(keyword); err != nil || !info.IsDir() {
+		return ""
 	}
+	return keyword
+}
 
-	if len(files) == 0 {
-		if b.Filter != "" {
-			return nil, fmt.Errorf("no files found in '%s' matching '%s'", b.Path, b.Filter)
-		}
-		return nil, fmt.Errorf("no files found in '%s'", b.Path)
+// ShadowWarning explains that b is read as a keyword, not the directory dir
+func ShadowWarning(b Binding, dir string) string {
+	kind := "a typed input"
+	if b.Type == BindingSecret {
+		kind = "a secret"
 	}
-
-	sort.Strings(files)
-	return files, nil
+	return fmt.Sprintf("%s is %s, not the directory ./%s; write ./%s to pick from the directory", b.Placeholder, kind, dir, dir)
 }
 
 // GetAbsolutePath returns the absolute path for a selected relative file
@@ -288,12 +424,15 @@ func GetAbsolutePath(b Binding, relativePath string) string {
 
 // Resolve replaces the binding placeholder with the given value in the command
 // If the binding has a flag, it outputs "flag value" (e.g., "--debug True")
+// or "flag=value" for joined flags (e.g., "--lr=0.1")
 // For boolean flag bindings, it just outputs the flag itself
 func Resolve(command string, b Binding, value string) string {
 	var replacement string
 	if b.Type == BindingBooleanFlag {
 		// Boolean flag: just output the flag itself (value is ignored)
 		replacement = b.Flag
+	} else if b.Flag != "" && b.Joined {
+		replacement = b.Flag + "=" + value
 	} else if b.Flag != "" {
 		replacement = b.Flag + " " + value
 	} else {
@@ -302,22 +441,93 @@ func Resolve(command string, b Binding, value string) string {
 	return strings.Replace(command, b.Placeholder, replacement, 1)
 }
 
+// ResolveSecret replaces a secret binding with a reference to the environment
+// variable holding its value, so the value itself never appears in the command
+// The reference is quoted to match its position: "${VAR}" in plain text, ${VAR}
+// inside double quotes, and '"${VAR}"' inside single quotes. fish has no ${VAR}
+// and doesn't split words, so for fish it is "$VAR", with double quotes closed
+// around it, and '"$VAR"'.
+func ResolveSecret(command string, b Binding, envVar string, fish bool) string {
+	idx := strings.Index(command, b.Placeholder)
+	if idx == -1 {
+		return command
+	}
+
+	var ref string
+	switch quote := quoteAt(command, idx); {
+	case fish && quote == '\'':
+		ref = `'"$` + envVar + `"'`
+	case fish:
+		ref = `"$` + envVar + `"`
+	case quote == '"':
+		ref = "${" + envVar + "}"
+	case quote == '\'':
+		ref = `'"${` + envVar + `}"'`
+	default:
+		ref = `"${` + envVar + `}"`
+	}
+	return Resolve(command, b, ref)
+}
+
+// quoteAt returns the quote character in effect at byte offset pos, or 0
+func quoteAt(command string, pos int) byte {
+	quote := byte(0)
+	for i := 0; i < pos; i++ {
+		c := command[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			}
+		case c == '\\':
+			i++ // Escaped character
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		}
+	}
+	return quote
+}
+
 // HasBindings checks if a command string contains any bindings
 func HasBindings(command string) bool {
 	return strings.Contains(command, "{%")
 }
 
 // ExtractPromptContext tries to extract context for the picker prompt
-// Returns something like "Select file for --config" or "Select value for --env"
+// Returns something like "Select file for --config" or "Select value for --env".
+// Environment bindings name their variable: "Select value ($AWS_PROFILE unset):".
 func ExtractPromptContext(command string, b Binding) string {
+	prompt := promptContext(command, b)
+	if b.Env == "" {
+		return prompt
+	}
+	end := len(prompt) - 1 // Before the trailing ":" or "?"
+	return prompt[:end] + " ($" + b.Env + " unset)" + prompt[end:]
+}
+
+// promptContext builds the prompt for ExtractPromptContext
+func promptContext(command string, b Binding) string {
 	// If binding has an explicit flag, use it
 	if b.Flag != "" {
 		if b.Type == BindingDirectory {
-			return fmt.Sprintf("Select file for %s [%s]:", b.Flag, b.Path)
+			return fmt.Sprintf("Select %s for %s [%s]:", b.Find.kind(), b.Flag, b.Path)
 		}
 		if b.Type == BindingBooleanFlag {
 			return fmt.Sprintf("Include %s?", b.Flag)
 		}
+		if b.Type == BindingSecret {
+			return fmt.Sprintf("Secret for %s:", b.Flag)
+		}
+		if b.Type == BindingInput {
+			return fmt.Sprintf("Value for %s (%s):", b.Flag, b.Input)
+		}
+		if b.Type == BindingSource {
+			return fmt.Sprintf("Select %s for %s:", b.Source.Noun(), b.Flag)
+		}
 		return fmt.Sprintf("Select value for %s:", b.Flag)
 	}
 
@@ -327,28 +537,73 @@ func ExtractPromptContext(command string, b Binding) string {
 		return defaultPrompt(b)
 	}
 
-	// Look backwards for a flag (--something or -x)
-	before := command[:idx]
-	before = strings.TrimRight(before, " =")
-
-	// Try to find --flag or -f pattern
-	flagPattern := regexp.MustCompile(`(-{1,2}[\w-]+)\s*$`)
-	match := flagPattern.FindStringSubmatch(before)
-
 	var context string
-	if len(match) > 1 {
-		context = fmt.Sprintf(" for %s", match[1])
+	if flag := flagBefore(command, idx); flag != "" {
+		context = fmt.Sprintf(" for %s", flag)
 	}
 
 	if b.Type == BindingDirectory {
-		return fmt.Sprintf("Select file%s [%s]:", context, b.Path)
+		return fmt.Sprintf("Select %s%s [%s]:", b.Find.kind(), context, b.Path)
+	}
+	if b.Type == BindingSecret && b.EnvVar != "" {
+		return fmt.Sprintf("Secret %s:", b.EnvVar)
+	}
+	if b.Type == BindingSecret {
+		return fmt.Sprintf("Secret%s:", context)
+	}
+	if b.Type == BindingInput {
+		return fmt.Sprintf("Value%s (%s):", context, b.Input)
+	}
+	if b.Type == BindingSource {
+		return fmt.Sprintf("Select %s%s:", b.Source.Noun(), context)
 	}
 	return fmt.Sprintf("Select value%s:", context)
 }
 
+// flagBefore returns the flag written just before offset idx in command, if any
+func flagBefore(command string, idx int) string {
+	// Look backwards for a flag (--something or -x)
+	before := strings.TrimRight(command[:idx], " =")
+	if match := flagBeforePattern.FindStringSubmatch(before); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// flagBeforePattern matches a --flag or -f at the end of a string
+var flagBeforePattern = regexp.MustCompile(`(-{1,2}\w[\w-]*)\s*$`)
+
+// Key returns the name a binding can be given a value by, e.g. with --set:
+// its flag without dashes, or else the environment variable it reads.
+// Returns "" for bindings with neither.
+func Key(command string, b Binding) string {
+	flag := b.Flag
+	if flag == "" {
+		if idx := strings.Index(command, b.Placeholder); idx != -1 {
+			flag = flagBefore(command, idx)
+		}
+	}
+	switch {
+	case flag != "":
+		return strings.TrimLeft(flag, "-")
+	case b.Env != "":
+		return b.Env
+	}
+	return b.EnvVar
+}
+
 func defaultPrompt(b Binding) string {
 	if b.Type == BindingDirectory {
-		return fmt.Sprintf("Select file [%s]:", b.Path)
+		return fmt.Sprintf("Select %s [%s]:", b.Find.kind(), b.Path)
+	}
+	if b.Type == BindingSecret {
+		return "Secret:"
+	}
+	if b.Type == BindingInput {
+		return fmt.Sprintf("Value (%s):", b.Input)
+	}
+	if b.Type == BindingSource {
+		return fmt.Sprintf("Select %s:", b.Source.Noun())
 	}
 	return "Select value:"
 }
@@ -0,0 +1,782 @@
+package binding
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		expected Binding
+	}{
+		{
+			name:    "value binding with flag",
+			command: "python train.py {%--debug:[True,False]%}",
+			expected: Binding{
+				Type:   BindingValues,
+				Values: []string{"True", "False"},
+				Flag:   "--debug",
+			},
+		},
+		{
+			name:    "joined flag",
+			command: "python train.py {%?--lr=[0.1,0.01,...]%}",
+			expected: Binding{
+				Type:        BindingValues,
+				Values:      []string{"0.1", "0.01"},
+				Flag:        "--lr",
+				Joined:      true,
+				Optional:    true,
+				AllowCustom: true,
+			},
+		},
+		{
+			name:    "optional boolean flag",
+			command: "ls {%?-la%}",
+			expected: Binding{
+				Type:     BindingBooleanFlag,
+				Flag:     "-la",
+				Optional: true,
+			},
+		},
+		{
+			name:    "directory binding with filter",
+			command: "cat {%/etc:*.conf%}",
+			expected: Binding{
+				Type:   BindingDirectory,
+				Path:   "/etc",
+				Filter: "*.conf",
+			},
+		},
+		{
+			name:    "secret with flag",
+			command: "curl {%--token=secret%} https://api.example.com",
+			expected: Binding{
+				Type:   BindingSecret,
+				Flag:   "--token",
+				Joined: true,
+			},
+		},
+		{
+			name:    "optional secret in environment variable",
+			command: "deploy {%?secret:API_TOKEN%}",
+			expected: Binding{
+				Type:     BindingSecret,
+				Optional: true,
+				EnvVar:   "API_TOKEN",
+			},
+		},
+		{
+			name:    "environment with value fallback",
+			command: "aws s3 ls {%--profile:env:AWS_PROFILE|[dev,staging,prod]%}",
+			expected: Binding{
+				Type:   BindingValues,
+				Values: []string{"dev", "staging", "prod"},
+				Flag:   "--profile",
+				Env:    "AWS_PROFILE",
+			},
+		},
+		{
+			name:    "environment with literal default",
+			command: "aws --region={%env:AWS_REGION|us-east-1%} s3 ls",
+			expected: Binding{
+				Type:    BindingValues,
+				Env:     "AWS_REGION",
+				Default: "us-east-1",
+			},
+		},
+		{
+			name:    "environment without fallback",
+			command: "kubectl --context {%?env:KUBE_CONTEXT%} get pods",
+			expected: Binding{
+				Type:        BindingValues,
+				Env:         "KUBE_CONTEXT",
+				Optional:    true,
+				AllowCustom: true,
+			},
+		},
+		{
+			name:    "source with custom input",
+			command: "git checkout {%?--branch:git:branches,...%}",
+			expected: Binding{
+				Type:        BindingSource,
+				Flag:        "--branch",
+				Optional:    true,
+				AllowCustom: true,
+			},
+		},
+		{
+			name:    "source as environment fallback",
+			command: "git push {%env:REMOTE|git:remotes%}",
+			expected: Binding{
+				Type: BindingSource,
+				Env:  "REMOTE",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bindings, err := Parse(tt.command)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(bindings) != 1 {
+				t.Fatalf("expected 1 binding, got %d", len(bindings))
+			}
+			b := bindings[0]
+			exp := tt.expected
+
+			if b.Type != exp.Type {
+				t.Errorf("expected type %v, got %v", exp.Type, b.Type)
+			}
+			if b.Flag != exp.Flag {
+				t.Errorf("expected flag %q, got %q", exp.Flag, b.Flag)
+			}
+			if b.Joined != exp.Joined {
+				t.Errorf("expected Joined %v, got %v", exp.Joined, b.Joined)
+			}
+			if b.Optional != exp.Optional {
+				t.Errorf("expected Optional %v, got %v", exp.Optional, b.Optional)
+			}
+			if b.AllowCustom != exp.AllowCustom {
+				t.Errorf("expected AllowCustom %v, got %v", exp.AllowCustom, b.AllowCustom)
+			}
+			if b.EnvVar != exp.EnvVar {
+				t.Errorf("expected EnvVar %q, got %q", exp.EnvVar, b.EnvVar)
+			}
+			if b.Env != exp.Env || b.Default != exp.Default {
+				t.Errorf("expected env %q default %q, got %q %q", exp.Env, exp.Default, b.Env, b.Default)
+			}
+			if b.Path != exp.Path || b.Filter != exp.Filter {
+				t.Errorf("expected path %q filter %q, got %q %q", exp.Path, exp.Filter, b.Path, b.Filter)
+			}
+			if len(b.Values) != len(exp.Values) {
+				t.Fatalf("expected values %v, got %v", exp.Values, b.Values)
+			}
+			for i := range b.Values {
+				if b.Values[i] != exp.Values[i] {
+					t.Errorf("expected values %v, got %v", exp.Values, b.Values)
+				}
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	commands := []string{
+		"echo {% %}",
+		"echo {%[]%}",
+		"echo {%--verbose%}",
+		"echo {%--lr=%}",
+		"echo {%--token:secret:TOKEN%}",
+		"echo {%env:1PROFILE|[a,b]%}",
+		"echo {%env:PROFILE|[]%}",
+		"echo {%int(10,1)%}",
+		"echo {%int(1.5,)%}",
+		"echo {%regex:[a-%}",
+		"echo {%range(0,10,0)%}",
+		"echo {%range(0,10,-1)%}",
+		"echo {%range(0,64,*2)%}",
+		"echo {%range(a,b)%}",
+		"echo {%/tmp:dirs,depth=0%}",
+		"echo {%/tmp:*.yaml,![%}",
+		"echo {%/tmp:sort=size%}",
+		"echo {%/tmp:dirs,sort=size%}",
+		"echo {%git:branches:[x%}",
+		"echo {%git:tags:%}",
+	}
+	for _, cmd := range commands {
+		if _, err := Parse(cmd); err == nil {
+			t.Errorf("expected error for %q", cmd)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		command  string
+		value    string
+		expected string
+	}{
+		{"train {%--epochs:[10,20]%}", "10", "train --epochs 10"},
+		{"train {%--lr=[0.1,0.01]%}", "0.1", "train --lr=0.1"},
+		{"train --config={%[a,b]%}", "a", "train --config=a"},
+		{"ls {%?-la%}", "", "ls -la"},
+	}
+
+	for _, tt := range tests {
+		bindings, err := Parse(tt.command)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", tt.command, err)
+		}
+		got := Resolve(tt.command, bindings[0], tt.value)
+		if got != tt.expected {
+			t.Errorf("Resolve(%q, %q) = %q, expected %q", tt.command, tt.value, got, tt.expected)
+		}
+	}
+}
+
+func TestResolveSecret(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{"curl -u admin:{%secret%} host", `curl -u admin:"${LZ_SECRET_1}" host`},
+		{`curl -H "Authorization: Bearer {%secret%}" host`, `curl -H "Authorization: Bearer ${LZ_SECRET_1}" host`},
+		{"psql 'password={%secret%}'", `psql 'password='"${LZ_SECRET_1}"''`},
+		{"login {%--password=secret%}", `login --password="${LZ_SECRET_1}"`},
+	}
+
+	for _, tt := range tests {
+		bindings, err := Parse(tt.command)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", tt.command, err)
+		}
+		got := ResolveSecret(tt.command, bindings[0], "LZ_SECRET_1", false)
+		if got != tt.expected {
+			t.Errorf("ResolveSecret(%q) = %q, expected %q", tt.command, got, tt.expected)
+		}
+	}
+
+	// fish has no ${VAR}, and its unquoted variables are not split
+	fish := []struct {
+		command  string
+		expected string
+	}{
+		{"curl -u admin:{%secret%} host", `curl -u admin:"$LZ_SECRET_1" host`},
+		{`curl -H "Authorization: Bearer {%secret%}x" host`, `curl -H "Authorization: Bearer "$LZ_SECRET_1"x" host`},
+		{"psql 'password={%secret%}'", `psql 'password='"$LZ_SECRET_1"''`},
+	}
+	for _, tt := range fish {
+		bindings, err := Parse(tt.command)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", tt.command, err)
+		}
+		if got := ResolveSecret(tt.command, bindings[0], "LZ_SECRET_1", true); got != tt.expected {
+			t.Errorf("ResolveSecret(%q) for fish = %q, expected %q", tt.command, got, tt.expected)
+		}
+	}
+}
+
+func TestEnvValue(t *testing.T) {
+	bindings, err := Parse("aws {%--profile:env:LZ_TEST_PROFILE|[dev,prod]%} {%--region:env:LZ_TEST_REGION|us-east-1%}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile, region := bindings[0], bindings[1]
+
+	t.Setenv("LZ_TEST_PROFILE", "")
+	t.Setenv("LZ_TEST_REGION", "")
+	if value, ok := EnvValue(profile); ok {
+		t.Errorf("EnvValue(profile) = %q, expected to ask", value)
+	}
+	if value, ok := EnvValue(region); !ok || value != "us-east-1" {
+		t.Errorf("EnvValue(region) = %q, %v, expected the default", value, ok)
+	}
+	if got := ExtractPromptContext("", profile); got != "Select value for --profile ($LZ_TEST_PROFILE unset):" {
+		t.Errorf("ExtractPromptContext() = %q", got)
+	}
+
+	t.Setenv("LZ_TEST_PROFILE", "staging")
+	t.Setenv("LZ_TEST_REGION", "eu-west-1")
+	if value, ok := EnvValue(profile); !ok || value != "staging" {
+		t.Errorf("EnvValue(profile) = %q, %v, expected staging", value, ok)
+	}
+	if value, ok := EnvValue(region); !ok || value != "eu-west-1" {
+		t.Errorf("EnvValue(region) = %q, %v, expected eu-west-1", value, ok)
+	}
+}
+
+func TestInputCheck(t *testing.T) {
+	tests := []struct {
+		command string
+		valid   []string
+		invalid []string
+	}{
+		{"train {%--epochs:int(1,1000)%}", []string{"1", "1000"}, []string{"0", "1001", "10.5", "ten", ""}},
+		{"train {%--lr=float(0,)%}", []string{"0", "0.001", "3e-4"}, []string{"-0.1", "fast"}},
+		{"deploy {%regex:^v[0-9]+\\.[0-9]+$%}", []string{"v1.2"}, []string{"1.2", "v1"}},
+		{"curl {%url%}", []string{"https://example.com/x"}, []string{"example.com", "/path"}},
+		{"report --since {%date%}", []string{"2024-02-29"}, []string{"2023-02-29", "yesterday"}},
+		{"cat {%path%}", []string{"."}, []string{"/no/such/lz/path"}},
+	}
+
+	for _, tt := range tests {
+		bindings, err := Parse(tt.command)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", tt.command, err)
+		}
+		b := bindings[0]
+		if b.Type != BindingInput {
+			t.Fatalf("%q: expected typed input, got %v", tt.command, b.Type)
+		}
+		for _, v := range tt.valid {
+			if err := CheckValue(b, v); err != nil {
+				t.Errorf("%q: CheckValue(%q) = %v", tt.command, v, err)
+			}
+		}
+		for _, v := range tt.invalid {
+			if err := CheckValue(b, v); err == nil {
+				t.Errorf("%q: CheckValue(%q) = nil, expected an error", tt.command, v)
+			}
+		}
+	}
+
+	bindings, _ := Parse("train {%--epochs:int(1,1000)%}")
+	if got := ExtractPromptContext("", bindings[0]); got != "Value for --epochs (int 1..1000):" {
+		t.Errorf("ExtractPromptContext() = %q", got)
+	}
+}
+
+func TestKey(t *testing.T) {
+	command := "aws {%--profile:env:AWS_PROFILE|[dev,prod]%} --region {%[us,eu]%} {%env:BUCKET%} {%[a,b]%}"
+	bindings, err := Parse(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"profile", "region", "BUCKET", ""}
+	for i, b := range bindings {
+		if got := Key(command, b); got != expected[i] {
+			t.Errorf("Key(%s) = %q, expected %q", b.Placeholder, got, expected[i])
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected []string
+	}{
+		{"range(0,7)", []string{"0", "1", "2", "3", "4", "5", "6", "7"}},
+		{"range(0,100,25)", []string{"0", "25", "50", "75", "100"}},
+		{"range(0,10,4)", []string{"0", "4", "8"}},
+		{"range(3,1)", []string{"3", "2", "1"}},
+		{"range(0,1,0.25)", []string{"0", "0.25", "0.5", "0.75", "1"}},
+		{"range(0.1,0.3,0.1)", []string{"0.1", "0.2", "0.3"}},
+		{"range(1,64,*2)", []string{"1", "2", "4", "8", "16", "32", "64"}},
+		{"range(0.0001,0.1,*10)", []string{"0.0001", "0.001", "0.01", "0.1"}},
+		{"range(64,8,*0.5)", []string{"64", "32", "16", "8"}},
+	}
+
+	for _, tt := range tests {
+		bindings, err := Parse("train {%--x:" + tt.spec + "%}")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.spec, err)
+		}
+		r := bindings[0].Range
+		if bindings[0].Type != BindingRange || r == nil {
+			t.Fatalf("%s: expected a range binding", tt.spec)
+		}
+		got := r.Values()
+		if strings.Join(got, " ") != strings.Join(tt.expected, " ") {
+			t.Errorf("%s = %v, expected %v", tt.spec, got, tt.expected)
+		}
+	}
+
+	// Huge ranges are not expanded up front
+	bindings, err := Parse("seed {%range(0,1000000000)%}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := bindings[0].Range
+	if r.Len() != 1000000001 || r.At(123456789) != "123456789" {
+		t.Errorf("huge range: Len() = %d, At(123456789) = %s", r.Len(), r.At(123456789))
+	}
+	for value, valid := range map[string]bool{"0": true, "999": true, "1000000000": true, "-1": false, "2.5": false, "x": false} {
+		if err := CheckValue(bindings[0], value); (err == nil) != valid {
+			t.Errorf("CheckValue(%q) = %v, expected valid=%v", value, err, valid)
+		}
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, path := range []string{
+		"runs/a/config.yaml",
+		"runs/a/ckpt/last.pt",
+		"runs/b/notes.txt",
+		"runs/c/config.yaml",
+		"top.yaml",
+	} {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sep := string(filepath.Separator)
+	tests := []struct {
+		filter   string
+		expected []string
+	}{
+		{"*.yaml", []string{"runs/a/config.yaml", "runs/c/config.yaml", "top.yaml"}},
+		{"*.yaml,depth=1", []string{"top.yaml"}},
+		{"dirs,depth=1", []string{"runs/"}},
+		{"dirs,depth=2", []string{"runs/", "runs/a/", "runs/b/", "runs/c/"}},
+		{"leaf", []string{"runs/a/ckpt/", "runs/b/", "runs/c/"}},
+		{"marker=config.yaml", []string{"runs/a/", "runs/c/"}},
+		{"marker=*.txt", []string{"runs/b/"}},
+		{"dirs,?", []string{"runs/a/", "runs/b/", "runs/c/"}},
+	}
+
+	for _, tt := range tests {
+		bindings, err := Parse("run {%" + root + ":" + tt.filter + "%}")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.filter, err)
+		}
+		got, err := ListFiles(bindings[0])
+		if err != nil {
+			t.Fatalf("%s: ListFiles() = %v", tt.filter, err)
+		}
+		expected := strings.ReplaceAll(strings.Join(tt.expected, " "), "/", sep)
+		if strings.Join(got, " ") != expected {
+			t.Errorf("%s: ListFiles() = %v, expected %v", tt.filter, got, tt.expected)
+		}
+	}
+
+	bindings, _ := Parse("train {%--out-dir:" + root + ":dirs%}")
+	created, err := CreateDir(bindings[0], "runs/d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(created); err != nil || !info.IsDir() || created != filepath.Join(root, "runs", "d") {
+		t.Errorf("CreateDir() = %q, %v", created, err)
+	}
+	for _, name := range []string{"../x", "runs/../../x", "/abs", "", ".", "runs/.."} {
+		if created, err := CreateDir(bindings[0], name); err == nil {
+			t.Errorf("CreateDir(%q) = %q, expected error", name, created)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(root), "x")); err == nil {
+		t.Error("CreateDir() created a directory outside the binding's directory")
+	}
+	up, _ := Parse("train {%--out-dir:" + root + ":dirs,up%}")
+	if created, err := CreateDir(up[0], "../up"); err != nil || created != filepath.Join(filepath.Dir(root), "up") {
+		t.Errorf("CreateDir() with up = %q, %v", created, err)
+	}
+	if _, err := CreateDir(up[0], "/abs"); err == nil {
+		t.Error("CreateDir(\"/abs\") with up: expected error")
+	}
+	if got := ExtractPromptContext("", bindings[0]); got != "Select directory for --out-dir ["+root+"]:" {
+		t.Errorf("ExtractPromptContext() = %q", got)
+	}
+}
+
+// Directories named like a source stay directory bindings unless a source
+// kind follows, as they were before sources existed
+func TestSourcePrefixDirectories(t *testing.T) {
+	tests := []struct {
+		command, dir, filter string
+	}{
+		{"cat {%git:*.md%}", "git", "*.md"},
+		{"kubectl apply -f {%kube:*.yaml%}", "kube", "*.yaml"},
+		{"ssh -F {%ssh:config*%}", "ssh", "config*"},
+		{"kill {%proc%}", "proc", ""},
+	}
+	for _, tt := range tests {
+		bindings, err := Parse(tt.command)
+		if err != nil {
+			t.Fatalf("Parse(%q) = %v", tt.command, err)
+		}
+		b := bindings[0]
+		if b.Type != BindingDirectory || filepath.Base(b.Path) != tt.dir || b.Filter != tt.filter {
+			t.Errorf("Parse(%q) = %+v, expected directory %q filter %q", tt.command, b, tt.dir, tt.filter)
+		}
+	}
+}
+
+// A directory named secret with a filter stays a directory binding, as it
+// was before secrets existed; only a variable name makes secret:X a secret
+func TestSecretPrefixDirectories(t *testing.T) {
+	tests := []struct {
+		command, dir, filter string
+	}{
+		{"kubectl apply -f {%secret:*.yaml%}", "secret", "*.yaml"},
+		{"cat {%--key:secret:1TOKEN%}", "secret", "1TOKEN"},
+		{"cat {%secret:id_*%}", "secret", "id_*"},
+	}
+	for _, tt := range tests {
+		bindings, err := Parse(tt.command)
+		if err != nil {
+			t.Fatalf("Parse(%q) = %v", tt.command, err)
+		}
+		b := bindings[0]
+		if b.Type != BindingDirectory || filepath.Base(b.Path) != tt.dir || b.Filter != tt.filter {
+			t.Errorf("Parse(%q) = %+v, expected directory %q filter %q", tt.command, b, tt.dir, tt.filter)
+		}
+	}
+
+	bindings, err := Parse("publish {%secret:API_TOKEN%}")
+	if err != nil || bindings[0].Type != BindingSecret || bindings[0].EnvVar != "API_TOKEN" {
+		t.Errorf("Parse(secret:API_TOKEN) = %+v, %v", bindings, err)
+	}
+}
+
+// Bare keywords read as typed inputs or secrets warn when a directory by that name is
+// here, since commands saved before the keyword existed picked from it
+func TestShadowedDirectory(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	for _, dir := range []string{"path", "url", "secret"} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile("date", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		command, dir string
+	}{
+		{"cat {%path%}", "path"},
+		{"curl {%?--url:url%}", "url"},
+		{"report {%date%}", ""}, // A file, not a directory
+		{"login {%--password=secret%}", "secret"},
+		{"publish {%secret:API_TOKEN%}", ""},
+		{"cat {%./path%}", ""},
+		{"cat {%env:FILE|path%}", ""},
+		{"train {%int(1,10)%}", ""},
+	}
+	for _, tt := range tests {
+		bindings, err := Parse(tt.command)
+		if err != nil {
+			t.Fatalf("Parse(%q) = %v", tt.command, err)
+		}
+		if got := ShadowedDirectory(bindings[0]); got != tt.dir {
+			t.Errorf("ShadowedDirectory(%q) = %q, expected %q", tt.command, got, tt.dir)
+		}
+	}
+
+	bindings, _ := Parse("cat {%path%}")
+	if warnings := Validate(bindings[0]); len(warnings) != 1 || !strings.Contains(warnings[0], "./path") {
+		t.Errorf("Validate() = %q", warnings)
+	}
+}
+
+func TestListFilesOptions(t *testing.T) {
+	root := t.TempDir()
+	for _, path := range []string{
+		".git/config",
+		".env",
+		".gitignore",
+		"node_modules/lib/index.js",
+		"build/out.js",
+		"src/main.go",
+		"src/main_test.go",
+		"src/deep/util.go",
+		"src/deep/debug.log",
+		"src/keep.log",
+	} {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("# comment\n/build/\n*.log\n!keep.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Oldest first on disk, so sort=mtime reverses the names
+	now := time.Now()
+	for i, path := range []string{"src/deep/util.go", "src/main.go", "src/main_test.go"} {
+		stamp := now.Add(time.Duration(i) * time.Hour)
+		if err := os.Chtimes(filepath.Join(root, path), stamp, stamp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A symlink loop must not hang follow
+	linked := os.Symlink(filepath.Join(root, "src"), filepath.Join(root, "src", "deep", "again")) == nil
+
+	sep := string(filepath.Separator)
+	tests := []struct {
+		filter   string
+		expected []string
+	}{
+		{"*.go,*.js", []string{"build/out.js", "node_modules/lib/index.js", "src/deep/util.go", "src/main.go", "src/main_test.go"}},
+		{"*.go,!*_test.go,!src/deep", []string{"src/main.go"}},
+		{"*.js,!node_modules", []string{"build/out.js"}},
+		{"src/**/*.go,!*_test.go", []string{"src/deep/util.go", "src/main.go"}},
+		{"src/*.go", []string{"src/main.go", "src/main_test.go"}},
+		{"gitignore,!node_modules,!*.go", []string{"src/keep.log"}},
+		{"hidden,.*", []string{".env", ".gitignore"}},
+		{".env*", []string{".env"}},
+		{".git*,*.go,!src", []string{".gitignore"}},
+		{"*.go,!*_test.go,sort=mtime", []string{"src/main.go", "src/deep/util.go"}},
+		{"dirs,gitignore", []string{"node_modules/", "node_modules/lib/", "src/", "src/deep/"}},
+	}
+
+	for _, tt := range tests {
+		bindings, err := Parse("run {%" + root + ":" + tt.filter + "%}")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.filter, err)
+		}
+		got, err := ListFiles(bindings[0])
+		if err != nil {
+			t.Fatalf("%s: ListFiles() = %v", tt.filter, err)
+		}
+		expected := strings.ReplaceAll(strings.Join(tt.expected, " "), "/", sep)
+		if strings.Join(got, " ") != expected {
+			t.Errorf("%s: ListFiles() = %v, expected %v", tt.filter, got, tt.expected)
+		}
+	}
+
+	if linked {
+		bindings, _ := Parse("run {%" + root + ":src/**/util.go,follow%}")
+		got, err := ListFiles(bindings[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		// src itself was walked first, so the loop back to it adds nothing
+		if strings.Join(got, " ") != filepath.FromSlash("src/deep/util.go") {
+			t.Errorf("follow: ListFiles() = %v", got)
+		}
+	}
+
+	// A link to a directory outside the tree is listed under the link's name
+	outside := t.TempDir()
+	for _, path := range []string{"lib.go", "sub/x.go"} {
+		full := filepath.Join(outside, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if os.Symlink(outside, filepath.Join(root, "vendor")) == nil {
+		for _, tt := range []struct {
+			filter   string
+			expected []string
+		}{
+			{"*.go,!src,follow", []string{"vendor/lib.go", "vendor/sub/x.go"}},
+			{"!src,!node_modules,!build,follow", []string{"vendor/lib.go", "vendor/sub/x.go"}},
+			{"dirs,!src,!node_modules,!build,follow", []string{"vendor/", "vendor/sub/"}},
+			{"dirs,!src,!node_modules,!build", nil},
+		} {
+			bindings, _ := Parse("run {%" + root + ":" + tt.filter + "%}")
+			got, err := ListFiles(bindings[0])
+			if err != nil {
+				t.Fatalf("%s: ListFiles() = %v", tt.filter, err)
+			}
+			expected := strings.ReplaceAll(strings.Join(tt.expected, " "), "/", sep)
+			if strings.Join(got, " ") != expected {
+				t.Errorf("%s: ListFiles() = %v, expected %v", tt.filter, got, tt.expected)
+			}
+		}
+	}
+}
+
+func TestReadLevel(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "runs")
+	for _, path := range []string{
+		"a/config.yaml",
+		"a/ckpt/last.pt",
+		"b/notes.txt",
+		".cache/x",
+		"top.yaml",
+		"top.txt",
+	} {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names := func(entries []Entry) string {
+		var parts []string
+		for _, e := range entries {
+			name := e.Name
+			if e.Dir {
+				name += "/"
+			}
+			if e.Match {
+				name += "*"
+			}
+			if e.Open {
+				name += ">"
+			}
+			parts = append(parts, name)
+		}
+		return strings.Join(parts, " ")
+	}
+
+	tests := []struct {
+		filter   string
+		dir      string
+		expected string
+	}{
+		{"*.yaml,browse", ".", "a/> b/> top.yaml*"},
+		{"*.yaml,browse", "a", "ckpt/> config.yaml*"},
+		{"*.yaml,browse,depth=1", ".", "top.yaml*"},
+		{"marker=config.yaml,browse", ".", "a/*> b/>"},
+		{"dirs,browse,depth=1", ".", "a/* b/*"},
+		{"browse,up", "..", "runs/>"},
+		{"browse,hidden", ".", ".cache/> a/> b/> top.txt* top.yaml*"},
+	}
+
+	for _, tt := range tests {
+		bindings, err := Parse("run {%" + root + ":" + tt.filter + "%}")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.filter, err)
+		}
+		entries, err := ReadLevel(bindings[0], tt.dir)
+		if err != nil {
+			t.Fatalf("%s: ReadLevel(%q) = %v", tt.filter, tt.dir, err)
+		}
+		if got := names(entries); got != tt.expected {
+			t.Errorf("%s: ReadLevel(%q) = %q, expected %q", tt.filter, tt.dir, got, tt.expected)
+		}
+	}
+
+	bindings, _ := Parse("run {%" + root + ":browse%}")
+	if _, err := ReadLevel(bindings[0], "a/../.."); err == nil {
+		t.Error("expected error browsing above the directory without up")
+	}
+	if entries, _ := ReadLevel(bindings[0], "."); len(entries) == 0 || entries[len(entries)-1].Size != 4 {
+		t.Errorf("ReadLevel() sizes = %+v", entries)
+	}
+}
+
+func TestSourcePrompt(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{"git checkout {%--branch:git:branches%}", "Select branch for --branch:"},
+		{"git diff -- {%git:changed%}", "Select changed file:"},
+		{"git push --tags {%git:remotes%}", "Select remote for --tags:"},
+	}
+	for _, tt := range tests {
+		bindings, err := Parse(tt.command)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.command, err)
+		}
+		if got := ExtractPromptContext(tt.command, bindings[0]); got != tt.expected {
+			t.Errorf("ExtractPromptContext(%q) = %q, expected %q", tt.command, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,574 @@
+package binding
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FindOptions control what a directory binding lists
+// They are written as comma-separated terms after the path, e.g.
+// {%/experiments:dirs,leaf,depth=2%} or {%/src:*.go,!*_test.go,gitignore%}.
+type FindOptions struct {
+	Patterns  []string // Globs to include, any may match; "**" and "/" match the relative path
+	Exclude   []string // Globs to leave out, written with a leading "!"; excluded directories aren't searched
+	Dirs      bool     // List directories instead of files
+	Leaf      bool     // Only directories without subdirectories
+	Marker    string   // Only directories containing an entry matching this glob, e.g. "config.yaml"
+	Depth     int      // How many levels below the base directory to look, 0 for no limit
+	Hidden    bool     // Include entries whose name starts with ".", also set by a pattern starting with "."
+	Follow    bool     // Follow symlinks instead of skipping them
+	Gitignore bool     // Leave out entries ignored by .gitignore files
+	Sort      string   // "name" (default) or "mtime" for newest first
+	Browse    bool     // Navigate one level at a time instead of listing the whole tree
+	Up        bool     // Let browsing go above the binding's directory
+}
+
+// parseFindOptions parses the terms after a directory binding's path
+func parseFindOptions(filter string) (FindOptions, error) {
+	var opts FindOptions
+	if filter == "" {
+		return opts, nil
+	}
+
+	for _, term := range strings.Split(filter, ",") {
+		term = strings.TrimSpace(term)
+		key, value, hasValue := strings.Cut(term, "=")
+		switch {
+		case term == "":
+			return opts, fmt.Errorf("empty term in '%s'", filter)
+		case term == "dirs":
+			opts.Dirs = true
+		case term == "leaf":
+			opts.Dirs, opts.Leaf = true, true
+		case term == "hidden":
+			opts.Hidden = true
+		case term == "follow":
+			opts.Follow = true
+		case term == "gitignore":
+			opts.Gitignore = true
+		case term == "browse":
+			opts.Browse = true
+		case term == "up":
+			opts.Browse, opts.Up = true, true
+		case key == "marker" && hasValue:
+			if _, err := filepath.Match(value, ""); err != nil || value == "" {
+				return opts, fmt.Errorf("invalid marker '%s'", value)
+			}
+			opts.Dirs, opts.Marker = true, value
+		case key == "depth" && hasValue:
+			depth, err := strconv.Atoi(value)
+			if err != nil || depth < 1 {
+				return opts, fmt.Errorf("invalid depth '%s': must be 1 or more", value)
+			}
+			opts.Depth = depth
+		case key == "sort" && hasValue:
+			if value != "name" && value != "mtime" {
+				return opts, fmt.Errorf("invalid sort '%s': use name or mtime", value)
+			}
+			opts.Sort = value
+		case hasValue:
+			return opts, fmt.Errorf("unknown option '%s'", key)
+		case strings.HasPrefix(term, "!"):
+			pattern := term[1:]
+			if !validGlob(pattern) {
+				return opts, fmt.Errorf("invalid exclude pattern '%s'", pattern)
+			}
+			opts.Exclude = append(opts.Exclude, pattern)
+		default:
+			if !validGlob(term) {
+				return opts, fmt.Errorf("invalid pattern '%s'", term)
+			}
+			if strings.HasPrefix(term, ".") {
+				opts.Hidden = true // A pattern for dotfiles, e.g. .env*, asks for them
+			}
+			opts.Patterns = append(opts.Patterns, term)
+		}
+	}
+	return opts, nil
+}
+
+// validGlob reports whether every part of a glob is well-formed
+func validGlob(pattern string) bool {
+	if pattern == "" {
+		return false
+	}
+	for _, part := range strings.Split(pattern, "/") {
+		if _, err := path.Match(part, ""); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// kind names what a directory binding lists, for prompts and messages
+func (o FindOptions) kind() string {
+	if o.Dirs {
+		return "directory"
+	}
+	return "file"
+}
+
+// matchGlob matches a find pattern against an entry's slash-separated path
+// relative to the base directory. Patterns without "/" match the base name;
+// others match the whole path, with "**" standing for any number of directories.
+func matchGlob(pattern, rel string) bool {
+	if !strings.Contains(pattern, "/") && pattern != "**" {
+		matched, _ := path.Match(pattern, path.Base(rel))
+		return matched
+	}
+	return matchSegments(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(rel, "/"))
+}
+
+// matchSegments matches pattern segments against path segments
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], parts[0]); !matched {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// matchAny reports whether rel matches any of the patterns
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if matchGlob(p, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// found is an entry ListFiles will offer
+type found struct {
+	rel   string
+	mtime time.Time
+}
+
+// finder walks a directory binding's tree
+type finder struct {
+	root    string
+	opts    FindOptions
+	ignores []ignoreRule
+	visited map[string]bool // Real paths of directories walked, to stop symlink loops
+	results []found
+}
+
+// ListFiles returns the entries a directory binding offers
+// Files are listed by default, or directories (with a trailing slash) when
+// the binding has the dirs option. Paths are relative to the binding's
+// directory, sorted by name or newest first. Hidden entries and symlinks are
+// skipped unless asked for, and excluded or ignored directories aren't searched.
+// An empty directory listing is not an error, since a new one can be created.
+func ListFiles(b Binding) ([]string, error) {
+	if b.Type != BindingDirectory {
+		return nil, fmt.Errorf("ListFiles called on non-directory binding")
+	}
+
+	info, err := os.Stat(b.Path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("directory '%s' does not exist", b.Path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot access '%s': %v", b.Path, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("'%s' is not a directory", b.Path)
+	}
+
+	f := &finder{root: b.Path, opts: b.Find, visited: make(map[string]bool)}
+	if err := f.walk(b.Path, "."); err != nil {
+		return nil, fmt.Errorf("error reading directory '%s': %v", b.Path, err)
+	}
+
+	if len(f.results) == 0 && !b.Find.Dirs {
+		if b.Filter != "" {
+			return nil, fmt.Errorf("no files found in '%s' matching '%s'", b.Path, b.Filter)
+		}
+		return nil, fmt.Errorf("no files found in '%s'", b.Path)
+	}
+
+	if b.Find.Sort == "mtime" {
+		sort.SliceStable(f.results, func(i, j int) bool {
+			return f.results[i].mtime.After(f.results[j].mtime)
+		})
+	} else {
+		sort.Slice(f.results, func(i, j int) bool { return f.results[i].rel < f.results[j].rel })
+	}
+
+	files := make([]string, len(f.results))
+	for i, r := range f.results {
+		files[i] = filepath.FromSlash(r.rel)
+		if b.Find.Dirs {
+			files[i] += string(filepath.Separator)
+		}
+	}
+	return files, nil
+}
+
+// walk adds the matching entries under dir, which is root or the target of a
+// followed symlink; prefix is dir's path relative to root, as seen through links
+func (f *finder) walk(dir, prefix string) error {
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		if f.visited[real] {
+			return nil
+		}
+		f.visited[real] = true
+	}
+
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip entries we can't access
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return nil
+		}
+		rel = path.Join(prefix, filepath.ToSlash(rel))
+		isDir := d.IsDir()
+
+		if p == dir {
+			if isDir && f.opts.Gitignore {
+				f.loadIgnore(p, rel)
+			}
+			if rel == "." {
+				return nil
+			}
+		} else {
+			// Symlinks are skipped, or followed by walking their target
+			if d.Type()&fs.ModeSymlink != 0 {
+				if !f.opts.Follow {
+					return nil
+				}
+				target, err := os.Stat(p)
+				if err != nil {
+					return nil
+				}
+				if target.IsDir() {
+					if f.skip(rel, true) {
+						return nil
+					}
+					// WalkDir doesn't descend into links, so walk the target
+					// and list what's in it under the link's name
+					real, err := filepath.EvalSymlinks(p)
+					if err != nil {
+						return nil
+					}
+					return f.walk(real, rel)
+				}
+				isDir = false
+			}
+
+			if f.skip(rel, isDir) {
+				if isDir {
+					return filepath.SkipDir // Prune the whole subtree
+				}
+				return nil
+			}
+			if isDir && f.opts.Gitignore {
+				f.loadIgnore(p, rel)
+			}
+			// Remember directories walked, so links back to them aren't walked again
+			if isDir && f.opts.Follow {
+				if real, err := filepath.EvalSymlinks(p); err == nil {
+					f.visited[real] = true
+				}
+			}
+		}
+
+		depth := strings.Count(rel, "/") + 1
+		if isDir {
+			if f.opts.Dirs && f.matchDir(p, rel) {
+				f.add(p, rel)
+			}
+			// Don't look below the depth limit
+			if f.opts.Depth > 0 && depth >= f.opts.Depth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !f.opts.Dirs && (len(f.opts.Patterns) == 0 || matchAny(f.opts.Patterns, rel)) {
+			f.add(p, rel)
+		}
+		return nil
+	})
+}
+
+// skip reports whether an entry is hidden, excluded or ignored
+func (f *finder) skip(rel string, isDir bool) bool {
+	if !f.opts.Hidden && strings.HasPrefix(path.Base(rel), ".") {
+		return true
+	}
+	if matchAny(f.opts.Exclude, rel) {
+		return true
+	}
+	return f.opts.Gitignore && ignored(f.ignores, rel, isDir)
+}
+
+// matchDir reports whether a directory passes the dirs options
+func (f *finder) matchDir(p, rel string) bool {
+	if len(f.opts.Patterns) > 0 && !matchAny(f.opts.Patterns, rel) {
+		return false
+	}
+	if f.opts.Marker != "" {
+		if matches, _ := filepath.Glob(filepath.Join(p, f.opts.Marker)); len(matches) == 0 {
+			return false
+		}
+	}
+	if f.opts.Leaf {
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			return false
+		}
+		for _, e := range entries {
+			if e.IsDir() && !f.skip(path.Join(rel, e.Name()), true) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// add records a matching entry
+func (f *finder) add(p, rel string) {
+	r := found{rel: rel}
+	if f.opts.Sort == "mtime" {
+		if info, err := os.Stat(p); err == nil {
+			r.mtime = info.ModTime()
+		}
+	}
+	f.results = append(f.results, r)
+}
+
+// ignoreRule is one pattern from a .gitignore file
+type ignoreRule struct {
+	base     string // Directory of the .gitignore, relative to the root ("." for the root)
+	pattern  string
+	negate   bool // "!pattern" re-includes
+	dirOnly  bool // "pattern/" only matches directories
+	anchored bool // Contains a "/" other than at the end: matched against the path from base
+}
+
+// loadIgnore reads the .gitignore in dir, if any
+func (f *finder) loadIgnore(dir, rel string) {
+	file, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{base: rel}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`)
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		rule.anchored = strings.Contains(line, "/")
+		rule.pattern = strings.TrimPrefix(line, "/")
+		if rule.pattern != "" {
+			f.ignores = append(f.ignores, rule)
+		}
+	}
+}
+
+// ignored reports whether the last matching .gitignore rule ignores rel
+func ignored(rules []ignoreRule, rel string, isDir bool) bool {
+	result := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		sub := rel
+		if rule.base != "." {
+			if !strings.HasPrefix(rel, rule.base+"/") {
+				continue
+			}
+			sub = strings.TrimPrefix(rel, rule.base+"/")
+		}
+
+		var matched bool
+		if rule.anchored {
+			matched = matchSegments(strings.Split(rule.pattern, "/"), strings.Split(sub, "/"))
+		} else {
+			matched, _ = path.Match(rule.pattern, path.Base(sub))
+		}
+		if matched {
+			result = !rule.negate
+		}
+	}
+	return result
+}
+
+// errLeavesDir is returned by CheckNewDirName for names that climb out of
+// the directory they would be created in
+var errLeavesDir = errors.New("leaves the directory it would be created in")
+
+// CheckNewDirName checks the name given for a new directory, which is created
+// inside the directory being listed or browsed: it must be relative and stay
+// inside it once cleaned, e.g. "runs/new" but not "/tmp/x" or "../x"
+func CheckNewDirName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("no directory name given")
+	}
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") {
+		return fmt.Errorf("'%s' must be relative to the directory it is created in", name)
+	}
+	clean := path.Clean(filepath.ToSlash(name))
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("'%s' %w", name, errLeavesDir)
+	}
+	return nil
+}
+
+// CreateDir creates a new directory for a dirs binding and returns its path
+// name is relative to the binding's directory and follows CheckNewDirName,
+// except that bindings with the up option may create above the directory,
+// where browsing can go.
+func CreateDir(b Binding, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if err := CheckNewDirName(name); err != nil && !(b.Find.Up && errors.Is(err, errLeavesDir)) {
+		return "", err
+	}
+	p := GetAbsolutePath(b, name)
+	if err := os.MkdirAll(p, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	return p, nil
+}
+
+// Entry is one entry of a directory level shown while browsing
+type Entry struct {
+	Name    string
+	Dir     bool
+	Size    int64
+	ModTime time.Time
+	Match   bool // Can be picked: a matching file, or a matching directory for dirs bindings
+	Open    bool // A directory that can be browsed into
+}
+
+// ReadLevel lists the entries of one directory for browsing
+// dir is slash-separated and relative to the binding's directory; it starts
+// with ".." only for bindings with the up option. The same options as
+// ListFiles decide what is shown and what can be picked, except that
+// directories are always listed so they can be browsed into.
+func ReadLevel(b Binding, dir string) ([]Entry, error) {
+	if b.Type != BindingDirectory {
+		return nil, fmt.Errorf("ReadLevel called on non-directory binding")
+	}
+	dir = path.Clean(dir)
+	outside := dir == ".." || strings.HasPrefix(dir, "../")
+	if outside && !b.Find.Up {
+		return nil, fmt.Errorf("'%s' is outside '%s'", dir, b.Path)
+	}
+
+	full := filepath.Join(b.Path, filepath.FromSlash(dir))
+	entries, err := os.ReadDir(full)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read '%s': %v", full, err)
+	}
+
+	// Outside the binding's directory everything can be browsed, but only
+	// what is inside follows the binding's rules
+	f := &finder{root: b.Path, opts: b.Find}
+	if outside {
+		f.opts = FindOptions{Dirs: b.Find.Dirs, Hidden: b.Find.Hidden, Follow: b.Find.Follow, Sort: b.Find.Sort}
+	}
+	depth := 0
+	if dir != "." && !outside {
+		depth = strings.Count(dir, "/") + 1
+	}
+	if f.opts.Gitignore {
+		// Rules from every level above apply too
+		f.loadIgnore(b.Path, ".")
+		if dir != "." {
+			parts := strings.Split(dir, "/")
+			for i := range parts {
+				rel := strings.Join(parts[:i+1], "/")
+				f.loadIgnore(filepath.Join(b.Path, filepath.FromSlash(rel)), rel)
+			}
+		}
+	}
+
+	var level []Entry
+	for _, e := range entries {
+		rel := path.Join(dir, e.Name())
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if e.Type()&fs.ModeSymlink != 0 {
+			if !f.opts.Follow {
+				continue
+			}
+			if info, err = os.Stat(filepath.Join(full, e.Name())); err != nil {
+				continue
+			}
+		}
+		if f.skip(rel, info.IsDir()) {
+			continue
+		}
+
+		entry := Entry{Name: e.Name(), Dir: info.IsDir(), Size: info.Size(), ModTime: info.ModTime()}
+		if entry.Dir {
+			entry.Open = outside || f.opts.Depth == 0 || depth+1 < f.opts.Depth
+			entry.Match = f.opts.Dirs && (outside || f.matchDir(filepath.Join(full, e.Name()), rel))
+			if !entry.Open && !entry.Match {
+				continue // Nothing to pick in it or below
+			}
+		} else {
+			if f.opts.Dirs {
+				continue
+			}
+			entry.Match = outside || len(f.opts.Patterns) == 0 || matchAny(f.opts.Patterns, rel)
+			if !entry.Match {
+				continue
+			}
+		}
+		level = append(level, entry)
+	}
+
+	// Directories first, then by name or newest first
+	sort.SliceStable(level, func(i, j int) bool {
+		if level[i].Dir != level[j].Dir {
+			return level[i].Dir
+		}
+		if b.Find.Sort == "mtime" {
+			return level[i].ModTime.After(level[j].ModTime)
+		}
+		return level[i].Name < level[j].Name
+	})
+	return level, nil
+}
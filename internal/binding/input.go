@@ -0,0 +1,194 @@
+package binding
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Input kinds accepted by typed input bindings
+const (
+	InputInt   = "int"
+	InputFloat = "float"
+	InputRegex = "regex"
+	InputPath  = "path"
+	InputURL   = "url"
+	InputDate  = "date"
+)
+
+// dateLayout is the format accepted by date inputs
+const dateLayout = "2006-01-02"
+
+// Input describes the values a typed input binding accepts
+type Input struct {
+	Kind    string         // One of the Input* kinds
+	Min     *float64       // Lower bound for int and float, nil when open
+	Max     *float64       // Upper bound for int and float, nil when open
+	Pattern *regexp.Regexp // For regex inputs
+	Spec    string         // The text it was parsed from, e.g. "int(1,1000)"
+}
+
+// rangePattern matches int and float inputs with optional bounds: int, int(1,100), float(0,)
+var rangePattern = regexp.MustCompile(`^(int|float)(?:\(\s*([^,()]*?)\s*,\s*([^,()]*?)\s*\))?$`)
+
+// isInputSpec reports whether content names a typed input
+func isInputSpec(content string) bool {
+	return rangePattern.MatchString(content) || strings.HasPrefix(content, "regex:") ||
+		content == InputPath || content == InputURL || content == InputDate
+}
+
+// parseInput parses a typed input spec such as "int(1,1000)" or "regex:^v\d+$"
+func parseInput(spec string) (*Input, error) {
+	in := &Input{Spec: spec}
+
+	if m := rangePattern.FindStringSubmatch(spec); m != nil {
+		in.Kind = m[1]
+		for i, bound := range []**float64{&in.Min, &in.Max} {
+			text := m[2+i]
+			if text == "" {
+				continue
+			}
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil || (in.Kind == InputInt && value != float64(int64(value))) {
+				return nil, fmt.Errorf("invalid %s bound '%s'", in.Kind, text)
+			}
+			*bound = &value
+		}
+		if in.Min != nil && in.Max != nil && *in.Min > *in.Max {
+			return nil, fmt.Errorf("minimum %s is greater than maximum %s", m[2], m[3])
+		}
+		return in, nil
+	}
+
+	if pattern, ok := strings.CutPrefix(spec, "regex:"); ok {
+		if pattern == "" {
+			return nil, fmt.Errorf("regex input needs a pattern")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		in.Kind = InputRegex
+		in.Pattern = re
+		return in, nil
+	}
+
+	in.Kind = spec
+	return in, nil
+}
+
+// String describes the accepted values for prompts, e.g. "int 1..1000"
+func (in *Input) String() string {
+	switch in.Kind {
+	case InputInt, InputFloat:
+		switch {
+		case in.Min != nil && in.Max != nil:
+			return fmt.Sprintf("%s %s..%s", in.Kind, formatBound(*in.Min), formatBound(*in.Max))
+		case in.Min != nil:
+			return fmt.Sprintf("%s >= %s", in.Kind, formatBound(*in.Min))
+		case in.Max != nil:
+			return fmt.Sprintf("%s <= %s", in.Kind, formatBound(*in.Max))
+		}
+	case InputRegex:
+		return "matching " + in.Pattern.String()
+	case InputPath:
+		return "existing path"
+	case InputDate:
+		return "date YYYY-MM-DD"
+	}
+	return in.Kind
+}
+
+// formatBound formats a bound without a trailing ".0"
+func formatBound(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// Check returns an error describing why value isn't accepted, or nil
+func (in *Input) Check(value string) error {
+	if value == "" {
+		return fmt.Errorf("a value is required")
+	}
+
+	switch in.Kind {
+	case InputInt, InputFloat:
+		var n float64
+		if in.Kind == InputInt {
+			i, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("'%s' is not a whole number", value)
+			}
+			n = float64(i)
+		} else {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("'%s' is not a number", value)
+			}
+			n = f
+		}
+		if in.Min != nil && n < *in.Min {
+			return fmt.Errorf("%s is less than the minimum %s", value, formatBound(*in.Min))
+		}
+		if in.Max != nil && n > *in.Max {
+			return fmt.Errorf("%s is more than the maximum %s", value, formatBound(*in.Max))
+		}
+
+	case InputRegex:
+		if !in.Pattern.MatchString(value) {
+			return fmt.Errorf("'%s' doesn't match %s", value, in.Pattern)
+		}
+
+	case InputPath:
+		path := value
+		if strings.HasPrefix(path, "~") {
+			if home, err := os.UserHomeDir(); err == nil {
+				path = filepath.Join(home, path[1:])
+			}
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("'%s' does not exist", value)
+		}
+
+	case InputURL:
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("'%s' is not a URL like https://example.com/path", value)
+		}
+
+	case InputDate:
+		if _, err := time.Parse(dateLayout, value); err != nil {
+			return fmt.Errorf("'%s' is not a date like 2024-01-31", value)
+		}
+	}
+	return nil
+}
+
+// CheckValue checks a value given for a binding without a picker, such as
+// with --set: typed inputs and ranges are validated, and value lists without
+// "..." only accept their listed values
+func CheckValue(b Binding, value string) error {
+	switch b.Type {
+	case BindingInput:
+		return b.Input.Check(value)
+	case BindingRange:
+		return b.Range.Check(value)
+	case BindingSecret:
+		return fmt.Errorf("secrets can't be set on the command line")
+	case BindingValues:
+		if b.AllowCustom || len(b.Values) == 0 {
+			return nil
+		}
+		for _, v := range b.Values {
+			if v == value {
+				return nil
+			}
+		}
+		return fmt.Errorf("'%s' is not one of: %s", value, strings.Join(b.Values, ", "))
+	}
+	return nil
+}
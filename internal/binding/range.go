@@ -0,0 +1,164 @@
+package binding
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxRangeLen caps the number of values a range may have
+const maxRangeLen = math.MaxInt32
+
+// Range is a numeric sequence expanded one value at a time, so ranges with
+// millions of values cost nothing until shown
+// Both ends are included: range(0,10) is 0 to 10, range(0,100,5) counts in
+// fives, and range(1,64,*2) doubles: 1, 2, 4, ... 64.
+type Range struct {
+	Start    float64
+	End      float64
+	Step     float64 // Added for each value of a linear range
+	Factor   float64 // Multiplied for each value of a geometric range, 0 when linear
+	Spec     string  // The text it was parsed from, e.g. "range(1,64,*2)"
+	count    int
+	decimals int  // Most decimal places written in the bounds and step
+	integral bool // Whether every value is a whole number
+}
+
+// rangeSpecPattern matches range(start,end) with an optional step or *factor
+var rangeSpecPattern = regexp.MustCompile(`^range\(\s*([^,()]+?)\s*,\s*([^,()]+?)\s*(?:,\s*(\*?)\s*([^,()]+?)\s*)?\)$`)
+
+// isRangeSpec reports whether content is a range
+func isRangeSpec(content string) bool {
+	return strings.HasPrefix(content, "range(")
+}
+
+// parseRange parses a range spec such as "range(0,10)" or "range(1,64,*2)"
+func parseRange(spec string) (*Range, error) {
+	m := rangeSpecPattern.FindStringSubmatch(spec)
+	if m == nil {
+		return nil, fmt.Errorf("invalid range '%s': expected range(start,end), range(start,end,step) or range(start,end,*factor)", spec)
+	}
+
+	r := &Range{Spec: spec}
+	var err error
+	if r.Start, err = parseNumber(m[1]); err != nil {
+		return nil, err
+	}
+	if r.End, err = parseNumber(m[2]); err != nil {
+		return nil, err
+	}
+	r.decimals = max(decimals(m[1]), decimals(m[2]))
+	r.integral = r.decimals == 0
+
+	var n float64
+	switch {
+	case m[3] == "*":
+		if r.Factor, err = parseNumber(m[4]); err != nil {
+			return nil, err
+		}
+		if r.Factor <= 0 || r.Factor == 1 || r.Start == 0 || r.End/r.Start <= 0 {
+			return nil, fmt.Errorf("invalid range '%s': a factor must be positive and not 1, and start and end non-zero with the same sign", spec)
+		}
+		n = math.Log(r.End/r.Start) / math.Log(r.Factor)
+		r.integral = r.integral && r.Factor == math.Trunc(r.Factor)
+
+	default:
+		r.Step = 1
+		if r.End < r.Start {
+			r.Step = -1
+		}
+		if m[4] != "" {
+			if r.Step, err = parseNumber(m[4]); err != nil {
+				return nil, err
+			}
+			r.decimals = max(r.decimals, decimals(m[4]))
+			r.integral = r.decimals == 0
+		}
+		if r.Step == 0 {
+			return nil, fmt.Errorf("invalid range '%s': step can't be 0", spec)
+		}
+		n = (r.End - r.Start) / r.Step
+	}
+
+	// A little slack so range(0,1,0.1) includes 1 despite rounding
+	if n < -1e-9 {
+		return nil, fmt.Errorf("invalid range '%s': the step moves away from the end", spec)
+	}
+	if n+1 > maxRangeLen {
+		return nil, fmt.Errorf("invalid range '%s': more than %d values", spec, maxRangeLen)
+	}
+	r.count = int(math.Floor(n+1e-9)) + 1
+	return r, nil
+}
+
+// parseNumber parses a range bound, step or factor
+func parseNumber(text string) (float64, error) {
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return 0, fmt.Errorf("invalid number '%s' in range", text)
+	}
+	return f, nil
+}
+
+// decimals returns the number of digits written after the decimal point
+func decimals(text string) int {
+	if i := strings.IndexByte(text, '.'); i != -1 && !strings.ContainsAny(text, "eE") {
+		return len(text) - i - 1
+	}
+	return 0
+}
+
+// Len returns the number of values in the range
+func (r *Range) Len() int {
+	return r.count
+}
+
+// At returns the i-th value of the range, formatted as it would be typed
+func (r *Range) At(i int) string {
+	if r.Factor != 0 {
+		v := r.Start * math.Pow(r.Factor, float64(i))
+		if r.integral {
+			return strconv.FormatFloat(math.Round(v), 'f', 0, 64)
+		}
+		// Round away float noise such as 0.0001 * 10 = 0.0010000000000000002
+		v, _ = strconv.ParseFloat(strconv.FormatFloat(v, 'g', 12, 64), 64)
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	// Round to the precision written, then drop trailing zeros
+	v := strconv.FormatFloat(r.Start+float64(i)*r.Step, 'f', r.decimals, 64)
+	if strings.Contains(v, ".") {
+		v = strings.TrimRight(strings.TrimRight(v, "0"), ".")
+	}
+	if v == "-0" {
+		v = "0"
+	}
+	return v
+}
+
+// Values returns every value of the range; only use it for short ranges
+func (r *Range) Values() []string {
+	values := make([]string, r.count)
+	for i := range values {
+		values[i] = r.At(i)
+	}
+	return values
+}
+
+// Check returns an error if value isn't a number between the range's ends
+// Values between the listed steps are accepted.
+func (r *Range) Check(value string) error {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("'%s' is not a number", value)
+	}
+	if r.integral && f != math.Trunc(f) {
+		return fmt.Errorf("'%s' is not a whole number", value)
+	}
+	low, high := math.Min(r.Start, r.End), math.Max(r.Start, r.End)
+	if f < low || f > high {
+		return fmt.Errorf("%s is outside %s..%s", value, formatBound(low), formatBound(high))
+	}
+	return nil
+}
@@ -2,9 +2,15 @@ package builder
 
 import (
 	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"laziest/internal/binding"
 	"laziest/internal/flagparse"
+	"laziest/internal/helpparse"
 	"laziest/internal/picker"
 )
 
@@ -16,6 +22,7 @@ const (
 	ChoiceBoolean                        // Optional boolean flag (include or skip)
 	ChoiceDirectory                      // Directory picker binding
 	ChoiceValueList                      // List of predefined values
+	ChoiceCustom                         // Free-text input at runtime
 )
 
 // BuildResult represents the result of the interactive builder
@@ -24,75 +31,395 @@ type BuildResult struct {
 	Cancelled bool   // True if user cancelled
 }
 
+// Options controls optional builder behaviour
+type Options struct {
+	ScanHelp    bool          // Run "<command> --help" to suggest choices and extra flags
+	HelpTimeout time.Duration // Limit for the --help run (default 3s)
+}
+
 // BuildCommand runs the interactive command builder
-// Takes an example command and walks through each flag to create bindings
-func BuildCommand(command string) BuildResult {
+// Takes an example command and walks through each flag and positional
+// argument to create bindings. The program and its subcommand chain
+// (e.g. "aws ec2 start-instances") are kept as-is.
+func BuildCommand(command string, opts Options) BuildResult {
+	return build(command, command, opts, nil)
+}
+
+// Rebuild runs the builder over a saved command that may contain bindings
+// Each existing binding is offered with the builder's choices, defaulted to
+// its current configuration, alongside the static flags and arguments.
+func Rebuild(command string) (BuildResult, error) {
+	bindings, err := binding.Parse(command)
+	if err != nil {
+		return BuildResult{}, err
+	}
+
+	// Swap placeholders for plain words so the command parses like an example
+	masked := command
+	current := make(map[string]binding.Binding)
+	for i, b := range bindings {
+		marker := fmt.Sprintf("__lz%d__", i)
+		masked = strings.Replace(masked, b.Placeholder, marker, 1)
+		current[marker] = b
+	}
+
+	return build(masked, command, Options{}, current), nil
+}
+
+// param is one flag or positional argument the builder asks about
+type param struct {
+	flag    flagparse.Flag
+	current *binding.Binding // Existing binding when rebuilding
+	label   string           // e.g. "Flag: --epochs = 100"
+}
+
+// piece is a part of the output command: fixed text or a param
+type piece struct {
+	text  string
+	param *param
+}
+
+// build walks the segments of command and asks about each param
+// current maps marker words in command back to the bindings they replaced
+func build(command, display string, opts Options, current map[string]binding.Binding) BuildResult {
 	segments := flagparse.ParseSegments(command)
 
-	if !flagparse.HasFlags(segments) {
-		// No flags found - return as-is
-		return BuildResult{Command: command, Cancelled: false}
+	// Flags documented in --help: choices for existing flags, extras to offer
+	var documented, extras []helpparse.Option
+	if opts.ScanHelp {
+		documented = scanHelp(segments, opts.HelpTimeout)
+		extras = missingOptions(documented, segments)
 	}
 
-	// Count flags for progress display
-	flagCount := 0
-	for _, seg := range segments {
-		if seg.Type == flagparse.SegmentFlag {
-			flagCount++
+	restore := func(s string) string {
+		for marker, b := range current {
+			s = strings.Replace(s, marker, b.Placeholder, 1)
 		}
+		return s
 	}
 
-	fmt.Printf("\n\033[1mBuilding command from:\033[0m %s\n\n", command)
-	fmt.Printf("\033[2mFound %d flag(s) to configure\033[0m\n\n", flagCount)
+	pieces, names, insertAt := layout(segments, current, restore)
+	paramCount := 0
+	for _, pc := range pieces {
+		if pc.param != nil {
+			paramCount++
+		}
+	}
 
-	// Process each segment in order
+	if paramCount == 0 && len(extras) == 0 {
+		// Nothing to configure - return as-is
+		return BuildResult{Command: display, Cancelled: false}
+	}
+
+	fmt.Printf("\n\033[1mBuilding command from:\033[0m %s\n\n", display)
+	if len(names) > 0 {
+		fmt.Printf("\033[2mCommand: %s\033[0m\n", restore(strings.Join(names, " ")))
+	}
+	fmt.Printf("\033[2mFound %d parameter(s) to configure\033[0m\n\n", paramCount)
+
+	// Process each param in order
 	var parts []string
-	flagIdx := 0
+	paramIdx := 0
+
+	for i, pc := range pieces {
+		if i == insertAt && len(extras) > 0 {
+			added, cancelled := addDocumentedFlags(extras)
+			if cancelled {
+				return BuildResult{Cancelled: true}
+			}
+			parts = append(parts, added...)
+			extras = nil
+		}
+
+		if pc.param == nil {
+			parts = append(parts, pc.text)
+			continue
+		}
+
+		paramIdx++
+		fmt.Printf("\033[1m[%d/%d] %s\033[0m\n", paramIdx, paramCount, pc.param.label)
+
+		out, cancelled := processFlag(pc.param.flag, documentedChoices(documented, pc.param.flag.Name), pc.param.current)
+		if cancelled {
+			return BuildResult{Cancelled: true}
+		}
+
+		if out != "" {
+			parts = append(parts, out)
+		}
+
+		fmt.Println()
+	}
+
+	if len(extras) > 0 {
+		added, cancelled := addDocumentedFlags(extras)
+		if cancelled {
+			return BuildResult{Cancelled: true}
+		}
+		parts = append(parts, added...)
+	}
+
+	result := strings.Join(parts, " ")
+	return BuildResult{Command: result, Cancelled: false}
+}
+
+// layout splits a command's segments into fixed text and params
+// It returns the pieces, the program and subcommand words, and where flags
+// from --help go. current and restore map marker words back to bindings.
+func layout(segments []flagparse.Segment, current map[string]binding.Binding, restore func(string) string) ([]piece, []string, int) {
+	var pieces []piece
+	var names []string
+	insertAt := -1 // Where flags from --help go: the end of the first command
+	commandWords := flagparse.CommandWords(segments)
+
+	addParam := func(p param) {
+		pieces = append(pieces, piece{param: &p})
+	}
 
-	for _, seg := range segments {
+	// addArg adds a positional argument, which may be a marker for a binding
+	addArg := func(arg string) {
+		b, bound := current[arg]
+		if b.Env != "" || b.Source != nil {
+			bound = false // Environment and source bindings are kept as-is
+		}
+		switch {
+		case bound && b.Type == binding.BindingBooleanFlag:
+			addParam(param{
+				flag:    flagparse.Flag{Name: b.Flag, IsBoolean: true},
+				current: &b,
+				label:   "Flag: " + b.Placeholder,
+			})
+		case bound:
+			label := "Argument: " + b.Placeholder
+			if b.Flag != "" {
+				label = "Flag: " + b.Placeholder
+			}
+			addParam(param{
+				flag:    flagparse.Flag{Name: b.Flag, Joined: b.Joined},
+				current: &b,
+				label:   label,
+			})
+		case restore(arg) != arg:
+			// Binding inside a larger word, e.g. out/{%[a,b]%}.txt, or from the environment
+			pieces = append(pieces, piece{text: restore(arg)})
+		default:
+			addParam(param{
+				flag:  flagparse.Flag{Value: arg},
+				label: "Argument: " + arg,
+			})
+		}
+	}
+
+	for i, seg := range segments {
 		switch seg.Type {
+		case flagparse.SegmentOperator:
+			// Shell operators pass through unchanged
+			if insertAt == -1 {
+				insertAt = len(pieces)
+			}
+			pieces = append(pieces, piece{text: restore(seg.Static)})
+
 		case flagparse.SegmentStatic:
-			// Static segments pass through unchanged
-			parts = append(parts, seg.Static)
+			// Program and subcommands pass through unchanged
+			if commandWords[i] > 0 {
+				words := seg.Args[:commandWords[i]]
+				names = append(names, words...)
+				pieces = append(pieces, piece{text: restore(strings.Join(words, " "))})
+			}
 
-		case flagparse.SegmentFlag:
-			flagIdx++
-			flag := seg.Flag
+			for _, arg := range seg.Args[commandWords[i]:] {
+				if arg == "--" {
+					if insertAt == -1 {
+						insertAt = len(pieces)
+					}
+					pieces = append(pieces, piece{text: arg})
+					continue
+				}
+
+				addArg(arg)
+			}
 
-			fmt.Printf("\033[1m[%d/%d] Flag: %s\033[0m", flagIdx, flagCount, flag.Name)
+		case flagparse.SegmentFlag:
+			flag := *seg.Flag
+			label := "Flag: " + flag.Name
 			if flag.Value != "" {
-				fmt.Printf(" = %s", flag.Value)
+				label += " = " + restore(flag.Value)
 			}
-			fmt.Println()
 
-			binding, cancelled := processFlag(*flag)
-			if cancelled {
-				return BuildResult{Cancelled: true}
+			b, bound := current[flag.Value]
+			if b.Env != "" || b.Source != nil {
+				bound = false // Environment and source bindings are kept as-is
 			}
+			switch {
+			case flag.Value != "" && !flag.Joined && (b.Flag != "" || b.Type == binding.BindingBooleanFlag):
+				// A static flag followed by a binding with its own flag, e.g.
+				// "--fp16 {%--config:cfgs%}": the flag takes no value here
+				addParam(param{flag: flagparse.Flag{Name: flag.Name, IsBoolean: true}, label: "Flag: " + flag.Name})
+				addArg(flag.Value)
+			case bound:
+				flag.Value = ""
+				addParam(param{flag: flag, current: &b, label: label})
+			case restore(flag.Value) != flag.Value:
+				flag.Value = restore(flag.Value)
+				pieces = append(pieces, piece{text: staticFlag(flag)})
+			default:
+				addParam(param{flag: flag, label: label})
+			}
+		}
+	}
+	return pieces, names, insertAt
+}
 
-			if binding != "" {
-				parts = append(parts, binding)
+// scanHelp runs the command's --help and parses the documented flags
+// Failures are reported and leave the builder working from the example alone
+func scanHelp(segments []flagparse.Segment, timeout time.Duration) []helpparse.Option {
+	name := flagparse.CommandName(segments)
+	if len(name) == 0 {
+		return nil
+	}
+	if timeout == 0 {
+		timeout = 3 * time.Second
+	}
+
+	// Scripts are project code, which may do more than print help
+	if flagparse.RunsScript(name) {
+		run, ok := picker.PromptYesNoDefault(fmt.Sprintf("Run '%s --help' to read its flags? This executes the script", strings.Join(name, " ")), false)
+		if !ok || !run {
+			fmt.Println("\033[2mSkipping --help\033[0m")
+			return nil
+		}
+	}
+
+	fmt.Printf("\033[2mReading '%s --help'...\033[0m\n", strings.Join(name, " "))
+	help, err := helpparse.Fetch(name, timeout)
+	if err != nil {
+		fmt.Printf("\033[2mSkipping --help: %v\033[0m\n", err)
+		return nil
+	}
+
+	options := helpparse.Parse(help)
+	if len(options) == 0 {
+		fmt.Println("\033[2mNo flags found in --help output\033[0m")
+	}
+	return options
+}
+
+// missingOptions returns documented flags that the example doesn't use
+func missingOptions(documented []helpparse.Option, segments []flagparse.Segment) []helpparse.Option {
+	var missing []helpparse.Option
+	for _, opt := range documented {
+		used := false
+		for _, seg := range segments {
+			if seg.Type == flagparse.SegmentFlag && opt.Has(seg.Flag.Name) {
+				used = true
+				break
 			}
+		}
+		if !used {
+			missing = append(missing, opt)
+		}
+	}
+	return missing
+}
 
-			fmt.Println()
+// documentedChoices returns the choices --help lists for a flag, if any
+func documentedChoices(documented []helpparse.Option, name string) []string {
+	for _, opt := range documented {
+		if opt.Has(name) {
+			return opt.Choices
 		}
 	}
+	return nil
+}
 
-	result := strings.Join(parts, " ")
-	return BuildResult{Command: result, Cancelled: false}
+// addDocumentedFlags lets the user pick flags from --help to add as optional
+// bindings: switches become {%?--flag%}, flags with documented choices a
+// value list, and other flags custom input
+func addDocumentedFlags(extras []helpparse.Option) ([]string, bool) {
+	fmt.Printf("\033[1mFound %d more flag(s) in --help\033[0m\n", len(extras))
+
+	var added []string
+	remaining := append([]helpparse.Option{}, extras...)
+
+	for len(remaining) > 0 {
+		labels := make([]string, len(remaining))
+		for i, opt := range remaining {
+			labels[i] = optionLabel(opt)
+		}
+
+		result := picker.PickString(labels, "Add an optional flag ([Skip] when done):", true, false)
+		if result.Action == picker.ActionCancel {
+			return nil, true
+		}
+		if result.Action != picker.ActionSelect {
+			break
+		}
+
+		idx := -1
+		for i, label := range labels {
+			if label == result.Value {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			break
+		}
+
+		binding := documentedBinding(remaining[idx])
+		fmt.Printf("  Added %s\n", binding)
+		added = append(added, binding)
+		remaining = append(remaining[:idx], remaining[idx+1:]...)
+	}
+
+	fmt.Println()
+	return added, false
+}
+
+// optionLabel formats a documented flag for the picker
+// e.g. "--precision {fp16,bf16,fp32}  training precision"
+func optionLabel(opt helpparse.Option) string {
+	label := opt.Name()
+	if opt.Metavar != "" {
+		label += " " + opt.Metavar
+	}
+	if opt.Description != "" {
+		label += "  " + opt.Description
+	}
+	return label
+}
+
+// documentedBinding builds the optional binding for a flag from --help
+func documentedBinding(opt helpparse.Option) string {
+	if !opt.TakesValue {
+		return fmt.Sprintf("{%%?%s%%}", opt.Name())
+	}
+
+	prefix := opt.Name() + ":"
+	if opt.OptionalArg {
+		prefix = opt.Name() + "="
+	}
+
+	values := "[...]"
+	if len(opt.Choices) > 0 {
+		values = "[" + strings.Join(opt.Choices, ",") + "]"
+	}
+	return fmt.Sprintf("{%%?%s%s%%}", prefix, values)
 }
 
 // processFlag interactively processes a single flag
+// choices are values documented in --help; cur is the flag's existing
+// binding when rebuilding, used to default every prompt
 // Returns the binding string and whether user cancelled
-func processFlag(flag flagparse.Flag) (string, bool) {
+func processFlag(flag flagparse.Flag, choices []string, cur *binding.Binding) (string, bool) {
 	if flag.IsBoolean {
-		return processBooleanFlag(flag)
+		return processBooleanFlag(flag, cur)
 	}
-	return processValueFlag(flag)
+	return processValueFlag(flag, choices, cur)
 }
 
 // processBooleanFlag handles flags that have no value or True/False value
-func processBooleanFlag(flag flagparse.Flag) (string, bool) {
+func processBooleanFlag(flag flagparse.Flag, cur *binding.Binding) (string, bool) {
 	// Case 1: Pure boolean flag (no value, e.g., --verbose)
 	if flag.Value == "" {
 		options := []string{
@@ -100,7 +427,12 @@ func processBooleanFlag(flag flagparse.Flag) (string, bool) {
 			"Make optional (choose to include or skip at runtime)",
 		}
 
-		idx := picker.PickOption("How should this flag behave?", options)
+		selected := 0
+		if cur != nil {
+			selected = 1
+		}
+
+		idx := picker.PickOptionDefault("How should this flag behave?", options, selected)
 		if idx == -1 {
 			return "", true // cancelled
 		}
@@ -130,120 +462,494 @@ func processBooleanFlag(flag flagparse.Flag) (string, bool) {
 
 	switch idx {
 	case 0: // Static
-		return flag.Name + " " + flag.Value, false
+		return staticFlag(flag), false
 	case 1: // Dynamic True/False
-		return fmt.Sprintf("{%%%s:[True,False]%%}", flag.Name), false
+		return fmt.Sprintf("{%%%s[True,False]%%}", flagPrefix(flag)), false
 	case 2: // Optional + Dynamic
-		return fmt.Sprintf("{%%?%s:[True,False]%%}", flag.Name), false
+		return fmt.Sprintf("{%%?%s[True,False]%%}", flagPrefix(flag)), false
 	}
 
-	return flag.Name + " " + flag.Value, false
+	return staticFlag(flag), false
 }
 
-// processValueFlag handles flags that have a value
-func processValueFlag(flag flagparse.Flag) (string, bool) {
+// processValueFlag handles flags that have a value, and positional arguments
+// (a Flag with an empty Name)
+func processValueFlag(flag flagparse.Flag, choices []string, cur *binding.Binding) (string, bool) {
 	options := []string{
 		"Keep static (always use this value)",
 		"Directory picker (browse and select a path)",
 		"Value list (choose from predefined options)",
+		"Custom input (type a value at runtime)",
+		"Secret (masked input, kept out of output and history)",
 	}
 
-	idx := picker.PickOption("How should this flag's value be set?", options)
+	question := "How should this flag's value be set?"
+	if flag.Name == "" {
+		question = "How should this argument be set?"
+	}
+
+	// Default to the current kind of binding, or secret for credential flags
+	selected := 0
+	if cur == nil && secretFlagPattern.MatchString(flag.Name) {
+		selected = 4
+	}
+	if cur != nil {
+		switch {
+		case cur.Type == binding.BindingDirectory:
+			selected = 1
+		case cur.Type == binding.BindingSecret:
+			selected = 4
+		case cur.Type == binding.BindingInput:
+			selected = 3
+		case cur.AllowCustom && len(cur.Values) == 0:
+			selected = 3
+		default:
+			selected = 2
+		}
+	}
+
+	idx := picker.PickOptionDefault(question, options, selected)
 	if idx == -1 {
 		return "", true // cancelled
 	}
 
 	switch idx {
 	case 0: // Static
-		return flag.Name + " " + flag.Value, false
+		if cur != nil {
+			// A bound value has no static value yet
+			value, cancelled := picker.PromptInput("Value: ", currentValue(cur))
+			if cancelled {
+				return "", true
+			}
+			flag.Value = value
+		}
+		return staticFlag(flag), false
 
 	case 1: // Directory binding
-		return buildDirectoryBinding(flag)
+		return buildDirectoryBinding(flag, cur)
 
 	case 2: // Value list
-		return buildValueListBinding(flag)
+		return buildValueListBinding(flag, choices, cur)
+
+	case 3: // Free-text input
+		return buildCustomBinding(flag, cur)
+
+	case 4: // Secret
+		return buildSecretBinding(flag, cur)
 	}
 
-	return flag.Name + " " + flag.Value, false
+	return staticFlag(flag), false
 }
 
+// dirFlagPattern matches flag names that usually take a directory
+var dirFlagPattern = regexp.MustCompile(`(?i)[-_]dir$|directory|folder`)
+
 // buildDirectoryBinding creates a directory picker binding
-func buildDirectoryBinding(flag flagparse.Flag) (string, bool) {
+func buildDirectoryBinding(flag flagparse.Flag, cur *binding.Binding) (string, bool) {
 	// Ask for base directory (pre-filled with extracted default)
-	defaultDir := extractDirectory(flag.Value)
+	defaultDir := extractDirectory(flagparse.Unquote(flag.Value))
+	defaultFilter := ""
+	if dirFlagPattern.MatchString(flag.Name) {
+		defaultFilter = "dirs,depth=1"
+	}
+	if cur != nil && cur.Type == binding.BindingDirectory {
+		defaultDir, defaultFilter = rawDirectory(*cur)
+	}
+
 	baseDir, cancelled := picker.PromptInput("Base directory: ", defaultDir)
 	if cancelled {
 		return "", true
 	}
 
-	// Ask for filter pattern
-	filter, cancelled := picker.PromptInput("Filter pattern (e.g., *.yaml, empty for all): ", "")
+	// Ask for filter pattern and options
+	fmt.Println("\033[2mTip: Combine patterns with commas, !glob to exclude; options: dirs, leaf, marker=config.yaml, depth=2, gitignore, sort=mtime, browse.\033[0m")
+	filter, cancelled := picker.PromptValidated("Filter pattern (e.g., *.yaml, empty for all): ", defaultFilter, func(v string) error {
+		if v == "" {
+			return nil
+		}
+		return checkSpec(baseDir + ":" + v)
+	})
 	if cancelled {
 		return "", true
 	}
 
 	// Ask if optional
-	optional, ok := picker.PromptYesNo("Make this flag optional?")
+	optional, ok := askOptional(flag, cur)
 	if !ok {
 		return "", true // cancelled
 	}
 
 	// Build binding: {%?--flag:/path[:filter]%} or {%--flag:/path[:filter]%}
-	var binding string
+	var result string
 	if optional {
-		binding = fmt.Sprintf("{%%?%s:%s", flag.Name, baseDir)
+		result = fmt.Sprintf("{%%?%s%s", flagPrefix(flag), baseDir)
 	} else {
-		binding = fmt.Sprintf("{%%%s:%s", flag.Name, baseDir)
+		result = fmt.Sprintf("{%%%s%s", flagPrefix(flag), baseDir)
 	}
 
 	if filter != "" {
-		binding += ":" + filter
+		result += ":" + filter
 	}
-	binding += "%}"
+	result += "%}"
 
-	return binding, false
+	return result, false
 }
 
 // buildValueListBinding creates a value list binding
-func buildValueListBinding(flag flagparse.Flag) (string, bool) {
+// Documented choices can be used as the list instead of typing values
+func buildValueListBinding(flag flagparse.Flag, choices []string, cur *binding.Binding) (string, bool) {
+	var values []string
+	if cur != nil && (cur.Type == binding.BindingValues || cur.Type == binding.BindingRange) {
+		// Rebuilding: edit the current list in one line
+		current := strings.Join(valueList(cur), ",")
+		if cur.Range != nil {
+			current = cur.Range.Spec
+		}
+		line, cancelled := picker.PromptValidated("Values (comma-separated, '...' for custom input, or range(start,end)): ", current, checkRange)
+		if cancelled {
+			return "", true
+		}
+		if line = strings.TrimSpace(line); isRange(line) {
+			values = []string{line}
+		} else {
+			for _, v := range strings.Split(line, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					values = append(values, v)
+				}
+			}
+		}
+	} else if len(choices) > 0 {
+		useChoices, ok := picker.PromptYesNo(fmt.Sprintf("Use documented choices [%s]?", strings.Join(choices, ",")))
+		if !ok {
+			return "", true // cancelled
+		}
+		if useChoices {
+			values = choices
+		}
+	}
+
+	if len(values) == 0 {
+		values = promptValues(flag)
+	}
+
+	if len(values) == 0 {
+		// No values entered, keep static
+		return staticFlag(flag), false
+	}
+
+	// Ask if optional
+	optional, ok := askOptional(flag, cur)
+	if !ok {
+		return "", true // cancelled
+	}
+
+	// Build binding: {%?--flag:[val1,val2,...]%} or {%--flag:[val1,val2,...]%}
+	list := "[" + strings.Join(values, ",") + "]"
+	if len(values) == 1 && isRange(values[0]) {
+		list = values[0]
+	}
+	if optional {
+		return fmt.Sprintf("{%%?%s%s%%}", flagPrefix(flag), list), false
+	}
+	return fmt.Sprintf("{%%%s%s%%}", flagPrefix(flag), list), false
+}
+
+// promptValues reads value list entries one per line
+func promptValues(flag flagparse.Flag) []string {
 	fmt.Println("\033[2mEnter values one per line. Empty line or Esc to finish.\033[0m")
 	fmt.Printf("\033[2mTip: Add '...' as the last value to allow custom input at runtime.\033[0m\n")
+	fmt.Printf("\033[2mTip: For numbers, enter a range instead: range(0,10), range(0,100,5), range(1,64,*2).\033[0m\n")
 
-	// Pre-fill with current value as first suggestion
+	// Show current value as a suggestion
 	var values []string
 	if flag.Value != "" {
 		fmt.Printf("\033[2mSuggested: %s\033[0m\n", flag.Value)
 	}
 
 	for {
-		v, cancelled := picker.PromptInput("Value: ", "")
+		v, cancelled := picker.PromptValidated("Value: ", "", checkRange)
 		if cancelled || v == "" {
 			break
 		}
 		values = append(values, v)
+		if isRange(v) && len(values) == 1 {
+			break // A range is the whole list
+		}
 	}
 
-	if len(values) == 0 {
-		// No values entered, keep static
-		return flag.Name + " " + flag.Value, false
+	return values
+}
+
+// inputKinds are the kinds of value a custom input binding can require
+// The first accepts any text; the others become typed input bindings.
+var inputKinds = []struct{ label, kind string }{
+	{"Any text", ""},
+	{"Whole number (e.g. 100, optionally within a range)", binding.InputInt},
+	{"Number (e.g. 0.001, optionally within a range)", binding.InputFloat},
+	{"Text matching a regular expression", binding.InputRegex},
+	{"Path that exists", binding.InputPath},
+	{"URL", binding.InputURL},
+	{"Date (YYYY-MM-DD)", binding.InputDate},
+}
+
+// buildCustomBinding creates a binding that asks for a value at runtime
+// Any text offers the example value as the only predefined choice; other
+// kinds are validated when entered.
+func buildCustomBinding(flag flagparse.Flag, cur *binding.Binding) (string, bool) {
+	values := "[...]"
+	if cur != nil && cur.Type == binding.BindingValues {
+		values = "[" + strings.Join(append(append([]string{}, cur.Values...), "..."), ",") + "]"
+	} else if flag.Value != "" && !strings.ContainsAny(flag.Value, ",[]%") {
+		values = "[" + flag.Value + ",...]"
 	}
 
-	// Ask if optional
-	optional, ok := picker.PromptYesNo("Make this flag optional?")
+	spec, cancelled := askInputKind(flag, cur)
+	if cancelled {
+		return "", true
+	}
+	if spec != "" {
+		values = spec
+	}
+
+	optional, ok := askOptional(flag, cur)
 	if !ok {
 		return "", true // cancelled
 	}
 
-	// Build binding: {%?--flag:[val1,val2,...]%} or {%--flag:[val1,val2,...]%}
-	valueList := "[" + strings.Join(values, ",") + "]"
-	var binding string
 	if optional {
-		binding = fmt.Sprintf("{%%?%s:%s%%}", flag.Name, valueList)
-	} else {
-		binding = fmt.Sprintf("{%%%s:%s%%}", flag.Name, valueList)
+		return fmt.Sprintf("{%%?%s%s%%}", flagPrefix(flag), values), false
 	}
+	return fmt.Sprintf("{%%%s%s%%}", flagPrefix(flag), values), false
+}
+
+// askInputKind asks what kind of value a custom input takes
+// Returns the typed input spec (e.g. "int(1,1000)"), or "" for any text.
+// The default is the current kind when rebuilding, or a guess from the example.
+func askInputKind(flag flagparse.Flag, cur *binding.Binding) (string, bool) {
+	current := guessInputKind(flagparse.Unquote(flag.Value))
+	if cur != nil {
+		current = ""
+		if cur.Input != nil {
+			current = cur.Input.Kind
+		}
+	}
+
+	labels := make([]string, len(inputKinds))
+	selected := 0
+	for i, k := range inputKinds {
+		labels[i] = k.label
+		if k.kind == current {
+			selected = i
+		}
+	}
+	idx := picker.PickOptionDefault("What kind of value does it take?", labels, selected)
+	if idx == -1 {
+		return "", true
+	}
+
+	kind := inputKinds[idx].kind
+	switch kind {
+	case binding.InputInt, binding.InputFloat:
+		bounds := ""
+		if cur != nil && cur.Input != nil && cur.Input.Kind == kind {
+			bounds = strings.TrimSuffix(strings.TrimPrefix(cur.Input.Spec, kind+"("), ")")
+			bounds = strings.TrimPrefix(bounds, kind)
+		}
+		bounds, cancelled := picker.PromptValidated("Range as min,max (either may be empty, empty for any): ", bounds, func(v string) error {
+			return checkSpec(inputSpec(kind, v))
+		})
+		if cancelled {
+			return "", true
+		}
+		return inputSpec(kind, bounds), false
+
+	case binding.InputRegex:
+		pattern := ""
+		if cur != nil && cur.Input != nil && cur.Input.Pattern != nil {
+			pattern = cur.Input.Pattern.String()
+		}
+		pattern, cancelled := picker.PromptValidated("Regular expression: ", pattern, func(v string) error {
+			return checkSpec("regex:" + v)
+		})
+		if cancelled {
+			return "", true
+		}
+		return "regex:" + pattern, false
+	}
+	return kind, false
+}
 
-	return binding, false
+// isRange reports whether a value list entry is a range such as range(0,10)
+func isRange(value string) bool {
+	return strings.HasPrefix(value, "range(")
+}
+
+// checkRange reports a problem with a value list entry that is a range
+func checkRange(value string) error {
+	if !isRange(strings.TrimSpace(value)) {
+		return nil
+	}
+	return checkSpec(strings.TrimSpace(value))
+}
+
+// inputSpec writes an int or float spec with optional "min,max" bounds
+func inputSpec(kind, bounds string) string {
+	if strings.TrimSpace(bounds) == "" {
+		return kind
+	}
+	return kind + "(" + bounds + ")"
+}
+
+// checkSpec reports whether a typed input spec is valid
+func checkSpec(spec string) error {
+	if strings.Contains(spec, "%}") {
+		return fmt.Errorf("can't contain %%}")
+	}
+	_, err := binding.Parse("{%" + spec + "%}")
+	return err
+}
+
+// guessInputKind suggests an input kind from an example value
+func guessInputKind(value string) string {
+	if value == "" {
+		return ""
+	}
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return binding.InputInt
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return binding.InputFloat
+	}
+	if u, err := url.Parse(value); err == nil && u.Scheme != "" && u.Host != "" {
+		return binding.InputURL
+	}
+	if _, err := time.Parse("2006-01-02", value); err == nil {
+		return binding.InputDate
+	}
+	return ""
+}
+
+// secretFlagPattern matches flag names that usually take credentials
+var secretFlagPattern = regexp.MustCompile(`(?i)passw|token|secret|api[-_]?key`)
+
+// buildSecretBinding creates a binding read with masked input
+// The value can be passed as an environment variable instead of an argument,
+// which keeps it out of the process list.
+func buildSecretBinding(flag flagparse.Flag, cur *binding.Binding) (string, bool) {
+	question := "Environment variable to pass it in (empty to pass as argument): "
+	if flag.Name != "" {
+		question = "Environment variable to pass it in instead of " + flag.Name + " (empty to keep the flag): "
+	}
+	current := ""
+	if cur != nil {
+		current = cur.EnvVar
+	}
+	var envVar string
+	for {
+		input, cancelled := picker.PromptInput(question, current)
+		if cancelled {
+			return "", true
+		}
+		envVar = strings.TrimSpace(input)
+		if envVar == "" || binding.IsEnvVarName(envVar) {
+			break
+		}
+		fmt.Printf("Invalid variable name '%s': use letters, numbers and underscores\n", envVar)
+		current = envVar
+	}
+
+	optional, ok := askOptional(flag, cur)
+	if !ok {
+		return "", true // cancelled
+	}
+	prefix := "{%"
+	if optional {
+		prefix = "{%?"
+	}
+
+	if envVar != "" {
+		return prefix + "secret:" + envVar + "%}", false
+	}
+	return prefix + flagPrefix(flag) + "secret%}", false
+}
+
+// askOptional asks whether a flag or positional argument can be skipped
+// When rebuilding, Enter keeps the current setting
+func askOptional(flag flagparse.Flag, cur *binding.Binding) (bool, bool) {
+	question := "Make this flag optional?"
+	if flag.Name == "" {
+		question = "Make this argument optional?"
+	}
+	if cur != nil {
+		return picker.PromptYesNoDefault(question, cur.Optional)
+	}
+	return picker.PromptYesNo(question)
+}
+
+// currentValue suggests a static value for a bound param
+func currentValue(b *binding.Binding) string {
+	if b.Type == binding.BindingDirectory {
+		path, _ := rawDirectory(*b)
+		return path
+	}
+	if len(b.Values) > 0 {
+		return b.Values[0]
+	}
+	if b.Range != nil {
+		return b.Range.At(0)
+	}
+	return ""
+}
+
+// valueList returns a value binding's values, with "..." if it allows custom input
+func valueList(b *binding.Binding) []string {
+	values := append([]string{}, b.Values...)
+	if b.AllowCustom {
+		values = append(values, "...")
+	}
+	return values
+}
+
+// rawDirectory returns a directory binding's path and filter as written
+// binding.Parse makes the path absolute; rebuilding keeps "~/configs" as-is
+func rawDirectory(b binding.Binding) (string, string) {
+	content := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(b.Placeholder, "{%"), "%}"))
+	content = strings.TrimSpace(strings.TrimPrefix(content, "?"))
+	if b.Flag != "" && strings.HasPrefix(content, b.Flag) {
+		content = strings.TrimSpace(content[len(b.Flag)+1:])
+	}
+
+	if lastColon := strings.LastIndex(content, ":"); lastColon > 1 {
+		return content[:lastColon], content[lastColon+1:]
+	}
+	return content, ""
+}
+
+// staticFlag re-emits a flag and its value in the style it was written
+// e.g. "--epochs 100" or "--lr=0.001"; positional arguments are just the value
+func staticFlag(flag flagparse.Flag) string {
+	if flag.Name == "" {
+		return flag.Value
+	}
+	if flag.Value == "" {
+		return flag.Name
+	}
+	if flag.Joined {
+		return flag.Name + "=" + flag.Value
+	}
+	return flag.Name + " " + flag.Value
+}
+
+// flagPrefix returns the flag part of a binding: "--flag:" or, for flags
+// written as --flag=value, "--flag=" so the resolved command keeps that form
+func flagPrefix(flag flagparse.Flag) string {
+	if flag.Name == "" {
+		return ""
+	}
+	if flag.Joined {
+		return flag.Name + "="
+	}
+	return flag.Name + ":"
 }
 
 // extractDirectory extracts the directory portion from a path
@@ -0,0 +1,119 @@
+package builder
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"laziest/internal/binding"
+	"laziest/internal/flagparse"
+)
+
+func TestRawDirectory(t *testing.T) {
+	tests := []struct {
+		command        string
+		expectedPath   string
+		expectedFilter string
+	}{
+		{"python train.py {%~/configs:*.yaml%}", "~/configs", "*.yaml"},
+		{"python train.py {%?--config:configs%}", "configs", ""},
+		{"python train.py {% --config=/data/in:*.csv %}", "/data/in", "*.csv"},
+	}
+
+	for _, tt := range tests {
+		bindings, err := binding.Parse(tt.command)
+		if err != nil || len(bindings) != 1 {
+			t.Fatalf("Parse(%q) = %v, %v", tt.command, bindings, err)
+		}
+		path, filter := rawDirectory(bindings[0])
+		if path != tt.expectedPath || filter != tt.expectedFilter {
+			t.Errorf("rawDirectory(%q) = (%q, %q), expected (%q, %q)", tt.command, path, filter, tt.expectedPath, tt.expectedFilter)
+		}
+	}
+}
+
+func TestGuessInputKind(t *testing.T) {
+	tests := map[string]string{
+		"100":                     binding.InputInt,
+		"0.001":                   binding.InputFloat,
+		"https://api.example.com": binding.InputURL,
+		"2024-01-31":              binding.InputDate,
+		"model.yaml":              "",
+		"":                        "",
+	}
+	for value, expected := range tests {
+		if got := guessInputKind(value); got != expected {
+			t.Errorf("guessInputKind(%q) = %q, expected %q", value, got, expected)
+		}
+	}
+}
+
+// TestRebuildLayout checks that rebuilding keeps every part of a saved
+// command: kept as-is, each param renders as its flag, value or binding
+func TestRebuildLayout(t *testing.T) {
+	tests := []struct {
+		command string
+		params  []string // Labels of the params offered
+	}{
+		{
+			"python train.py --fp16 {%--config:cfgs%}",
+			[]string{"Argument: train.py", "Flag: --fp16", "Flag: {%--config:cfgs%}"},
+		},
+		{
+			"python train.py --fp16 {%?--verbose%} --epochs {%[10,20]%}",
+			[]string{"Argument: train.py", "Flag: --fp16", "Flag: {%?--verbose%}", "Flag: --epochs = {%[10,20]%}"},
+		},
+		{
+			"git checkout --quiet {%--branch:git:branches%}",
+			[]string{"Flag: --quiet"},
+		},
+	}
+
+	for _, tt := range tests {
+		bindings, err := binding.Parse(tt.command)
+		if err != nil {
+			t.Fatalf("Parse(%q) = %v", tt.command, err)
+		}
+		masked := tt.command
+		current := make(map[string]binding.Binding)
+		for i, b := range bindings {
+			marker := fmt.Sprintf("__lz%d__", i)
+			masked = strings.Replace(masked, b.Placeholder, marker, 1)
+			current[marker] = b
+		}
+		restore := func(s string) string {
+			for marker, b := range current {
+				s = strings.Replace(s, marker, b.Placeholder, 1)
+			}
+			return s
+		}
+
+		pieces, _, _ := layout(flagparse.ParseSegments(masked), current, restore)
+		var parts, labels []string
+		for _, pc := range pieces {
+			switch {
+			case pc.param == nil:
+				parts = append(parts, pc.text)
+			case pc.param.current != nil:
+				flag := pc.param.flag
+				flag.Value = pc.param.current.Placeholder
+				if pc.param.current.Flag != "" {
+					flag.Name = "" // The binding writes its own flag
+				}
+				parts = append(parts, staticFlag(flag))
+				labels = append(labels, pc.param.label)
+			default:
+				parts = append(parts, staticFlag(pc.param.flag))
+				labels = append(labels, pc.param.label)
+			}
+		}
+
+		if got := strings.Join(parts, " "); got != tt.command {
+			t.Errorf("layout(%q) rebuilds %q", tt.command, got)
+		}
+		if !reflect.DeepEqual(labels, tt.params) {
+			t.Errorf("layout(%q) params = %q, expected %q", tt.command, labels, tt.params)
+		}
+	}
+}
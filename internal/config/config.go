@@ -7,20 +7,31 @@ import (
 	"path/filepath"
 	"sort"
 	"time"
+
+	"laziest/internal/paths"
 )
 
 // Command represents a saved command with its metadata
 type Command struct {
-	Name    string    `json:"name"`
-	Command string    `json:"command"`
-	Tags    []string  `json:"tags,omitempty"`
-	AddedAt time.Time `json:"added_at"`
+	Name        string            `json:"name"`
+	Command     string            `json:"command"`
+	Tags        []string          `json:"tags,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Cwd         string            `json:"cwd,omitempty"`    // Working directory to run in (empty = current)
+	Env         map[string]string `json:"env,omitempty"`    // Extra environment variables
+	Source      string            `json:"source,omitempty"` // Where an imported command came from, e.g. "project:/path/to/repo"
+	AddedAt     time.Time         `json:"added_at"`
+	Library     string            `json:"-"` // Library the command was loaded from (empty = personal)
 }
 
 // Config holds all saved commands
+// Commands also holds the read-only commands of the configured libraries,
+// which are loaded with the config but never saved to it.
 type Config struct {
-	Commands []Command `json:"commands"`
-	path     string
+	Commands      []Command `json:"commands"`
+	Libraries     []Library `json:"libraries,omitempty"`
+	LibraryErrors []string  `json:"-"` // Problems loading libraries, e.g. unreadable files
+	path          string
 }
 
 // HistoryEntry represents a recently executed command
@@ -32,11 +43,7 @@ type HistoryEntry struct {
 
 // GetConfigDir returns the path to the config directory
 func GetConfigDir() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
-	}
-	return filepath.Join(home, ".config", "laziest"), nil
+	return paths.ConfigDir()
 }
 
 // GetConfigPath returns the path to the config file
@@ -72,6 +79,7 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 	cfg.path = configPath
+	cfg.loadLibraries()
 
 	return cfg, nil
 }
@@ -83,7 +91,11 @@ func (c *Config) Save() error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	data, err := json.MarshalIndent(c, "", "  ")
+	// Library commands stay in their libraries
+	personal := *c
+	personal.Commands = c.PersonalCommands()
+
+	data, err := json.MarshalIndent(personal, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -125,6 +137,9 @@ func (c *Config) AddCommand(name, command string, tags []string) error {
 func (c *Config) RemoveCommandByName(name string) error {
 	for i, cmd := range c.Commands {
 		if cmd.Name == name {
+			if cmd.Library != "" {
+				return readOnlyError(cmd)
+			}
 			c.Commands = append(c.Commands[:i], c.Commands[i+1:]...)
 			return nil
 		}
@@ -136,6 +151,9 @@ func (c *Config) RemoveCommandByName(name string) error {
 func (c *Config) UpdateCommand(originalName, newName, newCommand string, newTags []string) error {
 	for i, cmd := range c.Commands {
 		if cmd.Name == originalName {
+			if cmd.Library != "" {
+				return readOnlyError(cmd)
+			}
 			// Validate new tags
 			for _, tag := range newTags {
 				if !IsValidTag(tag) {
@@ -151,6 +169,86 @@ func (c *Config) UpdateCommand(originalName, newName, newCommand string, newTags
 	return fmt.Errorf("command '%s' not found", originalName)
 }
 
+// UpdateCommandDetails updates the description, working directory and environment of a command
+func (c *Config) UpdateCommandDetails(name, description, cwd string, env map[string]string) error {
+	for i, cmd := range c.Commands {
+		if cmd.Name == name {
+			if cmd.Library != "" {
+				return readOnlyError(cmd)
+			}
+			c.Commands[i].Description = description
+			c.Commands[i].Cwd = cwd
+			if len(env) == 0 {
+				env = nil
+			}
+			c.Commands[i].Env = env
+			return nil
+		}
+	}
+	return fmt.Errorf("command '%s' not found", name)
+}
+
+// SyncResult lists the names changed by SyncSource
+type SyncResult struct {
+	Added   []string
+	Updated []string
+	Removed []string
+	Skipped []string // Names already used by commands from elsewhere
+}
+
+// SyncSource makes the commands imported from source match cmds
+// Existing ones keep their tags and added date but take the new command,
+// description and working directory; ones no longer in cmds are removed.
+func (c *Config) SyncSource(source string, cmds []Command) (SyncResult, error) {
+	var result SyncResult
+
+	wanted := make(map[string]Command, len(cmds))
+	for _, cmd := range cmds {
+		for _, tag := range cmd.Tags {
+			if !IsValidTag(tag) {
+				return result, fmt.Errorf("invalid tag '%s': must contain only letters, numbers, and underscores", tag)
+			}
+		}
+		wanted[cmd.Name] = cmd
+	}
+
+	kept := c.Commands[:0]
+	existing := make(map[string]bool)
+	for _, cmd := range c.Commands {
+		existing[cmd.Name] = true
+		if cmd.Source != source {
+			kept = append(kept, cmd)
+			continue
+		}
+		w, ok := wanted[cmd.Name]
+		if !ok {
+			result.Removed = append(result.Removed, cmd.Name)
+			continue
+		}
+		if cmd.Command != w.Command || cmd.Description != w.Description || cmd.Cwd != w.Cwd {
+			cmd.Command, cmd.Description, cmd.Cwd = w.Command, w.Description, w.Cwd
+			result.Updated = append(result.Updated, cmd.Name)
+		}
+		kept = append(kept, cmd)
+	}
+	c.Commands = kept
+
+	for _, cmd := range cmds {
+		if existing[cmd.Name] {
+			if other, err := c.GetCommandByName(cmd.Name); err == nil && other.Source != source {
+				result.Skipped = append(result.Skipped, cmd.Name)
+			}
+			continue
+		}
+		cmd.Source = source
+		cmd.AddedAt = time.Now()
+		c.Commands = append(c.Commands, cmd)
+		result.Added = append(result.Added, cmd.Name)
+	}
+
+	return result, nil
+}
+
 // GetCommandByName returns a command by its name
 func (c *Config) GetCommandByName(name string) (*Command, error) {
 	for i, cmd := range c.Commands {
@@ -175,6 +273,17 @@ func (c *Config) GetCommandsByTag(tag string) []Command {
 	return result
 }
 
+// GetCommandsBySource returns all commands imported from source
+func (c *Config) GetCommandsBySource(source string) []Command {
+	var result []Command
+	for _, cmd := range c.Commands {
+		if cmd.Source == source {
+			result = append(result, cmd)
+		}
+	}
+	return result
+}
+
 // GetAllTags returns a sorted list of all unique tags
 func (c *Config) GetAllTags() []string {
 	tagSet := make(map[string]struct{})
@@ -218,6 +327,28 @@ func IsValidTag(tag string) bool {
 	return true
 }
 
+// IsValidName checks if a command name can be used as a shell alias
+func IsValidName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+
+	// Must start with letter or underscore
+	first := name[0]
+	if !((first >= 'a' && first <= 'z') || (first >= 'A' && first <= 'Z') || first == '_') {
+		return false
+	}
+
+	// Rest must be alphanumeric or underscore
+	for _, c := range name[1:] {
+		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_') {
+			return false
+		}
+	}
+
+	return true
+}
+
 // GetHistoryPath returns the path to the history file
 func GetHistoryPath() (string, error) {
 	dir, err := GetConfigDir()
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"laziest/internal/binding"
+	"laziest/internal/keys"
+)
+
+// Library is a directory of shared command files, loaded read-only
+// Its commands are named "<library>/<command>", e.g. "ml/train". Files must
+// be signed by a trusted key unless AllowUntrusted is set.
+type Library struct {
+	Name           string `json:"name"`
+	Path           string `json:"path"` // Absolute directory path
+	AllowUntrusted bool   `json:"allow_untrusted,omitempty"`
+}
+
+// libraryFile is the layout of a library's JSON files
+// It matches both commands.json and exported packs.
+type libraryFile struct {
+	Commands []Command `json:"commands"`
+}
+
+// BaseName returns a command's name without its library namespace
+func (cmd Command) BaseName() string {
+	if cmd.Library == "" {
+		return cmd.Name
+	}
+	return strings.TrimPrefix(cmd.Name, cmd.Library+"/")
+}
+
+// readOnlyError explains why a library command can't be changed
+func readOnlyError(cmd Command) error {
+	return fmt.Errorf("'%s' is from the '%s' library and is read-only", cmd.Name, cmd.Library)
+}
+
+// PersonalCommands returns the commands saved in the personal config
+func (c *Config) PersonalCommands() []Command {
+	result := []Command{}
+	for _, cmd := range c.Commands {
+		if cmd.Library == "" {
+			result = append(result, cmd)
+		}
+	}
+	return result
+}
+
+// AddLibrary registers a library directory and loads its commands
+func (c *Config) AddLibrary(name, path string, allowUntrusted bool) error {
+	if !IsValidTag(name) {
+		return fmt.Errorf("invalid library name '%s': must contain only letters, numbers, and underscores", name)
+	}
+	for _, lib := range c.Libraries {
+		if lib.Name == name {
+			return fmt.Errorf("library '%s' already exists", name)
+		}
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", abs)
+	}
+
+	lib := Library{Name: name, Path: abs, AllowUntrusted: allowUntrusted}
+	c.Libraries = append(c.Libraries, lib)
+	c.loadLibrary(lib, c.trustedKeys())
+	return nil
+}
+
+// RemoveLibrary unregisters a library and drops its commands
+func (c *Config) RemoveLibrary(name string) error {
+	for i, lib := range c.Libraries {
+		if lib.Name != name {
+			continue
+		}
+		c.Libraries = append(c.Libraries[:i], c.Libraries[i+1:]...)
+
+		kept := c.Commands[:0]
+		for _, cmd := range c.Commands {
+			if cmd.Library != name {
+				kept = append(kept, cmd)
+			}
+		}
+		c.Commands = kept
+		return nil
+	}
+	return fmt.Errorf("library '%s' not found", name)
+}
+
+// loadLibraries appends the commands of every configured library
+func (c *Config) loadLibraries() {
+	if len(c.Libraries) == 0 {
+		return
+	}
+	trusted := c.trustedKeys()
+	for _, lib := range c.Libraries {
+		c.loadLibrary(lib, trusted)
+	}
+}
+
+// trustedKeys returns the keys library files may be signed with
+func (c *Config) trustedKeys() []keys.Key {
+	dir, err := GetConfigDir()
+	if err != nil {
+		c.LibraryErrors = append(c.LibraryErrors, err.Error())
+		return nil
+	}
+	trusted, err := keys.LoadTrusted(dir)
+	if err != nil {
+		c.LibraryErrors = append(c.LibraryErrors, err.Error())
+	}
+	return trusted
+}
+
+// loadLibrary appends the commands found in a library's *.json files
+// Problems are recorded in LibraryErrors rather than failing the whole load.
+func (c *Config) loadLibrary(lib Library, trusted []keys.Key) {
+	files, err := filepath.Glob(filepath.Join(lib.Path, "*.json"))
+	if err == nil && len(files) == 0 {
+		if _, statErr := os.Stat(lib.Path); statErr != nil {
+			err = statErr
+		}
+	}
+	if err != nil {
+		c.LibraryErrors = append(c.LibraryErrors, fmt.Sprintf("%s: %v", lib.Name, err))
+		return
+	}
+	sort.Strings(files)
+
+	seen := make(map[string]bool)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			c.LibraryErrors = append(c.LibraryErrors, fmt.Sprintf("%s: %v", lib.Name, err))
+			continue
+		}
+		if !lib.AllowUntrusted {
+			if _, err := keys.Verify(data, trusted); err != nil {
+				c.LibraryErrors = append(c.LibraryErrors, fmt.Sprintf("%s: %s skipped: %v", lib.Name, filepath.Base(file), err))
+				continue
+			}
+		}
+		var lf libraryFile
+		if err := json.Unmarshal(data, &lf); err != nil {
+			c.LibraryErrors = append(c.LibraryErrors, fmt.Sprintf("%s: %s: %v", lib.Name, filepath.Base(file), err))
+			continue
+		}
+
+		for _, cmd := range lf.Commands {
+			if !IsValidName(cmd.Name) || seen[cmd.Name] {
+				c.LibraryErrors = append(c.LibraryErrors, fmt.Sprintf("%s: %s: invalid or duplicate name '%s'", lib.Name, filepath.Base(file), cmd.Name))
+				continue
+			}
+			seen[cmd.Name] = true
+			if err := checkLibraryCommand(cmd); err != nil {
+				c.LibraryErrors = append(c.LibraryErrors, fmt.Sprintf("%s: %s: %s skipped: %v", lib.Name, filepath.Base(file), cmd.Name, err))
+				continue
+			}
+
+			cmd.Name = lib.Name + "/" + cmd.Name
+			cmd.Library = lib.Name
+			cmd.Source = ""
+			c.Commands = append(c.Commands, cmd)
+		}
+	}
+}
+
+// checkLibraryCommand checks what a pack import would: the tags, which end up
+// in aliases and filters, and the bindings, so a broken command is reported
+// when the library loads rather than when it runs
+func checkLibraryCommand(cmd Command) error {
+	for _, tag := range cmd.Tags {
+		if !IsValidTag(tag) {
+			return fmt.Errorf("invalid tag '%s'", tag)
+		}
+	}
+	_, err := binding.Parse(cmd.Command)
+	return err
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"laziest/internal/keys"
+)
+
+func TestLoadLibrary(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	files := map[string]string{
+		"train.json": `{"commands": [{"name": "train", "command": "python train.py", "tags": ["ML"]}, {"name": "bad-name", "command": "ls"}]}`,
+		"eval.json":  `{"version": 1, "commands": [{"name": "eval", "command": "python eval.py"}, {"name": "train", "command": "dup"}]}`,
+		"notes.json": `not json`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &Config{Commands: []Command{{Name: "gs", Command: "git status"}}}
+	if err := cfg.AddLibrary("ml", dir, true); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, cmd := range cfg.Commands {
+		names = append(names, cmd.Name)
+	}
+	expected := []string{"gs", "ml/eval", "ml/train"}
+	if len(names) != len(expected) {
+		t.Fatalf("commands = %v, expected %v", names, expected)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("commands = %v, expected %v", names, expected)
+		}
+	}
+	if len(cfg.LibraryErrors) != 3 {
+		t.Errorf("LibraryErrors = %v, expected 3 problems", cfg.LibraryErrors)
+	}
+
+	train, _ := cfg.GetCommandByName("ml/train")
+	if train.Library != "ml" || train.BaseName() != "train" {
+		t.Errorf("ml/train = %+v", *train)
+	}
+	if err := cfg.RemoveCommandByName("ml/train"); err == nil {
+		t.Error("RemoveCommandByName() on a library command should fail")
+	}
+	if err := cfg.UpdateCommand("ml/train", "ml/train", "x", nil); err == nil {
+		t.Error("UpdateCommand() on a library command should fail")
+	}
+	if got := cfg.PersonalCommands(); len(got) != 1 || got[0].Name != "gs" {
+		t.Errorf("PersonalCommands() = %v", got)
+	}
+
+	if err := cfg.RemoveLibrary("ml"); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Commands) != 1 {
+		t.Errorf("commands after RemoveLibrary = %v", cfg.Commands)
+	}
+}
+
+// Library commands get the checks a pack import runs, so broken ones are
+// reported when the library loads
+func TestLoadLibraryChecksCommands(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	content := `{"commands": [
+		{"name": "seed", "command": "echo {%range(0,10)%}", "tags": ["ml"]},
+		{"name": "broken", "command": "echo {%range(0,10,0)%}"},
+		{"name": "tagged", "command": "ls", "tags": ["bad tag"]}
+	]}`
+	if err := os.WriteFile(filepath.Join(dir, "team.json"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{}
+	if err := cfg.AddLibrary("team", dir, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Commands) != 1 || cfg.Commands[0].Name != "team/seed" {
+		t.Errorf("commands = %v, expected only team/seed", cfg.Commands)
+	}
+	if len(cfg.LibraryErrors) != 2 {
+		t.Fatalf("LibraryErrors = %v, expected 2 problems", cfg.LibraryErrors)
+	}
+	for i, name := range []string{"broken", "tagged"} {
+		if !strings.Contains(cfg.LibraryErrors[i], name) {
+			t.Errorf("LibraryErrors[%d] = %q, expected it to name %s", i, cfg.LibraryErrors[i], name)
+		}
+	}
+}
+
+func TestLoadLibrarySigned(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir, err := GetConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := keys.Generate(configDir, "team", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.Trust(configDir, key); err != nil {
+		t.Fatal(err)
+	}
+	private, err := keys.LoadSigner(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	signed, err := keys.Sign([]byte(`{"commands": [{"name": "train", "command": "python train.py"}]}`), private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"signed.json":   signed,
+		"unsigned.json": []byte(`{"commands": [{"name": "eval", "command": "python eval.py"}]}`),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &Config{}
+	if err := cfg.AddLibrary("ml", dir, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Commands) != 1 || cfg.Commands[0].Name != "ml/train" {
+		t.Errorf("commands = %v, expected only ml/train", cfg.Commands)
+	}
+	if len(cfg.LibraryErrors) != 1 || !strings.Contains(cfg.LibraryErrors[0], "unsigned.json skipped: not signed") {
+		t.Errorf("LibraryErrors = %v", cfg.LibraryErrors)
+	}
+}
@@ -0,0 +1,230 @@
+package editor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"laziest/internal/binding"
+)
+
+// Entry is the editable form of a saved command
+type Entry struct {
+	Name        string
+	Tags        []string
+	Description string
+	Command     string
+	Cwd         string
+	Env         map[string]string
+}
+
+const header = `# Edit the command below and save to apply.
+# Lines starting with '#' are ignored. Clear the command to cancel.
+#
+# name:        alias name (letters, numbers, underscores)
+# tags:        comma-separated
+# description: free text shown alongside the command
+# cwd:         directory to run in (empty = current directory)
+# env:         KEY=VALUE, one per 'env:' line
+#
+# Everything after 'command:' is the command, and may span multiple lines.
+`
+
+// Format renders an entry as the text shown in the editor
+func Format(e Entry) string {
+	var sb strings.Builder
+	sb.WriteString(header)
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("name: %s\n", e.Name))
+	sb.WriteString(fmt.Sprintf("tags: %s\n", strings.Join(e.Tags, ", ")))
+	sb.WriteString(fmt.Sprintf("description: %s\n", e.Description))
+	sb.WriteString(fmt.Sprintf("cwd: %s\n", e.Cwd))
+
+	// Sort env keys for stable output
+	keys := make([]string, 0, len(e.Env))
+	for k := range e.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		sb.WriteString("env:\n")
+	}
+	for _, k := range keys {
+		sb.WriteString(fmt.Sprintf("env: %s=%s\n", k, e.Env[k]))
+	}
+
+	sb.WriteString("\ncommand:\n")
+	sb.WriteString(e.Command)
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// Parse reads an entry back from editor text
+func Parse(text string) (Entry, error) {
+	var e Entry
+	var commandLines []string
+	inCommand := false
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+
+		if inCommand {
+			commandLines = append(commandLines, line)
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		key, value, found := strings.Cut(trimmed, ":")
+		if !found {
+			return Entry{}, fmt.Errorf("line %d: expected 'key: value', got %q", lineNum, trimmed)
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "name":
+			e.Name = value
+		case "tags":
+			for _, t := range strings.Split(value, ",") {
+				t = strings.TrimSpace(t)
+				if t != "" {
+					e.Tags = append(e.Tags, t)
+				}
+			}
+		case "description":
+			e.Description = value
+		case "cwd":
+			e.Cwd = value
+		case "env":
+			if value == "" {
+				continue
+			}
+			k, v, ok := strings.Cut(value, "=")
+			k = strings.TrimSpace(k)
+			if !ok || k == "" {
+				return Entry{}, fmt.Errorf("line %d: env must be KEY=VALUE, got %q", lineNum, value)
+			}
+			if !binding.IsEnvVarName(k) {
+				return Entry{}, fmt.Errorf("line %d: invalid env name %q: must be letters, numbers and underscores, not starting with a number", lineNum, k)
+			}
+			if e.Env == nil {
+				e.Env = make(map[string]string)
+			}
+			e.Env[k] = v
+		case "command":
+			inCommand = true
+			if value != "" {
+				commandLines = append(commandLines, value)
+			}
+		default:
+			return Entry{}, fmt.Errorf("line %d: unknown field %q", lineNum, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Entry{}, err
+	}
+
+	if !inCommand {
+		return Entry{}, fmt.Errorf("missing 'command:' section")
+	}
+	e.Command = strings.TrimSpace(strings.Join(commandLines, "\n"))
+
+	return e, nil
+}
+
+// Edit opens the entry in the user's editor until it parses and validates
+// validate is called on every parsed entry; a non-nil error re-opens the
+// editor with the message shown at the top of the file.
+// Returns (entry, cancelled, error); cancelled is true if the file was saved
+// without changes or the command was cleared.
+func Edit(e Entry, validate func(Entry) error) (Entry, bool, error) {
+	tmp, err := os.CreateTemp("", "lz-edit-*.txt")
+	if err != nil {
+		return Entry{}, false, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	path := tmp.Name()
+	tmp.Close()
+	defer os.Remove(path)
+
+	content := Format(e)
+
+	for {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			return Entry{}, false, fmt.Errorf("failed to write temp file: %w", err)
+		}
+
+		if err := launch(path); err != nil {
+			return Entry{}, false, err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Entry{}, false, fmt.Errorf("failed to read temp file: %w", err)
+		}
+		if string(data) == content {
+			return Entry{}, true, nil
+		}
+		edited := stripErrors(string(data))
+
+		parsed, err := Parse(edited)
+		if err == nil && parsed.Command == "" {
+			return Entry{}, true, nil
+		}
+		if err == nil && validate != nil {
+			err = validate(parsed)
+		}
+		if err == nil {
+			return parsed, false, nil
+		}
+
+		// Re-open with the error shown at the top
+		content = fmt.Sprintf("%s %v\n%s", errorPrefix, err, edited)
+	}
+}
+
+// errorPrefix marks validation errors inserted at the top of the file
+const errorPrefix = "# ERROR:"
+
+// stripErrors removes previously inserted error lines
+func stripErrors(text string) string {
+	for strings.HasPrefix(text, errorPrefix) {
+		_, rest, found := strings.Cut(text, "\n")
+		if !found {
+			return ""
+		}
+		text = rest
+	}
+	return text
+}
+
+// launch runs $VISUAL or $EDITOR (falling back to vi) on the given file
+func launch(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// Run through the shell so editors with arguments work (e.g. "code --wait")
+	cmd := exec.Command("/bin/sh", "-c", editor+` "$1"`, "lz", path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor '%s' failed: %w", editor, err)
+	}
+	return nil
+}
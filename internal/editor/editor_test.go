@@ -0,0 +1,114 @@
+package editor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormatParseRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry Entry
+	}{
+		{
+			name: "all fields",
+			entry: Entry{
+				Name:        "train",
+				Tags:        []string{"ml", "gpu"},
+				Description: "Train the model: full run",
+				Command:     "python train.py --epochs {%[10,20]%}",
+				Cwd:         "~/src/model",
+				Env:         map[string]string{"CUDA_VISIBLE_DEVICES": "0,1", "WANDB_MODE": "offline=1"},
+			},
+		},
+		{
+			name:  "command only",
+			entry: Entry{Name: "gs", Command: "git status"},
+		},
+		{
+			name: "multi-line command",
+			entry: Entry{
+				Name:    "deploy",
+				Command: "docker build -t app . \\\n  && docker push app\n# not a comment here",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(Format(tt.entry))
+			if err != nil {
+				t.Fatalf("Parse(Format()) error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.entry) {
+				t.Errorf("Parse(Format()) = %+v, expected %+v", got, tt.entry)
+			}
+		})
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	text := "name: x\ncommand: echo one\necho two\n\n"
+	got, err := Parse(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Command != "echo one\necho two" {
+		t.Errorf("Command = %q, expected %q", got.Command, "echo one\necho two")
+	}
+
+	if _, err := Parse("name: x\n"); err == nil {
+		t.Error("expected error for a missing 'command:' section")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		line string
+	}{
+		{"unknown field", "name: x\ncolour: blue\ncommand:\nls\n", "line 2:"},
+		{"not key: value", "name: x\njust text\ncommand:\nls\n", "line 2:"},
+		{"env without value", "env: KEY\ncommand:\nls\n", "line 1:"},
+		{"env name with space", "env: MY VAR=1\ncommand:\nls\n", "line 1:"},
+		{"env name starting with digit", "name: x\nenv: 1X=1\ncommand:\nls\n", "line 2:"},
+		{"env name with dash", "env: A-B=1\ncommand:\nls\n", "line 1:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.text)
+			if err == nil {
+				t.Fatalf("expected error for %q", tt.text)
+			}
+			if !strings.HasPrefix(err.Error(), tt.line) {
+				t.Errorf("error = %q, expected it to start with %q", err, tt.line)
+			}
+		})
+	}
+}
+
+// Errors inserted at the top of the file are dropped before re-parsing
+func TestStripErrors(t *testing.T) {
+	entry := Entry{Name: "gs", Command: "git status"}
+	text := Format(entry)
+	shown := errorPrefix + " invalid name\n" + errorPrefix + " line 3: unknown field\n" + text
+
+	stripped := stripErrors(shown)
+	if stripped != text {
+		t.Errorf("stripErrors() = %q, expected %q", stripped, text)
+	}
+	got, err := Parse(stripped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, entry) {
+		t.Errorf("Parse() = %+v, expected %+v", got, entry)
+	}
+
+	if got := stripErrors(errorPrefix + " only an error"); got != "" {
+		t.Errorf("stripErrors() = %q, expected empty", got)
+	}
+}
@@ -1,35 +1,43 @@
 package flagparse
 
 import (
+	"strconv"
 	"strings"
 )
 
-// SegmentType indicates whether a segment is static text or a flag
+// SegmentType indicates whether a segment is static text, a flag or an operator
 type SegmentType int
 
 const (
-	SegmentStatic SegmentType = iota // Non-flag portion: "watch", "aws ec2 start-instances"
-	SegmentFlag                      // Flag with optional value: "-n 10", "--profile ai-dev/Admin"
+	SegmentStatic   SegmentType = iota // Non-flag portion: "watch", "aws ec2 start-instances"
+	SegmentFlag                        // Flag with optional value: "-n 10", "--profile ai-dev/Admin"
+	SegmentOperator                    // Shell operator or redirection: "|", "&&", "> out.log", "2>&1"
 )
 
 // Flag represents a parsed flag from a command
 type Flag struct {
 	Name      string // "--config", "-v"
-	Value     string // "100", "/path/to/file", "" for boolean
+	Value     string // "100", "/path/to/file", "" for boolean (original quoting preserved)
 	IsBoolean bool   // true if no value or value is True/False
+	Joined    bool   // true if written as --name=value rather than --name value
 }
 
 // Segment represents a portion of a command, either static text or a flag
 type Segment struct {
 	Type   SegmentType
-	Static string // For SegmentStatic: the static text
-	Flag   *Flag  // For SegmentFlag: the flag details
+	Static string   // For SegmentStatic/SegmentOperator: the original text
+	Args   []string // For SegmentStatic: the individual words (original quoting preserved)
+	Flag   *Flag    // For SegmentFlag: the flag details
 }
 
 // ParseSegments parses a command into an ordered list of segments
 // This preserves the relative order of all command parts, allowing commands like:
 // "watch -n 10 aws ec2 start-instances --instance-ids i-123"
 // to be correctly parsed with "aws ec2 start-instances" in its original position
+// Quoted words keep their original quoting, and pipes, control operators and
+// redirections split the command so flags never consume them as values.
+// "--name=value" is split into name and value (Flag.Joined), negative numbers
+// are values rather than flags, and everything after "--" is positional.
 func ParseSegments(command string) []Segment {
 	tokens := tokenize(command)
 	if len(tokens) == 0 {
@@ -38,30 +46,70 @@ func ParseSegments(command string) []Segment {
 
 	var segments []Segment
 	var staticAccumulator []string
+	endOfFlags := false // Set after "--" until the next operator
 	i := 0
 
+	flushStatic := func() {
+		if len(staticAccumulator) > 0 {
+			segments = append(segments, Segment{
+				Type:   SegmentStatic,
+				Static: strings.Join(staticAccumulator, " "),
+				Args:   staticAccumulator,
+			})
+			staticAccumulator = nil
+		}
+	}
+
 	for i < len(tokens) {
 		tok := tokens[i]
 
-		if isFlag(tok.Value) {
-			// Flush accumulated static tokens as a Static segment
-			if len(staticAccumulator) > 0 {
-				segments = append(segments, Segment{
-					Type:   SegmentStatic,
-					Static: strings.Join(staticAccumulator, " "),
-				})
-				staticAccumulator = nil
+		if tok.Kind == tokenOperator {
+			flushStatic()
+
+			// Redirections carry their target: "> out.log", "2>&1"
+			text := tok.Raw
+			if isRedirect(tok.Raw) && i+1 < len(tokens) && tokens[i+1].Kind == tokenWord {
+				text = command[tok.StartIdx:tokens[i+1].EndIdx]
+				i++
+			}
+			segments = append(segments, Segment{
+				Type:   SegmentOperator,
+				Static: text,
+			})
+			if !isRedirect(tok.Raw) {
+				endOfFlags = false // A new command starts after |, &&, ;
 			}
+			i++
+			continue
+		}
+
+		if tok.Value == "--" && !endOfFlags {
+			// End of options: everything after is positional
+			endOfFlags = true
+			staticAccumulator = append(staticAccumulator, tok.Raw)
+			i++
+			continue
+		}
+
+		if !endOfFlags && isFlag(tok.Value) {
+			// Flush accumulated static tokens as a Static segment
+			flushStatic()
 
 			// Parse the flag
 			flag := &Flag{
-				Name: tok.Value,
+				Name: tok.Raw,
 			}
 
-			// Check if next token is a value (not another flag and exists)
-			if i+1 < len(tokens) && !isFlag(tokens[i+1].Value) {
+			if name, value, ok := splitJoined(tok); ok {
+				// --name=value: the value is part of the same token
+				flag.Name = name
+				flag.Value = value
+				flag.Joined = true
+				flag.IsBoolean = isBooleanValue(Unquote(value))
+				i++
+			} else if i+1 < len(tokens) && tokens[i+1].Kind == tokenWord && !isFlag(tokens[i+1].Value) {
 				valueToken := tokens[i+1]
-				flag.Value = valueToken.Value
+				flag.Value = valueToken.Raw
 				flag.IsBoolean = isBooleanValue(valueToken.Value)
 				i += 2
 			} else {
@@ -76,18 +124,13 @@ func ParseSegments(command string) []Segment {
 			})
 		} else {
 			// Not a flag - accumulate as static text
-			staticAccumulator = append(staticAccumulator, tok.Value)
+			staticAccumulator = append(staticAccumulator, tok.Raw)
 			i++
 		}
 	}
 
 	// Flush any remaining static tokens
-	if len(staticAccumulator) > 0 {
-		segments = append(segments, Segment{
-			Type:   SegmentStatic,
-			Static: strings.Join(staticAccumulator, " "),
-		})
-	}
+	flushStatic()
 
 	return segments
 }
@@ -102,51 +145,299 @@ func HasFlags(segments []Segment) bool {
 	return false
 }
 
+// tokenKind distinguishes words from shell operators
+type tokenKind int
+
+const (
+	tokenWord     tokenKind = iota // Regular word, possibly quoted
+	tokenOperator                  // Control or redirection operator: |, &&, ;, >, 2>&1...
+)
+
 // token represents a token in the command with its position
 type token struct {
-	Value    string
+	Kind     tokenKind
+	Value    string // Unquoted value (quotes and escapes removed)
+	Raw      string // Original text, including quotes
 	StartIdx int
 	EndIdx   int
 }
 
+// operators lists shell operators, longest first so the longest match wins
+var operators = []string{
+	"&>>", "<<<", ";;&",
+	"&&", "||", "|&", ";;", ";&", ">>", "<<", ">&", "<&", "&>", ">|", "<>",
+	"|", "&", ";", "<", ">", "(", ")", "\n",
+}
+
 // tokenize splits a command into tokens, tracking positions
-// Does not handle quoted strings with spaces
+// Understands POSIX shell quoting: single quotes, double quotes, backslash
+// escapes, $(...), ${...} and backticks. Control and redirection operators
+// outside quotes become separate operator tokens.
 func tokenize(command string) []token {
 	var tokens []token
-	start := -1
+	i := 0
+	n := len(command)
+
+	for i < n {
+		c := command[i]
+
+		// Skip whitespace and line continuations
+		if c == ' ' || c == '\t' || c == '\r' {
+			i++
+			continue
+		}
+		if c == '\\' && i+1 < n && command[i+1] == '\n' {
+			i += 2
+			continue
+		}
 
-	for i, c := range command {
-		if c == ' ' || c == '\t' {
-			if start >= 0 {
+		// File descriptor redirection: 2>, 2>>, 2>&1
+		if isDigit(c) {
+			j := i
+			for j < n && isDigit(command[j]) {
+				j++
+			}
+			if j < n && (command[j] == '>' || command[j] == '<') {
+				op := matchOperator(command[j:])
 				tokens = append(tokens, token{
-					Value:    command[start:i],
-					StartIdx: start,
-					EndIdx:   i,
+					Kind:     tokenOperator,
+					Value:    command[i : j+len(op)],
+					Raw:      command[i : j+len(op)],
+					StartIdx: i,
+					EndIdx:   j + len(op),
 				})
-				start = -1
+				i = j + len(op)
+				continue
 			}
-		} else {
-			if start < 0 {
-				start = i
+		}
+
+		if op := matchOperator(command[i:]); op != "" {
+			tokens = append(tokens, token{
+				Kind:     tokenOperator,
+				Value:    op,
+				Raw:      op,
+				StartIdx: i,
+				EndIdx:   i + len(op),
+			})
+			i += len(op)
+			continue
+		}
+
+		tok := readWord(command, i)
+		tokens = append(tokens, tok)
+		i = tok.EndIdx
+	}
+
+	return tokens
+}
+
+// readWord reads a single (possibly quoted) word starting at start
+func readWord(command string, start int) token {
+	var value strings.Builder
+	i := start
+	n := len(command)
+
+loop:
+	for i < n {
+		c := command[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			break loop
+
+		case c == '\\':
+			if i+1 < n {
+				if command[i+1] != '\n' {
+					value.WriteByte(command[i+1])
+				}
+				i += 2
+			} else {
+				i++
+			}
+
+		case c == '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				value.WriteString(command[i+1:])
+				i = n
+			} else {
+				value.WriteString(command[i+1 : i+1+end])
+				i += end + 2
+			}
+
+		case c == '"':
+			i++
+			for i < n && command[i] != '"' {
+				switch {
+				case command[i] == '\\' && i+1 < n && strings.IndexByte("\"\\$`\n", command[i+1]) >= 0:
+					if command[i+1] != '\n' {
+						value.WriteByte(command[i+1])
+					}
+					i += 2
+				case command[i] == '$' && i+1 < n && (command[i+1] == '(' || command[i+1] == '{'):
+					end := skipExpansion(command, i)
+					value.WriteString(command[i:end])
+					i = end
+				case command[i] == '`':
+					end := skipBacktick(command, i)
+					value.WriteString(command[i:end])
+					i = end
+				default:
+					value.WriteByte(command[i])
+					i++
+				}
+			}
+			if i < n {
+				i++ // Closing quote
 			}
+
+		case c == '$' && i+1 < n && (command[i+1] == '(' || command[i+1] == '{'):
+			end := skipExpansion(command, i)
+			value.WriteString(command[i:end])
+			i = end
+
+		case c == '`':
+			end := skipBacktick(command, i)
+			value.WriteString(command[i:end])
+			i = end
+
+		case c == '\n' || matchOperator(command[i:]) != "":
+			break loop
+
+		default:
+			value.WriteByte(c)
+			i++
 		}
 	}
 
-	// Last token
-	if start >= 0 {
-		tokens = append(tokens, token{
-			Value:    command[start:],
-			StartIdx: start,
-			EndIdx:   len(command),
-		})
+	return token{
+		Kind:     tokenWord,
+		Value:    value.String(),
+		Raw:      command[start:i],
+		StartIdx: start,
+		EndIdx:   i,
+	}
+}
+
+// skipExpansion returns the index just past a $(...) or ${...} starting at i
+// Nested parentheses/braces and quotes inside the expansion are respected
+func skipExpansion(command string, i int) int {
+	open := command[i+1]
+	closeCh := byte(')')
+	if open == '{' {
+		closeCh = '}'
 	}
 
-	return tokens
+	depth := 0
+	j := i + 1
+	for j < len(command) {
+		switch c := command[j]; {
+		case c == '\\':
+			j += 2
+			continue
+		case c == '\'':
+			end := strings.IndexByte(command[j+1:], '\'')
+			if end < 0 {
+				return len(command)
+			}
+			j += end + 2
+			continue
+		case c == '"':
+			j++
+			for j < len(command) && command[j] != '"' {
+				if command[j] == '\\' {
+					j++
+				}
+				j++
+			}
+		case c == open:
+			depth++
+		case c == closeCh:
+			depth--
+			if depth == 0 {
+				return j + 1
+			}
+		}
+		j++
+	}
+	return len(command)
+}
+
+// skipBacktick returns the index just past a `...` command substitution
+func skipBacktick(command string, i int) int {
+	j := i + 1
+	for j < len(command) {
+		if command[j] == '\\' {
+			j += 2
+			continue
+		}
+		if command[j] == '`' {
+			return j + 1
+		}
+		j++
+	}
+	return len(command)
+}
+
+// matchOperator returns the shell operator at the start of s, or ""
+func matchOperator(s string) string {
+	for _, op := range operators {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// isRedirect reports whether an operator token is a redirection
+// Redirections take the following word as their target
+func isRedirect(op string) bool {
+	op = strings.TrimLeft(op, "0123456789")
+	return strings.ContainsAny(op, "<>")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Unquote returns the value of a shell word with quotes and escapes removed
+// e.g. 'fix the bug' -> fix the bug, "a\"b" -> a"b
+func Unquote(s string) string {
+	tok := readWord(s, 0)
+	if tok.EndIdx != len(s) {
+		return s
+	}
+	return tok.Value
 }
 
 // isFlag checks if a token is a flag (starts with - or --)
+// A lone "-" (stdin) and negative numbers like -1 or -0.5 are values
 func isFlag(s string) bool {
-	return strings.HasPrefix(s, "-")
+	if !strings.HasPrefix(s, "-") || s == "-" {
+		return false
+	}
+	return !isNumber(s)
+}
+
+// isNumber reports whether s is a (possibly negative) number: -1, -0.5, -1e-3
+func isNumber(s string) bool {
+	digits := strings.TrimLeft(s, "-+")
+	if digits == "" || !(isDigit(digits[0]) || digits[0] == '.') {
+		return false // Rejects words ParseFloat accepts, like -inf and -nan
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// splitJoined splits a "--name=value" token into its name and raw value
+func splitJoined(tok token) (string, string, bool) {
+	if !strings.HasPrefix(tok.Value, "--") {
+		return "", "", false
+	}
+	eq := strings.Index(tok.Raw, "=")
+	if eq <= 2 {
+		return "", "", false
+	}
+	return tok.Raw[:eq], tok.Raw[eq+1:], true
 }
 
 // isBooleanValue checks if a value is a boolean value
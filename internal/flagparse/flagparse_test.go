@@ -1,6 +1,7 @@
 package flagparse
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -37,6 +38,58 @@ func TestParseSegments(t *testing.T) {
 				{Type: SegmentStatic, Static: "echo hello world"},
 			},
 		},
+		{
+			name:    "quoted values with spaces",
+			command: `git commit -m 'fix the bug' --author "A B"`,
+			expected: []Segment{
+				{Type: SegmentStatic, Static: "git commit"},
+				{Type: SegmentFlag, Flag: &Flag{Name: "-m", Value: "'fix the bug'", IsBoolean: false}},
+				{Type: SegmentFlag, Flag: &Flag{Name: "--author", Value: `"A B"`, IsBoolean: false}},
+			},
+		},
+		{
+			name:    "pipes and control operators split segments",
+			command: "ls -la | grep -v foo && make build",
+			expected: []Segment{
+				{Type: SegmentStatic, Static: "ls"},
+				{Type: SegmentFlag, Flag: &Flag{Name: "-la", Value: "", IsBoolean: true}},
+				{Type: SegmentOperator, Static: "|"},
+				{Type: SegmentStatic, Static: "grep"},
+				{Type: SegmentFlag, Flag: &Flag{Name: "-v", Value: "foo", IsBoolean: false}},
+				{Type: SegmentOperator, Static: "&&"},
+				{Type: SegmentStatic, Static: "make build"},
+			},
+		},
+		{
+			name:    "redirections keep their target",
+			command: "python run.py --verbose > out.log 2>&1",
+			expected: []Segment{
+				{Type: SegmentStatic, Static: "python run.py"},
+				{Type: SegmentFlag, Flag: &Flag{Name: "--verbose", Value: "", IsBoolean: true}},
+				{Type: SegmentOperator, Static: "> out.log"},
+				{Type: SegmentOperator, Static: "2>&1"},
+			},
+		},
+		{
+			name:    "command substitution is a single word",
+			command: `tar -czf "backup-$(date +%F).tgz" $(ls -d */)`,
+			expected: []Segment{
+				{Type: SegmentStatic, Static: "tar"},
+				{Type: SegmentFlag, Flag: &Flag{Name: "-czf", Value: `"backup-$(date +%F).tgz"`, IsBoolean: false}},
+				{Type: SegmentStatic, Static: "$(ls -d */)"},
+			},
+		},
+		{
+			name:    "joined values, negative numbers and end of flags",
+			command: "python train.py --lr=0.001 --offset -1 --bias=-0.5 -- --not-a-flag -x",
+			expected: []Segment{
+				{Type: SegmentStatic, Static: "python train.py"},
+				{Type: SegmentFlag, Flag: &Flag{Name: "--lr", Value: "0.001", IsBoolean: false, Joined: true}},
+				{Type: SegmentFlag, Flag: &Flag{Name: "--offset", Value: "-1", IsBoolean: false}},
+				{Type: SegmentFlag, Flag: &Flag{Name: "--bias", Value: "-0.5", IsBoolean: false, Joined: true}},
+				{Type: SegmentStatic, Static: "-- --not-a-flag -x"},
+			},
+		},
 		{
 			name:    "flags only",
 			command: "--config test.yaml --verbose",
@@ -54,10 +107,10 @@ func TestParseSegments(t *testing.T) {
 			if len(segments) != len(tt.expected) {
 				t.Errorf("expected %d segments, got %d", len(tt.expected), len(segments))
 				for i, seg := range segments {
-					if seg.Type == SegmentStatic {
-						t.Logf("  %d: [Static] %q", i, seg.Static)
-					} else {
+					if seg.Type == SegmentFlag {
 						t.Logf("  %d: [Flag] %s = %q", i, seg.Flag.Name, seg.Flag.Value)
+					} else {
+						t.Logf("  %d: [Static] %q", i, seg.Static)
 					}
 				}
 				return
@@ -70,7 +123,7 @@ func TestParseSegments(t *testing.T) {
 					continue
 				}
 
-				if seg.Type == SegmentStatic {
+				if seg.Type != SegmentFlag {
 					if seg.Static != exp.Static {
 						t.Errorf("segment %d: expected static %q, got %q", i, exp.Static, seg.Static)
 					}
@@ -84,8 +137,117 @@ func TestParseSegments(t *testing.T) {
 					if seg.Flag.IsBoolean != exp.Flag.IsBoolean {
 						t.Errorf("segment %d: expected IsBoolean %v, got %v", i, exp.Flag.IsBoolean, seg.Flag.IsBoolean)
 					}
+					if seg.Flag.Joined != exp.Flag.Joined {
+						t.Errorf("segment %d: expected Joined %v, got %v", i, exp.Flag.Joined, seg.Flag.Joined)
+					}
 				}
 			}
 		})
 	}
 }
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected []string
+	}{
+		{`echo 'a b' "c \"d\"" e\ f`, []string{"echo", "a b", `c "d"`, "e f"}},
+		{`echo "$(printf '%s' "x y")"`, []string{"echo", `$(printf '%s' "x y")`}},
+		{"a;b", []string{"a", ";", "b"}},
+		{"run \\\n --flag", []string{"run", "--flag"}},
+	}
+
+	for _, tt := range tests {
+		tokens := tokenize(tt.command)
+		var values []string
+		for _, tok := range tokens {
+			values = append(values, tok.Value)
+		}
+		if strings.Join(values, "|") != strings.Join(tt.expected, "|") {
+			t.Errorf("tokenize(%q) = %q, expected %q", tt.command, values, tt.expected)
+		}
+	}
+}
+
+func TestUnquote(t *testing.T) {
+	tests := map[string]string{
+		`'fix the bug'`: "fix the bug",
+		`"a\"b"`:        `a"b`,
+		`/plain/path`:   "/plain/path",
+	}
+	for in, expected := range tests {
+		if got := Unquote(in); got != expected {
+			t.Errorf("Unquote(%q) = %q, expected %q", in, got, expected)
+		}
+	}
+}
+
+func TestCommandWords(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected []int
+	}{
+		{"aws ec2 start-instances --instance-ids i-1", []int{3, 0}},
+		{"python scripts/eval.py --split test", []int{1, 0}},
+		{"kubectl delete pod mypod", []int{2}},
+		{"docker compose up -d web", []int{3, 0}},
+		{"watch -n 10 kubectl get pods", []int{1, 0, 2}},
+		{"CUDA_VISIBLE_DEVICES=0 python train.py | tee log.txt", []int{2, 0, 1}},
+	}
+
+	for _, tt := range tests {
+		segments := ParseSegments(tt.command)
+		got := CommandWords(segments)
+		if len(got) != len(tt.expected) {
+			t.Errorf("CommandWords(%q) = %v, expected %v", tt.command, got, tt.expected)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Errorf("CommandWords(%q) = %v, expected %v", tt.command, got, tt.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestCommandName(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{"aws ec2 start-instances --instance-ids i-1", "aws ec2 start-instances"},
+		{"sudo systemctl restart nginx", "systemctl restart"},
+		{"FOO=1 python train.py --epochs 10", "python train.py"},
+		{"python -m pytest tests/", "python -m pytest"},
+		{"watch -n 10 kubectl get pods", "kubectl get"},
+		{"ls -la | grep foo", "ls"},
+		{"python", "python"},
+	}
+
+	for _, tt := range tests {
+		got := strings.Join(CommandName(ParseSegments(tt.command)), " ")
+		if got != tt.expected {
+			t.Errorf("CommandName(%q) = %q, expected %q", tt.command, got, tt.expected)
+		}
+	}
+}
+
+func TestRunsScript(t *testing.T) {
+	tests := map[string]bool{
+		"python train.py --epochs 10": true,
+		"python -m pytest tests/":     true,
+		"bash deploy.sh prod":         true,
+		"./deploy.sh --env prod":      true,
+		"scripts/x":                   true,
+		"no-such-lz-program --help":   true,
+		"sh":                          false,
+		"ls -la":                      false,
+		"sudo cat notes.txt":          false,
+	}
+	for command, expected := range tests {
+		if got := RunsScript(CommandName(ParseSegments(command))); got != expected {
+			t.Errorf("RunsScript(%q) = %v, expected %v", command, got, expected)
+		}
+	}
+}
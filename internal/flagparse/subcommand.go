@@ -0,0 +1,217 @@
+package flagparse
+
+import (
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// subcommandDepth lists how many words after the program name name a
+// subcommand (e.g. "aws ec2 start-instances" = 2). Unknown programs have none,
+// so every word after them is treated as a positional argument.
+var subcommandDepth = map[string]int{
+	"apt":       1,
+	"aws":       2,
+	"az":        2,
+	"brew":      1,
+	"cargo":     1,
+	"conda":     1,
+	"docker":    1,
+	"gcloud":    2,
+	"gh":        2,
+	"git":       1,
+	"go":        1,
+	"helm":      1,
+	"kubectl":   1,
+	"npm":       1,
+	"pip":       1,
+	"pip3":      1,
+	"pnpm":      1,
+	"podman":    1,
+	"poetry":    1,
+	"systemctl": 1,
+	"terraform": 1,
+	"uv":        1,
+	"yarn":      1,
+}
+
+// subcommandGroups lists subcommands that take a further subcommand
+// e.g. "docker compose up", "git stash pop", "kubectl config use-context"
+var subcommandGroups = map[string]map[string]bool{
+	"docker":  {"compose": true, "container": true, "image": true, "network": true, "volume": true, "buildx": true, "system": true, "context": true},
+	"podman":  {"container": true, "image": true, "network": true, "volume": true, "system": true, "pod": true},
+	"git":     {"remote": true, "stash": true, "submodule": true, "worktree": true, "lfs": true},
+	"kubectl": {"config": true, "rollout": true, "auth": true, "certificate": true},
+	"gcloud":  {"instances": true, "clusters": true, "buckets": true, "projects": true},
+	"helm":    {"repo": true, "plugin": true},
+	"go":      {"mod": true},
+}
+
+// wrappers run another command given as their positional arguments
+// e.g. "watch -n 10 aws ec2 describe-instances", "sudo systemctl restart nginx"
+var wrappers = map[string]bool{
+	"env":        true,
+	"exec":       true,
+	"nice":       true,
+	"nohup":      true,
+	"sudo":       true,
+	"time":       true,
+	"watch":      true,
+	"xargs":      true,
+	"caffeinate": true,
+}
+
+// subcommandWord matches plain lowercase words like "start-instances"
+var subcommandWord = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// assignmentWord matches leading environment assignments like FOO=bar
+var assignmentWord = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// CommandWords returns, for each segment, how many of its leading Args are
+// part of a command name (environment assignments, program and subcommand
+// chain) rather than positional arguments. Non-static segments get 0.
+//
+// "aws ec2 start-instances --instance-ids i-1" -> [3, 0]
+// "python scripts/eval.py --split test"        -> [1, 0]
+// "watch -n 10 kubectl get pods"               -> [1, 0, 2]
+func CommandWords(segments []Segment) []int {
+	counts := make([]int, len(segments))
+
+	expectCommand := true // Next positional word starts a command
+	pending := 0          // Subcommand words still expected for the current program
+	program := ""
+
+	for i, seg := range segments {
+		switch seg.Type {
+		case SegmentOperator:
+			if !isRedirect(seg.Static) {
+				expectCommand = true
+				pending = 0
+			}
+			continue
+		case SegmentFlag:
+			// Flags end a subcommand chain, but a wrapper's flags don't
+			// stop the wrapped command from following
+			pending = 0
+			continue
+		}
+
+		for _, arg := range seg.Args {
+			word := Unquote(arg)
+
+			if expectCommand {
+				counts[i]++
+				if assignmentWord.MatchString(word) {
+					continue
+				}
+				program = filepath.Base(word)
+				expectCommand = wrappers[program]
+				pending = subcommandDepth[program]
+				continue
+			}
+
+			if pending > 0 && subcommandWord.MatchString(word) {
+				counts[i]++
+				pending--
+				if subcommandGroups[program][word] {
+					pending++
+				}
+				continue
+			}
+
+			// First positional argument ends the command name
+			pending = 0
+			break
+		}
+	}
+
+	return counts
+}
+
+// interpreters run a script given as their first positional argument, which
+// is then part of the command's identity (e.g. "python train.py")
+var interpreters = map[string]bool{
+	"bash":    true,
+	"deno":    true,
+	"node":    true,
+	"perl":    true,
+	"python":  true,
+	"python3": true,
+	"ruby":    true,
+	"sh":      true,
+	"zsh":     true,
+}
+
+// RunsScript reports whether a command name from CommandName runs local code
+// rather than an installed program: an interpreter with a script or module,
+// e.g. [python train.py], a program given by path, e.g. [./deploy.sh], or one
+// that isn't on $PATH
+func RunsScript(name []string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	if len(name) > 1 && interpreters[filepath.Base(name[0])] {
+		return true
+	}
+	if strings.ContainsRune(name[0], '/') || strings.ContainsRune(name[0], filepath.Separator) {
+		return true
+	}
+	_, err := exec.LookPath(name[0])
+	return err != nil
+}
+
+// CommandName returns the words that name the first command in the segments:
+// the program and its subcommand chain, without environment assignments or
+// wrappers. For interpreters the script (or "-m module") is included.
+//
+// "sudo aws ec2 start-instances --instance-ids i-1" -> [aws ec2 start-instances]
+// "python train.py --epochs 10"                     -> [python train.py]
+// "python -m pytest tests/"                         -> [python -m pytest]
+func CommandName(segments []Segment) []string {
+	counts := CommandWords(segments)
+
+	var words []string
+	end := len(segments) // Index of the first segment after the name
+	for i, seg := range segments {
+		if seg.Type == SegmentOperator && !isRedirect(seg.Static) {
+			end = i
+			break
+		}
+		if seg.Type != SegmentStatic {
+			continue
+		}
+		for _, arg := range seg.Args[:counts[i]] {
+			word := Unquote(arg)
+			if assignmentWord.MatchString(word) {
+				continue
+			}
+			if len(words) > 0 && wrappers[filepath.Base(words[0])] {
+				// A wrapper's command replaces it
+				words = nil
+			}
+			words = append(words, word)
+		}
+		if counts[i] < len(seg.Args) {
+			end = i
+			break
+		}
+	}
+
+	if len(words) != 1 || !interpreters[filepath.Base(words[0])] {
+		return words
+	}
+
+	// Interpreter: the script is the first positional argument, or a module
+	// given with -m
+	for i := 0; i < len(segments) && i <= end; i++ {
+		seg := segments[i]
+		switch {
+		case seg.Type == SegmentFlag && seg.Flag.Name == "-m" && seg.Flag.Value != "":
+			return append(words, "-m", Unquote(seg.Flag.Value))
+		case seg.Type == SegmentStatic && counts[i] < len(seg.Args):
+			return append(words, Unquote(seg.Args[counts[i]]))
+		}
+	}
+	return words
+}
@@ -0,0 +1,274 @@
+package helpparse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Option is a flag documented in a command's --help output
+type Option struct {
+	Names       []string // All spellings, e.g. ["-p", "--precision"]
+	Metavar     string   // Value placeholder, e.g. "LR", "string", "{fp16,bf16}"
+	TakesValue  bool     // True if the flag expects a value
+	OptionalArg bool     // Value is optional, so must be joined with = (--color[=WHEN])
+	Choices     []string // Documented allowed values, if any
+	Description string   // First line of the flag's description
+}
+
+// Name returns the preferred spelling of the option (the first long form)
+func (o Option) Name() string {
+	for _, n := range o.Names {
+		if strings.HasPrefix(n, "--") {
+			return n
+		}
+	}
+	return o.Names[0]
+}
+
+// Has reports whether name is one of the option's spellings
+func (o Option) Has(name string) bool {
+	for _, n := range o.Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// waitDelay bounds how long Fetch waits for output after the timeout kills the command
+const waitDelay = 500 * time.Millisecond
+
+// Fetch runs "<words...> --help" and returns its combined output
+// Many programs exit non-zero after printing help, so output wins over errors.
+func Fetch(words []string, timeout time.Duration) (string, error) {
+	if len(words) == 0 {
+		return "", fmt.Errorf("no command to run")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	args := append(append([]string{}, words[1:]...), "--help")
+	cmd := exec.CommandContext(ctx, words[0], args...)
+	// A child left running past the timeout keeps the output pipe open;
+	// stop waiting for it rather than hang
+	cmd.WaitDelay = waitDelay
+	out, err := cmd.CombinedOutput()
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("'%s --help' timed out after %s", strings.Join(words, " "), timeout)
+	}
+	if len(strings.TrimSpace(string(out))) == 0 {
+		if err != nil {
+			return "", fmt.Errorf("'%s --help' failed: %w", strings.Join(words, " "), err)
+		}
+		return "", fmt.Errorf("'%s --help' printed nothing", strings.Join(words, " "))
+	}
+	return string(out), nil
+}
+
+// namePattern matches a flag name at the start of a spec piece
+var namePattern = regexp.MustCompile(`^-{1,2}[A-Za-z0-9][\w.-]*`)
+
+// mustBePattern matches cobra-style choice lists in descriptions
+// e.g. `Must be "none", "server", or "client".`
+var mustBePattern = regexp.MustCompile(`(?i)(?:must be|one of)[: ]+((?:"[^"]+"[, ]*(?:or )?)+)`)
+
+// quotedPattern extracts the quoted values from a mustBePattern match
+var quotedPattern = regexp.MustCompile(`"([^"]+)"`)
+
+// Parse extracts flags from --help output
+// Understands the common layouts of argparse, click, cobra and GNU getopt:
+//
+//	-c CONFIG, --config CONFIG   argparse
+//	--precision {fp16,bf16}      argparse choices
+//	--precision [fp16|bf16]      click choices
+//	--shout / --no-shout         click boolean pair
+//	-n, --namespace string       cobra
+//	-w, --width=COLS             GNU getopt
+//	    --color[=WHEN]           GNU optional value
+//
+// -h/--help and --version are omitted.
+func Parse(text string) []Option {
+	var options []Option
+	seen := make(map[string]bool)
+	var last *Option // Option whose description may continue on the next line
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		trimmed := strings.TrimLeft(line, " \t")
+
+		if !namePattern.MatchString(trimmed) {
+			// argparse puts the description of long specs on the next line
+			if last != nil && last.Description == "" && trimmed != "" && trimmed != line {
+				last.Description = trimmed
+				last.Choices = appendChoices(last.Choices, trimmed)
+			}
+			if trimmed == "" {
+				last = nil
+			}
+			continue
+		}
+
+		spec, desc := splitSpec(trimmed)
+		opt, ok := parseSpec(spec)
+		if !ok {
+			last = nil
+			continue
+		}
+		opt.Description = desc
+		opt.Choices = appendChoices(opt.Choices, desc)
+
+		if opt.Has("-h") || opt.Has("--help") || opt.Has("--version") || seen[opt.Name()] {
+			last = nil
+			continue
+		}
+		seen[opt.Name()] = true
+
+		options = append(options, opt)
+		last = &options[len(options)-1]
+	}
+
+	return options
+}
+
+// splitSpec separates the flag spec from its description
+// They are divided by a tab or a run of two or more spaces.
+func splitSpec(line string) (string, string) {
+	depth := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth > 0 {
+				depth--
+			}
+		case '\t':
+			if depth == 0 {
+				return line[:i], strings.TrimSpace(line[i:])
+			}
+		case ' ':
+			if depth == 0 && i+1 < len(line) && line[i+1] == ' ' {
+				return line[:i], strings.TrimSpace(line[i:])
+			}
+		}
+	}
+	return line, ""
+}
+
+// parseSpec parses "-c CONFIG, --config CONFIG" style flag specs
+func parseSpec(spec string) (Option, bool) {
+	var opt Option
+
+	for _, piece := range splitPieces(spec) {
+		name := namePattern.FindString(piece)
+		if name == "" {
+			// Not a flag list after all (e.g. a sentence starting with "-")
+			return Option{}, false
+		}
+		opt.Names = append(opt.Names, name)
+
+		rest := piece[len(name):]
+		switch {
+		case rest == "":
+			continue
+		case strings.HasPrefix(rest, "[="):
+			opt.Metavar = strings.TrimSuffix(rest[2:], "]")
+			opt.OptionalArg = true
+		case strings.HasPrefix(rest, "="), strings.HasPrefix(rest, " "):
+			opt.Metavar = strings.TrimSpace(rest[1:])
+		default:
+			opt.Metavar = strings.TrimSpace(rest)
+		}
+		if strings.Contains(opt.Metavar, "[=") {
+			// Cobra's string[="default"]
+			opt.OptionalArg = true
+		}
+		if opt.Metavar != "" {
+			opt.TakesValue = true
+		}
+	}
+
+	if len(opt.Names) == 0 {
+		return Option{}, false
+	}
+
+	opt.Choices = parseChoices(opt.Metavar)
+	return opt, true
+}
+
+// splitPieces splits a spec on commas and click's " / " outside of brackets
+func splitPieces(spec string) []string {
+	var pieces []string
+	depth := 0
+	start := 0
+	for i := 0; i < len(spec); i++ {
+		switch spec[i] {
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				pieces = append(pieces, spec[start:i])
+				start = i + 1
+			}
+		case '/':
+			if depth == 0 && i > 0 && spec[i-1] == ' ' {
+				pieces = append(pieces, spec[start:i])
+				start = i + 1
+			}
+		}
+	}
+	pieces = append(pieces, spec[start:])
+
+	for i := range pieces {
+		pieces[i] = strings.TrimSpace(pieces[i])
+	}
+	return pieces
+}
+
+// parseChoices extracts {a,b,c} or [a|b|c] choice lists from a metavar
+func parseChoices(metavar string) []string {
+	var inner, sep string
+	switch {
+	case strings.HasPrefix(metavar, "{") && strings.HasSuffix(metavar, "}"):
+		inner, sep = metavar[1:len(metavar)-1], ","
+	case strings.HasPrefix(metavar, "[") && strings.HasSuffix(metavar, "]") && strings.Contains(metavar, "|"):
+		inner, sep = metavar[1:len(metavar)-1], "|"
+	default:
+		return nil
+	}
+
+	var choices []string
+	for _, c := range strings.Split(inner, sep) {
+		c = strings.TrimSpace(c)
+		if c != "" {
+			choices = append(choices, c)
+		}
+	}
+	return choices
+}
+
+// appendChoices adds choices listed in a description, unless some are known
+func appendChoices(choices []string, desc string) []string {
+	if len(choices) > 0 {
+		return choices
+	}
+	m := mustBePattern.FindStringSubmatch(desc)
+	if m == nil {
+		return nil
+	}
+	for _, q := range quotedPattern.FindAllStringSubmatch(m[1], -1) {
+		choices = append(choices, q[1])
+	}
+	return choices
+}
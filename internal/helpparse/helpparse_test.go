@@ -0,0 +1,113 @@
+package helpparse
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		help     string
+		expected []Option
+	}{
+		{
+			name: "argparse",
+			help: `usage: train.py [-h] [-c CONFIG] [--precision {fp16,bf16,fp32}] [--resume]
+
+options:
+  -h, --help            show this help message and exit
+  -c CONFIG, --config CONFIG
+                        path to the config file
+  --precision {fp16,bf16,fp32}
+                        training precision
+  --resume              resume from the last checkpoint
+`,
+			expected: []Option{
+				{Names: []string{"-c", "--config"}, Metavar: "CONFIG", TakesValue: true, Description: "path to the config file"},
+				{Names: []string{"--precision"}, Metavar: "{fp16,bf16,fp32}", TakesValue: true, Choices: []string{"fp16", "bf16", "fp32"}, Description: "training precision"},
+				{Names: []string{"--resume"}, Description: "resume from the last checkpoint"},
+			},
+		},
+		{
+			name: "click",
+			help: `Usage: cli [OPTIONS]
+
+Options:
+  --lr FLOAT                     Learning rate
+  --precision [fp16|bf16|fp32]   Training precision
+  --shout / --no-shout           Shout the output
+  --help                         Show this message and exit.
+`,
+			expected: []Option{
+				{Names: []string{"--lr"}, Metavar: "FLOAT", TakesValue: true, Description: "Learning rate"},
+				{Names: []string{"--precision"}, Metavar: "[fp16|bf16|fp32]", TakesValue: true, Choices: []string{"fp16", "bf16", "fp32"}, Description: "Training precision"},
+				{Names: []string{"--shout", "--no-shout"}, Description: "Shout the output"},
+			},
+		},
+		{
+			name: "cobra",
+			help: `Flags:
+  -A, --all-namespaces          If present, list across all namespaces.
+      --dry-run string[="unchanged"]   Must be "none", "server", or "client".
+  -n, --namespace string        The namespace to use
+`,
+			expected: []Option{
+				{Names: []string{"-A", "--all-namespaces"}, Description: "If present, list across all namespaces."},
+				{Names: []string{"--dry-run"}, Metavar: `string[="unchanged"]`, TakesValue: true, OptionalArg: true, Choices: []string{"none", "server", "client"}, Description: `Must be "none", "server", or "client".`},
+				{Names: []string{"-n", "--namespace"}, Metavar: "string", TakesValue: true, Description: "The namespace to use"},
+			},
+		},
+		{
+			name: "GNU getopt",
+			help: `Usage: ls [OPTION]... [FILE]...
+  -a, --all                  do not ignore entries starting with .
+      --color[=WHEN]         color the output WHEN
+  -w, --width=COLS           set output width to COLS
+      --version  output version information and exit
+`,
+			expected: []Option{
+				{Names: []string{"-a", "--all"}, Description: "do not ignore entries starting with ."},
+				{Names: []string{"--color"}, Metavar: "WHEN", TakesValue: true, OptionalArg: true, Description: "color the output WHEN"},
+				{Names: []string{"-w", "--width"}, Metavar: "COLS", TakesValue: true, Description: "set output width to COLS"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.help)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Parse() =\n%#v\nexpected\n%#v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOptionName(t *testing.T) {
+	opt := Option{Names: []string{"-c", "--config"}}
+	if opt.Name() != "--config" {
+		t.Errorf("Name() = %q, expected %q", opt.Name(), "--config")
+	}
+	if !opt.Has("-c") || opt.Has("--cfg") {
+		t.Errorf("Has() mismatch for %v", opt.Names)
+	}
+}
+
+func TestFetchTimeout(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	// A child in the background keeps the output open after the timeout
+	start := time.Now()
+	_, err := Fetch([]string{"sh", "-c", "sleep 5 & echo usage; sleep 5", "sh"}, 200*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Fetch() = %v, expected a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Fetch() took %s, waiting on the background child", elapsed)
+	}
+}
@@ -0,0 +1,314 @@
+package keys
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File names inside the lz config directory
+const (
+	privateKeyFile  = "signing_key"  // base64 ed25519 seed, mode 0600
+	trustedKeysFile = "trusted_keys" // One public key per line
+)
+
+// keyType prefixes public keys in their text form
+const keyType = "lz-ed25519"
+
+var (
+	// ErrUnsigned is returned by Verify for documents without a signature
+	ErrUnsigned = errors.New("not signed")
+	// ErrUntrusted is returned by Verify for signatures by keys not in the trusted list
+	ErrUntrusted = errors.New("signed by an untrusted key")
+	// ErrNoKey is returned by LoadSigner when no signing key has been generated
+	ErrNoKey = errors.New("no signing key: run 'lz keys generate'")
+)
+
+// Key is a public key with the name it is known by
+type Key struct {
+	Public ed25519.PublicKey
+	Name   string
+}
+
+// signature is the "signature" field added to signed documents
+type signature struct {
+	Key   string `json:"key"`   // base64 public key
+	Value string `json:"value"` // base64 signature over the canonical document
+}
+
+// String returns the key as a line of text: "lz-ed25519 <base64> [name]"
+func (k Key) String() string {
+	s := keyType + " " + base64.StdEncoding.EncodeToString(k.Public)
+	if k.Name != "" {
+		s += " " + k.Name
+	}
+	return s
+}
+
+// Fingerprint returns a short identifier for the key
+func (k Key) Fingerprint() string {
+	sum := sha256.Sum256(k.Public)
+	return "SHA256:" + hex.EncodeToString(sum[:8])
+}
+
+// ParseKey parses a key in the form written by String
+// The "lz-ed25519" prefix is optional.
+func ParseKey(text string) (Key, error) {
+	fields := strings.Fields(text)
+	if len(fields) > 0 && fields[0] == keyType {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return Key{}, fmt.Errorf("empty key")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(fields[0])
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return Key{}, fmt.Errorf("invalid public key '%s'", fields[0])
+	}
+	return Key{Public: ed25519.PublicKey(raw), Name: strings.Join(fields[1:], " ")}, nil
+}
+
+// Generate creates a signing key in dir and returns its public key
+// An existing key is only replaced when force is set.
+func Generate(dir, name string, force bool) (Key, error) {
+	path := filepath.Join(dir, privateKeyFile)
+	if _, err := os.Stat(path); err == nil && !force {
+		return Key{}, fmt.Errorf("signing key already exists at %s (use --force to replace it)", path)
+	}
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return Key{}, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Key{}, fmt.Errorf("failed to create config directory: %w", err)
+	}
+	data := base64.StdEncoding.EncodeToString(private.Seed()) + "\n"
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		return Key{}, fmt.Errorf("failed to write signing key: %w", err)
+	}
+	return Key{Public: public, Name: name}, nil
+}
+
+// LoadSigner reads the signing key from dir
+func LoadSigner(dir string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(filepath.Join(dir, privateKeyFile))
+	if os.IsNotExist(err) {
+		return nil, ErrNoKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid signing key in %s", filepath.Join(dir, privateKeyFile))
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// PublicKey returns the public half of a signing key
+func PublicKey(private ed25519.PrivateKey, name string) Key {
+	return Key{Public: private.Public().(ed25519.PublicKey), Name: name}
+}
+
+// LoadTrusted reads the trusted keys in dir; a missing file means none
+func LoadTrusted(dir string) ([]Key, error) {
+	data, err := os.ReadFile(filepath.Join(dir, trustedKeysFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var trusted []Key
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, err := ParseKey(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", trustedKeysFile, n, err)
+		}
+		trusted = append(trusted, k)
+	}
+	return trusted, scanner.Err()
+}
+
+// Trust adds a key to the trusted keys in dir
+func Trust(dir string, k Key) error {
+	trusted, err := LoadTrusted(dir)
+	if err != nil {
+		return err
+	}
+	for _, t := range trusted {
+		if t.Public.Equal(k.Public) {
+			return fmt.Errorf("key %s is already trusted as '%s'", k.Fingerprint(), t.Name)
+		}
+		if k.Name != "" && t.Name == k.Name {
+			return fmt.Errorf("a trusted key is already named '%s'", k.Name)
+		}
+	}
+	return writeTrusted(dir, append(trusted, k))
+}
+
+// Replace trusts k in place of old, as when a signing key is regenerated
+func Replace(dir string, old, k Key) error {
+	trusted, err := LoadTrusted(dir)
+	if err != nil {
+		return err
+	}
+	var kept []Key
+	for _, t := range trusted {
+		if !t.Public.Equal(old.Public) {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) < len(trusted) {
+		if err := writeTrusted(dir, kept); err != nil {
+			return err
+		}
+	}
+	return Trust(dir, k)
+}
+
+// Untrust removes the trusted key matching a name, fingerprint or public key
+func Untrust(dir, which string) (Key, error) {
+	trusted, err := LoadTrusted(dir)
+	if err != nil {
+		return Key{}, err
+	}
+	for i, t := range trusted {
+		if t.Name == which || t.Fingerprint() == which || base64.StdEncoding.EncodeToString(t.Public) == which {
+			return t, writeTrusted(dir, append(trusted[:i], trusted[i+1:]...))
+		}
+	}
+	return Key{}, fmt.Errorf("no trusted key matches '%s'", which)
+}
+
+// writeTrusted replaces the trusted keys file
+func writeTrusted(dir string, trusted []Key) error {
+	var sb strings.Builder
+	sb.WriteString("# Public keys whose signed packs and libraries lz accepts\n")
+	for _, k := range trusted {
+		sb.WriteString(k.String() + "\n")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, trustedKeysFile), []byte(sb.String()), 0644)
+}
+
+// Sign adds a signature to a JSON document, replacing any existing one
+// The signature covers the document's canonical form without the signature
+// field, so formatting and key order don't affect it.
+func Sign(data []byte, private ed25519.PrivateKey) ([]byte, error) {
+	doc, payload, old, err := canonical(data)
+	if err != nil {
+		return nil, err
+	}
+
+	sig := signature{
+		Key:   base64.StdEncoding.EncodeToString(private.Public().(ed25519.PublicKey)),
+		Value: base64.StdEncoding.EncodeToString(ed25519.Sign(private, payload)),
+	}
+
+	// Append the field to unsigned documents so their layout is kept
+	body := bytes.TrimRight(data, " \t\r\n")
+	if old == nil && !bytes.Contains(data, []byte(`"signature"`)) && bytes.HasSuffix(body, []byte("}")) {
+		body = bytes.TrimRight(body[:len(body)-1], " \t\r\n")
+		field, err := json.Marshal(sig)
+		if err != nil {
+			return nil, err
+		}
+		var out bytes.Buffer
+		out.Write(body)
+		if !bytes.HasSuffix(body, []byte("{")) {
+			out.WriteByte(',')
+		}
+		out.WriteString("\n  \"signature\": ")
+		out.Write(field)
+		out.WriteString("\n}\n")
+		return out.Bytes(), nil
+	}
+
+	doc["signature"] = sig
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// Verify checks a signed JSON document and returns the trusted key that signed it
+func Verify(data []byte, trusted []Key) (Key, error) {
+	_, payload, sig, err := canonical(data)
+	if err != nil {
+		return Key{}, err
+	}
+	if sig == nil {
+		return Key{}, ErrUnsigned
+	}
+
+	signer, err := ParseKey(sig.Key)
+	if err != nil {
+		return Key{}, fmt.Errorf("invalid signature: %w", err)
+	}
+	value, err := base64.StdEncoding.DecodeString(sig.Value)
+	if err != nil || !ed25519.Verify(signer.Public, payload, value) {
+		return Key{}, fmt.Errorf("invalid signature: the content was changed after signing")
+	}
+
+	for _, t := range trusted {
+		if t.Public.Equal(signer.Public) {
+			return t, nil
+		}
+	}
+	return signer, fmt.Errorf("%w (%s)", ErrUntrusted, signer.Fingerprint())
+}
+
+// canonical parses a JSON object and returns it without its signature field,
+// the bytes that are signed, and the signature if present
+func canonical(data []byte) (map[string]any, []byte, *signature, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	var sig *signature
+	raw := doc["signature"]
+	delete(doc, "signature")
+	if raw != nil {
+		encoded, err := json.Marshal(raw)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		sig = &signature{}
+		if err := json.Unmarshal(encoded, sig); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid signature field: %w", err)
+		}
+	}
+
+	// encoding/json sorts map keys, which makes the output canonical
+	payload, err := json.Marshal(doc)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return doc, payload, sig, nil
+}
@@ -0,0 +1,120 @@
+package keys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSignVerify(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trusted := []Key{{Public: public, Name: "ml-team"}}
+
+	doc := []byte(`{"version": 1, "name": "ML", "commands": [{"name": "train", "command": "python train.py --lr 0.001"}]}`)
+	signed, err := Sign(doc, private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := Verify(signed, trusted)
+	if err != nil {
+		t.Fatalf("Verify() = %v", err)
+	}
+	if signer.Name != "ml-team" {
+		t.Errorf("signer = %q, expected ml-team", signer.Name)
+	}
+
+	// Re-signing replaces the signature rather than signing it
+	resigned, err := Sign(signed, private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(resigned, trusted); err != nil {
+		t.Errorf("Verify() after re-signing = %v", err)
+	}
+
+	tampered := []byte(strings.Replace(string(signed), "train.py", "evil.py", 1))
+	if _, err := Verify(tampered, trusted); err == nil || !strings.Contains(err.Error(), "changed after signing") {
+		t.Errorf("Verify(tampered) = %v, expected invalid signature", err)
+	}
+
+	if _, err := Verify(signed, nil); !errors.Is(err, ErrUntrusted) {
+		t.Errorf("Verify(untrusted) = %v, expected ErrUntrusted", err)
+	}
+	if _, err := Verify(doc, trusted); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("Verify(unsigned) = %v, expected ErrUnsigned", err)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := Key{Public: public, Name: "alice laptop"}
+
+	parsed, err := ParseKey(k.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Public.Equal(public) || parsed.Name != "alice laptop" {
+		t.Errorf("ParseKey(%q) = %+v", k.String(), parsed)
+	}
+
+	for _, bad := range []string{"", "lz-ed25519", "lz-ed25519 not-base64!", "lz-ed25519 AAAA"} {
+		if _, err := ParseKey(bad); err == nil {
+			t.Errorf("ParseKey(%q) = nil error, expected failure", bad)
+		}
+	}
+}
+
+func TestRegenerate(t *testing.T) {
+	dir := t.TempDir()
+	team, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Trust(dir, Key{Public: team, Name: "ml-team"}); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := Generate(dir, "alice", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Trust(dir, first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Generate(dir, "alice", false); err == nil {
+		t.Error("expected error replacing a key without force")
+	}
+
+	// Regenerating with the same name replaces the old key's trust
+	private, err := LoadSigner(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Generate(dir, "alice", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Trust(dir, second); err == nil {
+		t.Error("expected Trust to refuse a second key with the same name")
+	}
+	if err := Replace(dir, PublicKey(private, ""), second); err != nil {
+		t.Fatalf("Replace() = %v", err)
+	}
+
+	trusted, err := LoadTrusted(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trusted) != 2 || !trusted[0].Public.Equal(team) || !trusted[1].Public.Equal(second.Public) || trusted[1].Name != "alice" {
+		t.Errorf("trusted after regenerating = %+v", trusted)
+	}
+}
@@ -0,0 +1,239 @@
+package pack
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"laziest/internal/binding"
+	"laziest/internal/config"
+)
+
+// Version is the pack format version written by New
+const Version = 1
+
+// Pack is a shareable collection of commands
+type Pack struct {
+	Version    int       `json:"version"`
+	Name       string    `json:"name,omitempty"`
+	ExportedAt time.Time `json:"exported_at"`
+	Commands   []Command `json:"commands"`
+}
+
+// Command is a saved command as it travels in a pack
+// Machine-specific details (working directory, import source) are left out.
+type Command struct {
+	Name        string            `json:"name"`
+	Command     string            `json:"command"`
+	Tags        []string          `json:"tags,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+}
+
+// Strategy decides what happens when a pack command's name is already taken
+type Strategy string
+
+const (
+	StrategySkip      Strategy = "skip"      // Keep the existing command
+	StrategyRename    Strategy = "rename"    // Import under a prefixed name
+	StrategyOverwrite Strategy = "overwrite" // Replace the existing command
+)
+
+// ParseStrategy validates a strategy name
+func ParseStrategy(s string) (Strategy, error) {
+	switch Strategy(s) {
+	case StrategySkip, StrategyRename, StrategyOverwrite:
+		return Strategy(s), nil
+	}
+	return "", fmt.Errorf("unknown conflict strategy '%s': use skip, rename or overwrite", s)
+}
+
+// Action is what importing does with one pack command
+type Action int
+
+const (
+	ActionAdd       Action = iota // New command
+	ActionRename                  // Added under a new name
+	ActionOverwrite               // Replaces an existing command
+	ActionSkip                    // Name taken, left alone
+	ActionUnchanged               // Already saved with the same command
+)
+
+// Change is the planned import of one pack command
+type Change struct {
+	Action  Action
+	Name    string // Name it will be saved under
+	Command Command
+}
+
+// New builds a pack from saved commands
+// Library commands are named without their library, or as library_name
+// ("ml/train" -> "ml_train") when another command has the same base name.
+func New(name string, cmds []config.Command) *Pack {
+	p := &Pack{Version: Version, Name: name, ExportedAt: time.Now().UTC()}
+	count := make(map[string]int)
+	for _, c := range cmds {
+		count[c.BaseName()]++
+	}
+	for _, c := range cmds {
+		cmdName := c.BaseName()
+		if c.Library != "" && count[cmdName] > 1 {
+			cmdName = c.Library + "_" + cmdName
+		}
+		p.Commands = append(p.Commands, Command{
+			Name:        cmdName,
+			Command:     c.Command,
+			Tags:        c.Tags,
+			Description: c.Description,
+			Env:         c.Env,
+		})
+	}
+	return p
+}
+
+// Load reads and validates a pack file
+func Load(path string) (*Pack, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Parse decodes and validates a pack
+// A signature field, if any, is ignored; see keys.Verify.
+func Parse(data []byte) (*Pack, error) {
+	var p Pack
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse pack: %w", err)
+	}
+	if p.Version > Version {
+		return nil, fmt.Errorf("pack uses format version %d; this lz supports up to %d", p.Version, Version)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid pack: %w", err)
+	}
+	return &p, nil
+}
+
+// Marshal returns the pack as indented JSON
+func (p *Pack) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Validate checks every command's name, tags and binding syntax
+// All problems are reported together so a pack can be fixed in one pass.
+func (p *Pack) Validate() error {
+	var problems []string
+	seen := make(map[string]bool)
+
+	for i, c := range p.Commands {
+		label := c.Name
+		if label == "" {
+			label = fmt.Sprintf("#%d", i+1)
+		}
+
+		switch {
+		case !config.IsValidName(c.Name):
+			problems = append(problems, fmt.Sprintf("%s: invalid name", label))
+		case seen[c.Name]:
+			problems = append(problems, fmt.Sprintf("%s: duplicate name", label))
+		}
+		seen[c.Name] = true
+
+		if strings.TrimSpace(c.Command) == "" {
+			problems = append(problems, fmt.Sprintf("%s: empty command", label))
+		}
+		for _, tag := range c.Tags {
+			if !config.IsValidTag(tag) {
+				problems = append(problems, fmt.Sprintf("%s: invalid tag '%s'", label, tag))
+			}
+		}
+		if _, err := binding.Parse(c.Command); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", label, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Plan works out how each pack command would be merged into cfg
+// Renamed commands get prefix, plus a number if that is taken too.
+func (p *Pack) Plan(cfg *config.Config, strategy Strategy, prefix string) []Change {
+	taken := make(map[string]bool)
+	for _, c := range cfg.Commands {
+		taken[c.Name] = true
+	}
+	for _, c := range p.Commands {
+		taken[c.Name] = true
+	}
+
+	changes := make([]Change, 0, len(p.Commands))
+	for _, c := range p.Commands {
+		change := Change{Action: ActionAdd, Name: c.Name, Command: c}
+
+		if existing, err := cfg.GetCommandByName(c.Name); err == nil {
+			switch {
+			case existing.Command == c.Command:
+				change.Action = ActionUnchanged
+			case strategy == StrategyOverwrite:
+				change.Action = ActionOverwrite
+			case strategy == StrategyRename:
+				change.Action = ActionRename
+				change.Name = prefix + c.Name
+				for n := 2; taken[change.Name]; n++ {
+					change.Name = fmt.Sprintf("%s%s_%d", prefix, c.Name, n)
+				}
+				taken[change.Name] = true
+			default:
+				change.Action = ActionSkip
+			}
+		}
+
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// Apply merges planned changes into cfg
+// Overwritten commands keep their working directory and added date.
+func Apply(cfg *config.Config, changes []Change) error {
+	for _, ch := range changes {
+		c := ch.Command
+		switch ch.Action {
+		case ActionAdd, ActionRename:
+			if err := cfg.AddCommand(ch.Name, c.Command, c.Tags); err != nil {
+				return err
+			}
+			if err := cfg.UpdateCommandDetails(ch.Name, c.Description, "", c.Env); err != nil {
+				return err
+			}
+		case ActionOverwrite:
+			existing, err := cfg.GetCommandByName(ch.Name)
+			if err != nil {
+				return err
+			}
+			cwd := existing.Cwd
+			if err := cfg.UpdateCommand(ch.Name, ch.Name, c.Command, c.Tags); err != nil {
+				return err
+			}
+			if err := cfg.UpdateCommandDetails(ch.Name, c.Description, cwd, c.Env); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
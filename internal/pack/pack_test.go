@@ -0,0 +1,117 @@
+package pack
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"laziest/internal/config"
+)
+
+func TestValidate(t *testing.T) {
+	p := &Pack{Commands: []Command{
+		{Name: "train", Command: "python train.py {%--epochs:[10,50]%}"},
+		{Name: "train", Command: "python train.py"},
+		{Name: "bad-name", Command: "ls"},
+		{Name: "empty", Command: " "},
+		{Name: "tagged", Command: "ls", Tags: []string{"ML", "not ok"}},
+		{Name: "broken", Command: "ls {%--x:[]%}"},
+	}}
+
+	err := p.Validate()
+	if err == nil {
+		t.Fatal("Validate() = nil, expected errors")
+	}
+	for _, want := range []string{"train: duplicate name", "bad-name: invalid name", "empty: empty command", "tagged: invalid tag 'not ok'", "broken:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error %q missing %q", err, want)
+		}
+	}
+
+	ok := &Pack{Commands: []Command{{Name: "gs", Command: "git status", Tags: []string{"Git"}}}}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("Validate() = %v, expected nil", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	p := New("mixed", []config.Command{
+		{Name: "train", Command: "python train.py"},
+		{Name: "ml/train", Command: "python ml/train.py", Library: "ml"},
+		{Name: "ml/eval", Command: "python ml/eval.py", Library: "ml"},
+	})
+	var names []string
+	for _, c := range p.Commands {
+		names = append(names, c.Name)
+	}
+	if expected := []string{"train", "ml_train", "eval"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("New() names = %v, expected %v", names, expected)
+	}
+	if err := p.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	cfg := &config.Config{Commands: []config.Command{
+		{Name: "gs", Command: "git status"},
+		{Name: "train", Command: "python old.py"},
+		{Name: "ml_train", Command: "python other.py"},
+	}}
+	p := &Pack{Commands: []Command{
+		{Name: "gs", Command: "git status"},
+		{Name: "train", Command: "python train.py"},
+		{Name: "eval", Command: "python eval.py"},
+	}}
+
+	tests := []struct {
+		strategy Strategy
+		expected []Action
+		names    []string
+	}{
+		{StrategySkip, []Action{ActionUnchanged, ActionSkip, ActionAdd}, []string{"gs", "train", "eval"}},
+		{StrategyOverwrite, []Action{ActionUnchanged, ActionOverwrite, ActionAdd}, []string{"gs", "train", "eval"}},
+		{StrategyRename, []Action{ActionUnchanged, ActionRename, ActionAdd}, []string{"gs", "ml_train_2", "eval"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			changes := p.Plan(cfg, tt.strategy, "ml_")
+			var actions []Action
+			var names []string
+			for _, c := range changes {
+				actions = append(actions, c.Action)
+				names = append(names, c.Name)
+			}
+			if !reflect.DeepEqual(actions, tt.expected) {
+				t.Errorf("actions = %v, expected %v", actions, tt.expected)
+			}
+			if !reflect.DeepEqual(names, tt.names) {
+				t.Errorf("names = %v, expected %v", names, tt.names)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	cfg := &config.Config{Commands: []config.Command{
+		{Name: "train", Command: "python old.py", Cwd: "/work"},
+	}}
+	p := &Pack{Commands: []Command{
+		{Name: "train", Command: "python train.py", Tags: []string{"ML"}, Description: "Train"},
+		{Name: "eval", Command: "python eval.py", Env: map[string]string{"CUDA_VISIBLE_DEVICES": "0"}},
+	}}
+
+	if err := Apply(cfg, p.Plan(cfg, StrategyOverwrite, "")); err != nil {
+		t.Fatal(err)
+	}
+
+	train, _ := cfg.GetCommandByName("train")
+	if train.Command != "python train.py" || train.Description != "Train" || train.Cwd != "/work" {
+		t.Errorf("train = %+v", *train)
+	}
+	eval, err := cfg.GetCommandByName("eval")
+	if err != nil || eval.Env["CUDA_VISIBLE_DEVICES"] != "0" {
+		t.Errorf("eval = %+v, %v", eval, err)
+	}
+}
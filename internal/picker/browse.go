@@ -0,0 +1,368 @@
+package picker
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// BrowseEntry is one entry of the directory shown by Browse
+type BrowseEntry struct {
+	Name    string
+	Dir     bool
+	Size    int64
+	ModTime time.Time
+	Match   bool // Can be picked
+	Open    bool // A directory that can be browsed into
+}
+
+// BrowseOptions configures Browse
+type BrowseOptions struct {
+	Optional bool // Offer [s] to skip
+	Dirs     bool // Directories are picked rather than files, with Space
+	Up       bool // Allow going above the starting directory
+	NewDir   bool // Offer [n] to name a new directory in the current one
+
+	// CheckNewDir, when set, checks the name given for a new directory
+	CheckNewDir func(name string) error
+}
+
+// browseRow is a line of the browser: the parent directory or an entry
+type browseRow struct {
+	parent bool
+	entry  BrowseEntry
+}
+
+// Browse displays a file browser starting at root, one directory at a time
+// list returns the entries of a directory given relative to root, with
+// slashes. Enter browses into a directory and picks a file, Backspace goes
+// back up, and '/' filters the current directory. The picked path is
+// returned relative to root; with opts.NewDir, ActionCustom returns the
+// relative path of a directory to create.
+func Browse(root string, list func(dir string) ([]BrowseEntry, error), prompt string, opts BrowseOptions) PickResult {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		fmt.Fprintln(os.Stderr, "Cannot show interactive picker: not a terminal")
+		return PickResult{Action: ActionCancel}
+	}
+
+	dir := "."
+	entries, err := list(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return PickResult{Action: ActionCancel}
+	}
+
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to enable raw mode: %v\n", err)
+		return PickResult{Action: ActionCancel}
+	}
+	defer term.Restore(fd, oldState)
+
+	enableBracketedPaste()
+	defer disableBracketedPaste()
+
+	window := max(min(15, getTerminalHeight()-4), 3)
+	cursor, offset, drawn := 0, 0, 0
+	filterMode := false
+	filter := newLineEditor("")
+	message := "" // Shown instead of the help line until the next key
+
+	canGoUp := func() bool {
+		return opts.Up || dir != "."
+	}
+
+	// rows lists what is shown for the current directory and filter
+	rows := func() []browseRow {
+		var shown []browseRow
+		if canGoUp() && filter.String() == "" {
+			shown = append(shown, browseRow{parent: true})
+		}
+		needle := strings.ToLower(filter.String())
+		for _, e := range entries {
+			if strings.Contains(strings.ToLower(e.Name), needle) {
+				shown = append(shown, browseRow{entry: e})
+			}
+		}
+		return shown
+	}
+
+	draw := func() {
+		clearLines(drawn)
+		where := "" // The prompt names root, so only show where below or above it
+		if dir != "." {
+			where = filepath.FromSlash(dir) + string(filepath.Separator)
+		}
+		drawn = renderBrowse(rows(), cursor, offset, window, prompt, where, filterMode, filter.display(), message, opts, canGoUp())
+		message = ""
+	}
+
+	// open shows another directory, with the cursor on the entry named focus
+	open := func(next, focus string) {
+		next = path.Clean(next)
+		nextEntries, err := list(next)
+		if err != nil {
+			message = err.Error()
+			return
+		}
+		dir, entries = next, nextEntries
+		filterMode = false
+		filter = newLineEditor("")
+		cursor, offset = 0, 0
+		shown := rows()
+		if len(shown) > 1 && shown[0].parent {
+			cursor = 1 // The first entry rather than ../
+		}
+		for i, r := range shown {
+			if !r.parent && r.entry.Name == focus {
+				cursor = i
+			}
+		}
+	}
+
+	goUp := func() {
+		if !canGoUp() {
+			return
+		}
+		focus := path.Base(dir)
+		if dir == "." || focus == ".." {
+			// Above the start, land on the directory just left
+			focus = filepath.Base(filepath.Join(root, filepath.FromSlash(dir)))
+		}
+		open(path.Join(dir, ".."), focus)
+	}
+
+	pick := func(name string) PickResult {
+		clearLines(drawn)
+		return PickResult{Action: ActionSelect, Value: filepath.FromSlash(path.Join(dir, name))}
+	}
+
+	if opts.Up && len(entries) > 0 {
+		cursor = 1 // The first entry rather than ../
+	}
+	draw()
+	keys := newKeyReader(os.Stdin)
+	for {
+		k, err := keys.readKey()
+		if err != nil {
+			return PickResult{Action: ActionCancel}
+		}
+		shown := rows()
+		var current *browseRow
+		if cursor < len(shown) {
+			current = &shown[cursor]
+		}
+
+		switch {
+		case k.kind == keyCtrlC:
+			clearLines(drawn)
+			return PickResult{Action: ActionCancel}
+
+		case k.kind == keyEnter, !filterMode && (k.is('l') || k.kind == keyRight):
+			switch {
+			case current == nil:
+			case current.parent:
+				goUp()
+			case current.entry.Dir && current.entry.Open:
+				open(path.Join(dir, current.entry.Name), "")
+			case current.entry.Match && (k.kind == keyEnter || !current.entry.Dir):
+				return pick(current.entry.Name)
+			}
+
+		case k.is(' ') && opts.Dirs && !filterMode:
+			if current != nil && !current.parent && current.entry.Match {
+				return pick(current.entry.Name)
+			}
+			message = "Only a matching directory can be picked"
+
+		case k.kind == keyUp, !filterMode && k.is('k'):
+			cursor = max(cursor-1, 0)
+
+		case k.kind == keyDown, !filterMode && k.is('j'):
+			cursor = max(min(cursor+1, len(shown)-1), 0)
+
+		case k.kind == keyPageUp:
+			cursor = max(cursor-window, 0)
+
+		case k.kind == keyPageDown:
+			cursor = max(min(cursor+window, len(shown)-1), 0)
+
+		case filterMode && k.kind == keyEsc: // Esc - clear the filter
+			filterMode = false
+			filter = newLineEditor("")
+			cursor = 0
+
+		case filterMode:
+			if filter.handle(k) {
+				cursor = 0
+			}
+
+		case k.is('q'), k.kind == keyEsc:
+			clearLines(drawn)
+			return PickResult{Action: ActionCancel}
+
+		case k.is('/'):
+			filterMode = true
+			cursor = 0
+
+		case k.kind == keyBackspace, k.is('h'), k.kind == keyLeft:
+			goUp()
+
+		case k.is('g'), k.kind == keyHome:
+			cursor = 0
+
+		case k.is('G'), k.kind == keyEnd:
+			cursor = max(len(shown)-1, 0)
+
+		case k.is('s') && opts.Optional:
+			clearLines(drawn)
+			return PickResult{Action: ActionSkip}
+
+		case k.is('n') && opts.NewDir:
+			clearLines(drawn)
+			drawn = 0
+			name, cancelled := PromptInput(fmt.Sprintf("New directory in %s: ", filepath.Join(root, filepath.FromSlash(dir))), "")
+			name = strings.TrimSpace(name)
+			if !cancelled && name != "" {
+				if opts.CheckNewDir != nil {
+					if err := opts.CheckNewDir(name); err != nil {
+						message = err.Error()
+						break
+					}
+				}
+				return PickResult{Action: ActionCustom, Value: filepath.FromSlash(path.Join(dir, filepath.ToSlash(name)))}
+			}
+
+		default:
+			continue
+		}
+
+		// Keep the cursor inside the window
+		cursor = min(cursor, max(len(rows())-1, 0))
+		if cursor < offset {
+			offset = cursor
+		} else if cursor >= offset+window {
+			offset = cursor - window + 1
+		}
+		draw()
+	}
+}
+
+// renderBrowse draws the browser and returns the number of lines drawn
+func renderBrowse(rows []browseRow, cursor, offset, window int, prompt, where string, filterMode bool, filterText, message string, opts BrowseOptions, canGoUp bool) int {
+	lines := 0
+	position := ""
+	if len(rows) > window {
+		position = fmt.Sprintf(" (%d/%d)", cursor+1, len(rows))
+	}
+	fmt.Printf("%s \033[2m%s%s\033[0m\r\n", prompt, where, position)
+	lines++
+
+	// Names take what the size and time columns leave
+	nameWidth := 0
+	for _, r := range rows {
+		nameWidth = max(nameWidth, stringWidth(entryName(r)))
+	}
+	nameWidth = max(min(nameWidth, getTerminalWidth()-30), 10)
+
+	if len(rows) == 0 {
+		fmt.Printf("  \033[2m(empty)\033[0m\r\n")
+		lines++
+	}
+	for i := offset; i < offset+window && i < len(rows); i++ {
+		line := formatBrowseRow(rows[i], nameWidth)
+		switch {
+		case i == cursor:
+			fmt.Printf("  \033[7m> %s\033[0m\r\n", line)
+		case opts.Dirs && !rows[i].parent && !rows[i].entry.Match:
+			fmt.Printf("    \033[2m%s\033[0m\r\n", line) // Can only be browsed
+		default:
+			fmt.Printf("    %s\r\n", line)
+		}
+		lines++
+	}
+
+	if filterMode {
+		fmt.Printf("  \033[36m/%s\033[0m\r\n", filterText)
+		lines++
+	}
+
+	if message != "" {
+		fmt.Printf("\033[31m  %s\033[0m", message)
+		return lines + 1
+	}
+
+	helpParts := []string{"[↑/↓] navigate", "[Enter] open/select"}
+	if opts.Dirs {
+		helpParts[1] = "[Enter] open"
+		helpParts = append(helpParts, "[Space] select")
+	}
+	if filterMode {
+		helpParts = append(helpParts, "[Esc] clear filter")
+	} else {
+		if canGoUp {
+			helpParts = append(helpParts, "[Backspace] up")
+		}
+		helpParts = append(helpParts, "[/] filter")
+		if opts.NewDir {
+			helpParts = append(helpParts, "[n] new directory")
+		}
+		if opts.Optional {
+			helpParts = append(helpParts, "[s] skip")
+		}
+		helpParts = append(helpParts, "[q/Esc] cancel")
+	}
+	fmt.Printf("\033[2m  %s\033[0m", strings.Join(helpParts, "  "))
+	return lines + 1
+}
+
+// entryName is a row's name, with a trailing slash for directories
+func entryName(r browseRow) string {
+	if r.parent {
+		return "../"
+	}
+	if r.entry.Dir {
+		return r.entry.Name + "/"
+	}
+	return r.entry.Name
+}
+
+// formatBrowseRow lays out a row as name, size and modification time
+func formatBrowseRow(r browseRow, nameWidth int) string {
+	name := truncateString(entryName(r), nameWidth)
+	name += strings.Repeat(" ", nameWidth-stringWidth(name))
+	if r.parent {
+		return name
+	}
+	size := "-"
+	if !r.entry.Dir {
+		size = formatSize(r.entry.Size)
+	}
+	return fmt.Sprintf("%s  %6s  %s", name, size, r.entry.ModTime.Format("2006-01-02 15:04"))
+}
+
+// formatSize formats a byte count the way ls -h does, e.g. 512B, 1.5K, 23M
+func formatSize(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%dB", n)
+	}
+	size := float64(n)
+	unit := ""
+	for _, u := range []string{"K", "M", "G", "T", "P"} {
+		size /= 1024
+		unit = u
+		if size < 1024 {
+			break
+		}
+	}
+	if size < 10 {
+		return fmt.Sprintf("%.1f%s", size, unit)
+	}
+	return fmt.Sprintf("%.0f%s", size, unit)
+}
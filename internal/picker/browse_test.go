@@ -0,0 +1,43 @@
+package picker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		size     int64
+		expected string
+	}{
+		{0, "0B"},
+		{1023, "1023B"},
+		{1536, "1.5K"},
+		{20 * 1024, "20K"},
+		{5 * 1024 * 1024, "5.0M"},
+		{3 << 40, "3.0T"},
+	}
+	for _, tt := range tests {
+		if got := formatSize(tt.size); got != tt.expected {
+			t.Errorf("formatSize(%d) = %q, expected %q", tt.size, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatBrowseRow(t *testing.T) {
+	stamp := time.Date(2024, 3, 9, 14, 5, 0, 0, time.UTC)
+	tests := []struct {
+		row      browseRow
+		expected string
+	}{
+		{browseRow{parent: true}, "../       "},
+		{browseRow{entry: BrowseEntry{Name: "ckpt", Dir: true, ModTime: stamp}}, "ckpt/            -  2024-03-09 14:05"},
+		{browseRow{entry: BrowseEntry{Name: "last.pt", Size: 2048, ModTime: stamp}}, "last.pt       2.0K  2024-03-09 14:05"},
+		{browseRow{entry: BrowseEntry{Name: "a-very-long-name.yaml", Size: 10, ModTime: stamp}}, "a-very-...     10B  2024-03-09 14:05"},
+	}
+	for _, tt := range tests {
+		if got := formatBrowseRow(tt.row, 10); got != tt.expected {
+			t.Errorf("formatBrowseRow(%+v) = %q, expected %q", tt.row, got, tt.expected)
+		}
+	}
+}
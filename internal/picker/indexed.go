@@ -0,0 +1,172 @@
+package picker
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// IndexedOptions configures PickIndexed
+type IndexedOptions struct {
+	Optional bool               // Offer [Skip]
+	Validate func(string) error // When set, a value can be typed instead, checked by Validate
+}
+
+// PickIndexed displays a picker over count items, fetching item(i) only for
+// the rows on screen, so very long sequences are never built in full
+// Typing a digit, '-' or '.' (or pressing 'c') starts entering a value
+// directly when opts.Validate is set.
+func PickIndexed(count int, item func(int) string, prompt string, opts IndexedOptions) PickResult {
+	if count == 0 {
+		return PickResult{Action: ActionCancel}
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		fmt.Fprintln(os.Stderr, "Cannot show interactive picker: not a terminal")
+		return PickResult{Action: ActionCancel}
+	}
+
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to enable raw mode: %v\n", err)
+		return PickResult{Action: ActionCancel}
+	}
+	defer term.Restore(fd, oldState)
+
+	// Row 0 is [Skip] for optional pickers
+	skipOffset := 0
+	if opts.Optional {
+		skipOffset = 1
+	}
+	rows := count + skipOffset
+	label := func(row int) string {
+		if row < skipOffset {
+			return "[Skip]"
+		}
+		return item(row - skipOffset)
+	}
+
+	// Long sequences scroll within a window that fits the terminal
+	window := min(rows, 15)
+	if h := getTerminalHeight() - 3; window > h {
+		window = max(h, 3)
+	}
+
+	// typeValue asks for a value, starting with initial; false if cancelled
+	typeValue := func(initial string) (string, bool) {
+		clearLines(window + 2)
+		value, cancelled := PromptValidated(prompt+" ", initial, opts.Validate)
+		return value, !cancelled
+	}
+
+	cursor, offset := 0, 0
+	renderIndexed(label, rows, cursor, offset, window, prompt, opts, true)
+
+	keys := newKeyReader(os.Stdin)
+	for {
+		k, err := keys.readKey()
+		if err != nil {
+			return PickResult{Action: ActionCancel}
+		}
+
+		switch {
+		case k.is('q'), k.kind == keyEsc, k.kind == keyCtrlC: // q, Esc, Ctrl+C
+			clearLines(window + 2)
+			return PickResult{Action: ActionCancel}
+
+		case k.kind == keyEnter: // Enter
+			clearLines(window + 2)
+			if cursor < skipOffset {
+				return PickResult{Action: ActionSkip}
+			}
+			return PickResult{Action: ActionSelect, Value: item(cursor - skipOffset)}
+
+		case k.is('s', 'S') && opts.Optional: // s - skip
+			clearLines(window + 2)
+			return PickResult{Action: ActionSkip}
+
+		case opts.Validate != nil && (k.is('c', 'C', '-', '.') || (k.kind == keyRune && k.r >= '0' && k.r <= '9')):
+			// Type a value, starting with the key pressed unless it was 'c'
+			initial := ""
+			if !k.is('c', 'C') {
+				initial = string(k.r)
+			}
+			if value, ok := typeValue(initial); ok {
+				return PickResult{Action: ActionCustom, Value: value}
+			}
+			renderIndexed(label, rows, cursor, offset, window, prompt, opts, true)
+			continue
+
+		case k.is('k', 'K'), k.kind == keyUp: // k or Up
+			if cursor > 0 {
+				cursor--
+			}
+
+		case k.is('j', 'J'), k.kind == keyDown: // j or Down
+			if cursor < rows-1 {
+				cursor++
+			}
+
+		case k.kind == keyPageUp: // Page Up
+			cursor = max(cursor-window, 0)
+
+		case k.kind == keyPageDown: // Page Down
+			cursor = min(cursor+window, rows-1)
+
+		case k.is('g'), k.kind == keyHome: // g or Home
+			cursor = 0
+
+		case k.is('G'), k.kind == keyEnd: // G or End
+			cursor = rows - 1
+
+		default:
+			continue
+		}
+
+		// Keep the cursor inside the window
+		if cursor < offset {
+			offset = cursor
+		} else if cursor >= offset+window {
+			offset = cursor - window + 1
+		}
+		renderIndexed(label, rows, cursor, offset, window, prompt, opts, false)
+	}
+}
+
+// renderIndexed draws the visible window of an indexed picker
+func renderIndexed(label func(int) string, rows, cursor, offset, window int, prompt string, opts IndexedOptions, firstRender bool) {
+	if !firstRender {
+		clearLines(window + 2)
+	}
+
+	position := ""
+	if rows > window {
+		position = fmt.Sprintf(" \033[2m(%d/%d)\033[0m", cursor+1, rows)
+	}
+	fmt.Printf("%s%s\r\n", prompt, position)
+
+	for row := offset; row < offset+window && row < rows; row++ {
+		if row == cursor {
+			fmt.Printf("  \033[7m> %s\033[0m\r\n", label(row))
+		} else {
+			fmt.Printf("    %s\r\n", label(row))
+		}
+	}
+
+	helpParts := []string{"[↑/↓/j/k] navigate"}
+	if rows > window {
+		helpParts = append(helpParts, "[g/G] first/last")
+	}
+	helpParts = append(helpParts, "[Enter] select")
+	if opts.Validate != nil {
+		helpParts = append(helpParts, "[0-9/c] type a value")
+	}
+	if opts.Optional {
+		helpParts = append(helpParts, "[s] skip")
+	}
+	helpParts = append(helpParts, "[q/Esc] cancel")
+	fmt.Printf("\033[2m  %s\033[0m", strings.Join(helpParts, "  "))
+}
@@ -0,0 +1,509 @@
+package picker
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// keyKind identifies a decoded keypress
+type keyKind int
+
+const (
+	keyRune          keyKind = iota // Printable character (in key.r)
+	keyEnter                        // Enter / Return
+	keyEsc                          // Lone Escape
+	keyCtrlC                        // Ctrl+C
+	keyBackspace                    // Backspace / Ctrl+H
+	keyDelete                       // Delete (forward)
+	keyUp                           // Arrow up
+	keyDown                         // Arrow down
+	keyLeft                         // Arrow left
+	keyRight                        // Arrow right
+	keyHome                         // Home
+	keyEnd                          // End
+	keyPageUp                       // Page up
+	keyPageDown                     // Page down
+	keyWordLeft                     // Alt+B / Ctrl+Left
+	keyWordRight                    // Alt+F / Ctrl+Right
+	keyWordBackspace                // Alt+Backspace
+	keyTab                          // Tab
+	keyCtrl                         // Other Ctrl+letter (letter in key.r)
+	keyPaste                        // Bracketed paste (text in key.text)
+	keyUnknown                      // Unrecognized sequence
+)
+
+// key represents a single decoded keypress
+type key struct {
+	kind keyKind
+	r    rune   // For keyRune and keyCtrl
+	text string // For keyPaste
+}
+
+// is reports whether the key is one of the given printable characters
+func (k key) is(runes ...rune) bool {
+	if k.kind != keyRune {
+		return false
+	}
+	for _, r := range runes {
+		if k.r == r {
+			return true
+		}
+	}
+	return false
+}
+
+// ctrl reports whether the key is Ctrl+letter
+func (k key) ctrl(letter rune) bool {
+	return k.kind == keyCtrl && k.r == letter
+}
+
+// pasteEnd marks the end of a bracketed paste (the start is CSI 200~)
+const pasteEnd = "\033[201~"
+
+// keyReader decodes raw terminal input into keypresses
+// Handles multi-byte UTF-8, CSI/SS3 escape sequences and bracketed paste
+type keyReader struct {
+	r   io.Reader
+	buf []byte
+}
+
+func newKeyReader(r io.Reader) *keyReader {
+	return &keyReader{r: r}
+}
+
+// fill reads more input into the buffer
+func (kr *keyReader) fill() error {
+	chunk := make([]byte, 256)
+	n, err := kr.r.Read(chunk)
+	if n > 0 {
+		kr.buf = append(kr.buf, chunk[:n]...)
+		return nil
+	}
+	if err == nil {
+		err = io.ErrNoProgress
+	}
+	return err
+}
+
+// readKey returns the next keypress, blocking until one is available
+func (kr *keyReader) readKey() (key, error) {
+	for len(kr.buf) == 0 {
+		if err := kr.fill(); err != nil {
+			return key{}, err
+		}
+	}
+
+	b := kr.buf[0]
+	switch {
+	case b == 27:
+		return kr.readEscape()
+	case b == 13 || b == 10:
+		kr.consume(1)
+		return key{kind: keyEnter}, nil
+	case b == 3:
+		kr.consume(1)
+		return key{kind: keyCtrlC}, nil
+	case b == 127 || b == 8:
+		kr.consume(1)
+		return key{kind: keyBackspace}, nil
+	case b == 9:
+		kr.consume(1)
+		return key{kind: keyTab}, nil
+	case b >= 1 && b <= 26:
+		kr.consume(1)
+		return key{kind: keyCtrl, r: rune('a' + b - 1)}, nil
+	case b < 32:
+		kr.consume(1)
+		return key{kind: keyUnknown}, nil
+	}
+
+	// Printable character, possibly multi-byte UTF-8
+	for !utf8.FullRune(kr.buf) {
+		if err := kr.fill(); err != nil {
+			kr.consume(len(kr.buf))
+			return key{kind: keyUnknown}, nil
+		}
+	}
+	r, size := utf8.DecodeRune(kr.buf)
+	kr.consume(size)
+	if r == utf8.RuneError {
+		return key{kind: keyUnknown}, nil
+	}
+	return key{kind: keyRune, r: r}, nil
+}
+
+// readEscape decodes a sequence starting with ESC
+// A lone ESC at the end of the available input is reported as keyEsc
+func (kr *keyReader) readEscape() (key, error) {
+	if len(kr.buf) == 1 {
+		kr.consume(1)
+		return key{kind: keyEsc}, nil
+	}
+
+	switch kr.buf[1] {
+	case '[':
+		return kr.readCSI()
+	case 'O':
+		if len(kr.buf) < 3 {
+			kr.consume(len(kr.buf))
+			return key{kind: keyUnknown}, nil
+		}
+		final := kr.buf[2]
+		kr.consume(3)
+		return key{kind: csiFinalKind(final)}, nil
+	case 'b', 'B':
+		kr.consume(2)
+		return key{kind: keyWordLeft}, nil
+	case 'f', 'F':
+		kr.consume(2)
+		return key{kind: keyWordRight}, nil
+	case 127, 8:
+		kr.consume(2)
+		return key{kind: keyWordBackspace}, nil
+	}
+
+	// Alt+<key> or unrecognized - drop the pair
+	kr.consume(2)
+	return key{kind: keyUnknown}, nil
+}
+
+// readCSI decodes a Control Sequence Introducer sequence (ESC [ params final)
+func (kr *keyReader) readCSI() (key, error) {
+	end := -1
+	for end < 0 {
+		for i := 2; i < len(kr.buf); i++ {
+			if kr.buf[i] >= 0x40 && kr.buf[i] <= 0x7e {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			if err := kr.fill(); err != nil {
+				kr.consume(len(kr.buf))
+				return key{kind: keyUnknown}, nil
+			}
+		}
+	}
+
+	params := string(kr.buf[2:end])
+	final := kr.buf[end]
+	kr.consume(end + 1)
+
+	if final == '~' {
+		switch params {
+		case "200":
+			return kr.readPaste()
+		case "1", "7":
+			return key{kind: keyHome}, nil
+		case "4", "8":
+			return key{kind: keyEnd}, nil
+		case "3":
+			return key{kind: keyDelete}, nil
+		case "5":
+			return key{kind: keyPageUp}, nil
+		case "6":
+			return key{kind: keyPageDown}, nil
+		}
+		return key{kind: keyUnknown}, nil
+	}
+
+	// Ctrl/Alt modified arrows: ESC [ 1 ; 5 C
+	if strings.HasPrefix(params, "1;") {
+		switch final {
+		case 'C':
+			return key{kind: keyWordRight}, nil
+		case 'D':
+			return key{kind: keyWordLeft}, nil
+		}
+	}
+
+	return key{kind: csiFinalKind(final)}, nil
+}
+
+// csiFinalKind maps the final byte of a CSI/SS3 sequence to a key kind
+func csiFinalKind(final byte) keyKind {
+	switch final {
+	case 'A':
+		return keyUp
+	case 'B':
+		return keyDown
+	case 'C':
+		return keyRight
+	case 'D':
+		return keyLeft
+	case 'H':
+		return keyHome
+	case 'F':
+		return keyEnd
+	}
+	return keyUnknown
+}
+
+// readPaste collects bracketed paste content up to the end marker
+func (kr *keyReader) readPaste() (key, error) {
+	for {
+		if idx := strings.Index(string(kr.buf), pasteEnd); idx >= 0 {
+			text := string(kr.buf[:idx])
+			kr.consume(idx + len(pasteEnd))
+			return key{kind: keyPaste, text: text}, nil
+		}
+		if err := kr.fill(); err != nil {
+			text := string(kr.buf)
+			kr.consume(len(kr.buf))
+			return key{kind: keyPaste, text: text}, nil
+		}
+	}
+}
+
+func (kr *keyReader) consume(n int) {
+	kr.buf = kr.buf[n:]
+}
+
+// pasteDepth counts nested enableBracketedPaste calls (e.g. a prompt opened
+// from inside a picker) so the inner one doesn't switch paste mode off early
+var pasteDepth int
+
+// enableBracketedPaste asks the terminal to wrap pasted text in markers
+func enableBracketedPaste() {
+	if pasteDepth == 0 {
+		fmt.Print("\033[?2004h")
+	}
+	pasteDepth++
+}
+
+// disableBracketedPaste restores normal paste behavior
+func disableBracketedPaste() {
+	pasteDepth--
+	if pasteDepth == 0 {
+		fmt.Print("\033[?2004l")
+	}
+}
+
+// lineEditor is a single-line text buffer with a cursor
+// Supports emacs-style movement, word deletion and kill/yank
+type lineEditor struct {
+	buf    []rune
+	pos    int    // Cursor position (index into buf)
+	offset int    // First visible rune when the line is scrolled
+	killed []rune // Last killed text, inserted again by Ctrl+Y
+}
+
+func newLineEditor(initial string) *lineEditor {
+	buf := []rune(initial)
+	return &lineEditor{buf: buf, pos: len(buf)}
+}
+
+// String returns the current contents
+func (e *lineEditor) String() string {
+	return string(e.buf)
+}
+
+// handle applies an editing key and reports whether it was consumed
+func (e *lineEditor) handle(k key) bool {
+	switch {
+	case k.kind == keyRune:
+		e.insert([]rune{k.r})
+	case k.kind == keyPaste:
+		e.insert(sanitizePaste(k.text))
+	case k.kind == keyBackspace:
+		if e.pos > 0 {
+			e.buf = append(e.buf[:e.pos-1], e.buf[e.pos:]...)
+			e.pos--
+		}
+	case k.kind == keyDelete, k.ctrl('d'):
+		if e.pos < len(e.buf) {
+			e.buf = append(e.buf[:e.pos], e.buf[e.pos+1:]...)
+		}
+	case k.kind == keyLeft, k.ctrl('b'):
+		if e.pos > 0 {
+			e.pos--
+		}
+	case k.kind == keyRight, k.ctrl('f'):
+		if e.pos < len(e.buf) {
+			e.pos++
+		}
+	case k.kind == keyHome, k.ctrl('a'):
+		e.pos = 0
+	case k.kind == keyEnd, k.ctrl('e'):
+		e.pos = len(e.buf)
+	case k.kind == keyWordLeft:
+		e.pos = e.wordStart()
+	case k.kind == keyWordRight:
+		e.pos = e.wordEnd()
+	case k.kind == keyWordBackspace, k.ctrl('w'):
+		e.kill(e.wordStart(), e.pos)
+	case k.ctrl('u'):
+		e.kill(0, e.pos)
+	case k.ctrl('k'):
+		e.kill(e.pos, len(e.buf))
+	case k.ctrl('y'):
+		e.insert(e.killed)
+	default:
+		return false
+	}
+	return true
+}
+
+// insert adds runes at the cursor
+func (e *lineEditor) insert(runes []rune) {
+	if len(runes) == 0 {
+		return
+	}
+	tail := append([]rune{}, e.buf[e.pos:]...)
+	e.buf = append(append(e.buf[:e.pos], runes...), tail...)
+	e.pos += len(runes)
+}
+
+// kill removes buf[from:to] and remembers it for yank
+func (e *lineEditor) kill(from, to int) {
+	if from >= to {
+		return
+	}
+	e.killed = append([]rune{}, e.buf[from:to]...)
+	e.buf = append(e.buf[:from], e.buf[to:]...)
+	e.pos = from
+}
+
+// wordStart returns the start of the word before the cursor
+func (e *lineEditor) wordStart() int {
+	i := e.pos
+	for i > 0 && unicode.IsSpace(e.buf[i-1]) {
+		i--
+	}
+	for i > 0 && !unicode.IsSpace(e.buf[i-1]) {
+		i--
+	}
+	return i
+}
+
+// wordEnd returns the end of the word after the cursor
+func (e *lineEditor) wordEnd() int {
+	i := e.pos
+	for i < len(e.buf) && unicode.IsSpace(e.buf[i]) {
+		i++
+	}
+	for i < len(e.buf) && !unicode.IsSpace(e.buf[i]) {
+		i++
+	}
+	return i
+}
+
+// render returns the escape sequence that redraws the prompt and buffer on
+// the current line, scrolling horizontally so the cursor stays visible
+func (e *lineEditor) render(prompt string, termWidth int) string {
+	avail := termWidth - stringWidth(prompt) - 1
+	if avail < 10 {
+		avail = 10
+	}
+
+	// Keep the cursor inside the visible window
+	if e.pos < e.offset {
+		e.offset = e.pos
+	}
+	for e.offset < e.pos && runesWidth(e.buf[e.offset:e.pos]) > avail {
+		e.offset++
+	}
+
+	end := e.offset
+	used := 0
+	for end < len(e.buf) {
+		w := runeWidth(e.buf[end])
+		if used+w > avail {
+			break
+		}
+		used += w
+		end++
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\r\033[K")
+	sb.WriteString(prompt)
+	sb.WriteString(string(e.buf[e.offset:end]))
+	sb.WriteString("\r")
+	if col := stringWidth(prompt) + runesWidth(e.buf[e.offset:e.pos]); col > 0 {
+		sb.WriteString(fmt.Sprintf("\033[%dC", col))
+	}
+	return sb.String()
+}
+
+// display returns the buffer with the cursor shown in reverse video
+// Used where the terminal cursor sits elsewhere (e.g. picker filter line)
+// Returns "" for an empty buffer
+func (e *lineEditor) display() string {
+	if len(e.buf) == 0 {
+		return ""
+	}
+	if e.pos >= len(e.buf) {
+		return string(e.buf) + "\033[7m \033[27m"
+	}
+	return string(e.buf[:e.pos]) + "\033[7m" + string(e.buf[e.pos]) + "\033[27m" + string(e.buf[e.pos+1:])
+}
+
+// sanitizePaste flattens pasted text onto a single line
+func sanitizePaste(text string) []rune {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.TrimRight(text, "\n")
+	var out []rune
+	for _, r := range text {
+		switch {
+		case r == '\n' || r == '\r' || r == '\t':
+			out = append(out, ' ')
+		case unicode.IsControl(r):
+			// Drop other control characters
+		default:
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// wideRanges lists East Asian wide and fullwidth code points (two columns)
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},
+	{0x2E80, 0x303E},
+	{0x3041, 0x33FF},
+	{0x3400, 0x4DBF},
+	{0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF},
+	{0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF},
+	{0xFE30, 0xFE4F},
+	{0xFF00, 0xFF60},
+	{0xFFE0, 0xFFE6},
+	{0x1F300, 0x1F64F},
+	{0x1F900, 0x1F9FF},
+	{0x20000, 0x2FFFD},
+	{0x30000, 0x3FFFD},
+}
+
+// runeWidth returns the number of terminal columns a rune occupies
+func runeWidth(r rune) int {
+	if r == 0 || unicode.IsControl(r) {
+		return 0
+	}
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	for _, rng := range wideRanges {
+		if r >= rng[0] && r <= rng[1] {
+			return 2
+		}
+	}
+	return 1
+}
+
+func runesWidth(runes []rune) int {
+	w := 0
+	for _, r := range runes {
+		w += runeWidth(r)
+	}
+	return w
+}
+
+// stringWidth returns the display width of a string
+func stringWidth(s string) int {
+	return runesWidth([]rune(s))
+}
@@ -0,0 +1,129 @@
+package picker
+
+import (
+	"strings"
+	"testing"
+)
+
+func readAll(t *testing.T, input string) []key {
+	t.Helper()
+	kr := newKeyReader(strings.NewReader(input))
+	var keys []key
+	for {
+		k, err := kr.readKey()
+		if err != nil {
+			return keys
+		}
+		keys = append(keys, k)
+	}
+}
+
+func TestKeyReader(t *testing.T) {
+	keys := readAll(t, "aé日\x1b[D\x1b[3~\x1b[1;5C\x1bb\x17\x1b[200~pasted\ntext\x1b[201~\r")
+
+	expected := []key{
+		{kind: keyRune, r: 'a'},
+		{kind: keyRune, r: 'é'},
+		{kind: keyRune, r: '日'},
+		{kind: keyLeft},
+		{kind: keyDelete},
+		{kind: keyWordRight},
+		{kind: keyWordLeft},
+		{kind: keyCtrl, r: 'w'},
+		{kind: keyPaste, text: "pasted\ntext"},
+		{kind: keyEnter},
+	}
+
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d: %+v", len(expected), len(keys), keys)
+	}
+	for i, k := range keys {
+		if k != expected[i] {
+			t.Errorf("key %d: expected %+v, got %+v", i, expected[i], k)
+		}
+	}
+}
+
+func TestLineEditor(t *testing.T) {
+	tests := []struct {
+		name     string
+		initial  string
+		keys     []key
+		expected string
+		pos      int
+	}{
+		{
+			name:     "insert in the middle",
+			initial:  "hllo",
+			keys:     []key{{kind: keyHome}, {kind: keyRight}, {kind: keyRune, r: 'e'}},
+			expected: "hello",
+			pos:      2,
+		},
+		{
+			name:     "backspace multi-byte",
+			initial:  "naïve",
+			keys:     []key{{kind: keyLeft}, {kind: keyLeft}, {kind: keyBackspace}},
+			expected: "nave",
+			pos:      2,
+		},
+		{
+			name:     "ctrl-w deletes previous word",
+			initial:  "git commit --amend",
+			keys:     []key{{kind: keyCtrl, r: 'w'}},
+			expected: "git commit ",
+			pos:      11,
+		},
+		{
+			name:     "ctrl-u then yank",
+			initial:  "python train.py",
+			keys:     []key{{kind: keyWordLeft}, {kind: keyCtrl, r: 'u'}, {kind: keyEnd}, {kind: keyRune, r: ' '}, {kind: keyCtrl, r: 'y'}},
+			expected: "train.py python ",
+			pos:      16,
+		},
+		{
+			name:     "ctrl-k kills to end",
+			initial:  "echo hello world",
+			keys:     []key{{kind: keyCtrl, r: 'a'}, {kind: keyWordRight}, {kind: keyCtrl, r: 'k'}},
+			expected: "echo",
+			pos:      4,
+		},
+		{
+			name:     "paste flattens newlines",
+			initial:  "",
+			keys:     []key{{kind: keyPaste, text: "a\nb\r\n"}},
+			expected: "a b",
+			pos:      3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newLineEditor(tt.initial)
+			for _, k := range tt.keys {
+				e.handle(k)
+			}
+			if e.String() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, e.String())
+			}
+			if e.pos != tt.pos {
+				t.Errorf("expected cursor at %d, got %d", tt.pos, e.pos)
+			}
+		})
+	}
+}
+
+func TestStringWidth(t *testing.T) {
+	tests := map[string]int{
+		"abc": 3,
+		"日本語": 6,
+		"é":  1,
+	}
+	for s, expected := range tests {
+		if w := stringWidth(s); w != expected {
+			t.Errorf("stringWidth(%q) = %d, expected %d", s, w, expected)
+		}
+	}
+	if got := truncateString("日本語テキスト", 9); got != "日本語..." {
+		t.Errorf("truncateString = %q", got)
+	}
+}
@@ -0,0 +1,161 @@
+package picker
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// MultiItem is an entry in a multi-select list
+type MultiItem struct {
+	Label    string
+	Note     string // Shown dimmed after the label, e.g. "(exists)"
+	Selected bool   // Initial selection
+}
+
+// PickMulti displays a list where any number of items can be selected
+// Returns the selected indices and true, or nil and false if cancelled
+func PickMulti(items []MultiItem, prompt string) ([]int, bool) {
+	if len(items) == 0 {
+		return nil, false
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		fmt.Fprintln(os.Stderr, "Cannot show interactive picker: not a terminal")
+		return nil, false
+	}
+
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to enable raw mode: %v\n", err)
+		return nil, false
+	}
+	defer term.Restore(fd, oldState)
+
+	flushStdin(fd)
+
+	selected := make([]bool, len(items))
+	for i, item := range items {
+		selected[i] = item.Selected
+	}
+
+	// Long lists scroll within a window that fits the terminal
+	window := len(items)
+	if h := getTerminalHeight() - 3; window > h {
+		window = max(h, 3)
+	}
+
+	cursor, offset := 0, 0
+	renderMulti(items, selected, cursor, offset, window, prompt, true)
+
+	keys := newKeyReader(os.Stdin)
+	for {
+		k, err := keys.readKey()
+		if err != nil {
+			return nil, false
+		}
+
+		switch {
+		case k.is('q'), k.kind == keyEsc, k.kind == keyCtrlC: // q, Esc, Ctrl+C
+			clearLines(window + 2)
+			return nil, false
+
+		case k.kind == keyEnter: // Enter
+			clearLines(window + 2)
+			var chosen []int
+			for i, s := range selected {
+				if s {
+					chosen = append(chosen, i)
+				}
+			}
+			return chosen, true
+
+		case k.is(' '): // Space - toggle
+			selected[cursor] = !selected[cursor]
+
+		case k.is('a', 'A'): // a - select all, or none if all are selected
+			all := true
+			for _, s := range selected {
+				all = all && s
+			}
+			for i := range selected {
+				selected[i] = !all
+			}
+
+		case k.is('k', 'K'), k.kind == keyUp: // k or Up
+			if cursor > 0 {
+				cursor--
+			}
+
+		case k.is('j', 'J'), k.kind == keyDown: // j or Down
+			if cursor < len(items)-1 {
+				cursor++
+			}
+
+		case k.kind == keyPageUp: // Page Up
+			cursor = max(cursor-window, 0)
+
+		case k.kind == keyPageDown: // Page Down
+			cursor = min(cursor+window, len(items)-1)
+
+		default:
+			continue
+		}
+
+		// Keep the cursor inside the window
+		if cursor < offset {
+			offset = cursor
+		} else if cursor >= offset+window {
+			offset = cursor - window + 1
+		}
+		renderMulti(items, selected, cursor, offset, window, prompt, false)
+	}
+}
+
+// renderMulti draws the multi-select list
+func renderMulti(items []MultiItem, selected []bool, cursor, offset, window int, prompt string, firstRender bool) {
+	if !firstRender {
+		clearLines(window + 2)
+	}
+
+	count := 0
+	for _, s := range selected {
+		if s {
+			count++
+		}
+	}
+	fmt.Printf("%s \033[2m(%d/%d selected)\033[0m\r\n", prompt, count, len(items))
+
+	width := getTerminalWidth()
+	for i := offset; i < offset+window && i < len(items); i++ {
+		box := "[ ]"
+		if selected[i] {
+			box = "[x]"
+		}
+
+		note := ""
+		if items[i].Note != "" {
+			note = "  " + items[i].Note
+		}
+		label := truncateString(items[i].Label, max(width-8-stringWidth(note), 10))
+
+		if i == cursor {
+			fmt.Printf("  \033[7m> %s %s\033[0m\033[2m%s\033[0m\r\n", box, label, note)
+		} else {
+			fmt.Printf("    %s %s\033[2m%s\033[0m\r\n", box, label, note)
+		}
+	}
+
+	fmt.Printf("\033[2m  [↑/↓/j/k] navigate  [Space] toggle  [a] all  [Enter] confirm  [q/Esc] cancel\033[0m")
+}
+
+// getTerminalHeight returns the terminal height, defaulting to 24 if it can't be determined
+func getTerminalHeight() int {
+	_, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || height <= 0 {
+		return 24 // Default fallback
+	}
+	return height
+}
@@ -20,6 +20,8 @@ const (
 	ActionCustom
 	ActionDelete
 	ActionModify
+	ActionEdit
+	ActionRebuild
 )
 
 // PickResult represents the result of a picker interaction
@@ -37,6 +39,18 @@ type Item struct {
 	Name    string
 	Command string
 	Tags    []string
+	Library string // Library the command is shared from, marked in the list
+}
+
+// libraryMarker follows the names of library commands
+const libraryMarker = " (lib)"
+
+// label returns the name as shown in the list
+func (item Item) label() string {
+	if item.Library != "" {
+		return item.Name + libraryMarker
+	}
+	return item.Name
 }
 
 // formatTagsDisplay formats tags for picker display
@@ -108,8 +122,18 @@ func filterStrings(items []string, filter string) []int {
 }
 
 // Pick displays an interactive picker and returns the selected item
-// Returns PickResult with action (Cancel, Select, SelectWithExtra, or Delete)
+// Returns PickResult with action (Cancel, Select, SelectWithExtra, Delete, Modify, or Edit)
 func Pick(items []Item, prompt string) PickResult {
+	return PickWith(items, prompt, PickOptions{})
+}
+
+// PickOptions configures PickWith
+type PickOptions struct {
+	SelectOnly bool // Only pick with Enter: no extra, modify, editor, rebuild or delete keys
+}
+
+// PickWith is Pick with more options
+func PickWith(items []Item, prompt string, opts PickOptions) PickResult {
 	if len(items) == 0 {
 		return PickResult{Action: ActionCancel}
 	}
@@ -136,8 +160,8 @@ func Pick(items []Item, prompt string) PickResult {
 	maxNameLen := 0
 	maxTagLen := 0
 	for _, item := range items {
-		if len(item.Name) > maxNameLen {
-			maxNameLen = len(item.Name)
+		if len(item.label()) > maxNameLen {
+			maxNameLen = len(item.label())
 		}
 		tagStr := formatTagsDisplay(item.Tags)
 		if len(tagStr) > maxTagLen {
@@ -145,32 +169,37 @@ func Pick(items []Item, prompt string) PickResult {
 		}
 	}
 
+	enableBracketedPaste()
+	defer disableBracketedPaste()
+
 	// Filter state
 	filterMode := false
 	filterText := ""
+	filter := newLineEditor("")
 	var filteredIndices []int
 	prevFilteredCount := len(items) // Track previous filtered count for clearing
 
+	help := "[↑/↓/j/k] navigate  [Enter] select  [/] filter  [e] extra  [m] modify  [v] editor  [b] rebuild  [x] delete  [q] cancel"
+	if opts.SelectOnly {
+		help = "[↑/↓/j/k] navigate  [Enter] select  [/] filter  [q] cancel"
+	}
+
 	// Initial render
-	render(items, selected, maxNameLen, maxTagLen, prompt, "", true, "", nil, len(items))
+	render(items, selected, maxNameLen, maxTagLen, prompt, "", true, "", nil, len(items), help)
 
 	// Input loop
-	buf := make([]byte, 3)
+	keys := newKeyReader(os.Stdin)
 	confirmDelete := false
 
 	for {
-		n, err := os.Stdin.Read(buf)
+		k, err := keys.readKey()
 		if err != nil {
 			return PickResult{Action: ActionCancel}
 		}
 
-		if n == 0 {
-			continue
-		}
-
 		// Handle delete confirmation mode
 		if confirmDelete {
-			if buf[0] == 'y' || buf[0] == 'Y' {
+			if k.is('y', 'Y') {
 				clearLines(prevFilteredCount + 2)
 				// Get actual item from filtered index
 				actualIdx := selected
@@ -184,27 +213,28 @@ func Pick(items []Item, prompt string) PickResult {
 			}
 			// Any other key cancels delete
 			confirmDelete = false
-			render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filterText, filteredIndices, prevFilteredCount)
+			render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filter.display(), filteredIndices, prevFilteredCount, help)
 			continue
 		}
 
 		// Handle filter mode input
 		if filterMode {
 			switch {
-			case buf[0] == 27 && n == 1: // Esc - clear filter and exit filter mode
+			case k.kind == keyEsc: // Esc - clear filter and exit filter mode
 				filterMode = false
 				filterText = ""
+				filter = newLineEditor("")
 				filteredIndices = nil
 				selected = 0
-				render(items, selected, maxNameLen, maxTagLen, prompt, "", false, "", nil, prevFilteredCount+1) // +1 for filter line
+				render(items, selected, maxNameLen, maxTagLen, prompt, "", false, "", nil, prevFilteredCount+1, help) // +1 for filter line
 				prevFilteredCount = len(items)
 				continue
 
-			case buf[0] == 3: // Ctrl+C - cancel picker entirely
+			case k.kind == keyCtrlC: // Ctrl+C - cancel picker entirely
 				clearLines(prevFilteredCount + 3) // +1 for filter line
 				return PickResult{Action: ActionCancel}
 
-			case buf[0] == 13 || buf[0] == 10: // Enter - select current item
+			case k.kind == keyEnter: // Enter - select current item
 				if filteredIndices != nil && len(filteredIndices) > 0 {
 					clearLines(len(filteredIndices) + 3) // +1 for filter line
 					actualIdx := filteredIndices[selected]
@@ -216,79 +246,67 @@ func Pick(items []Item, prompt string) PickResult {
 				// No matches, ignore Enter
 				continue
 
-			case buf[0] == 127: // Backspace
-				if len(filterText) > 0 {
-					filterText = filterText[:len(filterText)-1]
-					filteredIndices = filterItems(items, filterText)
-					selected = 0
-					render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filterText, filteredIndices, prevFilteredCount)
-					prevFilteredCount = len(filteredIndices)
-					if prevFilteredCount == 0 {
-						prevFilteredCount = 1 // for "(no matches)" line
-					}
+			case k.kind == keyUp, k.kind == keyDown: // Arrow keys in filter mode
+				displayCount := len(filteredIndices)
+				if displayCount == 0 {
+					continue
 				}
-				continue
-
-			case buf[0] >= 32 && buf[0] < 127: // Printable ASCII
-				filterText += string(buf[0])
-				filteredIndices = filterItems(items, filterText)
-				selected = 0
-				render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filterText, filteredIndices, prevFilteredCount)
-				prevFilteredCount = len(filteredIndices)
-				if prevFilteredCount == 0 {
-					prevFilteredCount = 1 // for "(no matches)" line
+				if k.kind == keyUp && selected > 0 {
+					selected--
+					render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filter.display(), filteredIndices, prevFilteredCount, help)
+				}
+				if k.kind == keyDown && selected < displayCount-1 {
+					selected++
+					render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filter.display(), filteredIndices, prevFilteredCount, help)
 				}
 				continue
 
-			case n == 3 && buf[0] == 27 && buf[1] == 91: // Arrow keys in filter mode
-				displayCount := len(filteredIndices)
-				if displayCount == 0 {
+			default: // Editing keys go to the filter line
+				if !filter.handle(k) {
 					continue
 				}
-				switch buf[2] {
-				case 65: // Up
-					if selected > 0 {
-						selected--
-						render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filterText, filteredIndices, prevFilteredCount)
-					}
-				case 66: // Down
-					if selected < displayCount-1 {
-						selected++
-						render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filterText, filteredIndices, prevFilteredCount)
-					}
+				if filter.String() != filterText {
+					filterText = filter.String()
+					filteredIndices = filterItems(items, filterText)
+					selected = 0
+				}
+				render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filter.display(), filteredIndices, prevFilteredCount, help)
+				prevFilteredCount = len(filteredIndices)
+				if prevFilteredCount == 0 {
+					prevFilteredCount = 1 // for "(no matches)" line
 				}
 				continue
 			}
-			continue
 		}
 
 		// Handle normal mode input
 		switch {
-		case buf[0] == 'q', buf[0] == 27 && n == 1: // q or Esc
+		case k.is('q'), k.kind == keyEsc: // q or Esc
 			clearLines(prevFilteredCount + 2)
 			return PickResult{Action: ActionCancel}
 
-		case buf[0] == 3: // Ctrl+C
+		case k.kind == keyCtrlC: // Ctrl+C
 			clearLines(prevFilteredCount + 2)
 			return PickResult{Action: ActionCancel}
 
-		case buf[0] == '/': // Enter filter mode
+		case k.is('/'): // Enter filter mode
 			filterMode = true
 			filterText = ""
+			filter = newLineEditor("")
 			filteredIndices = filterItems(items, "")
-			render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filterText, filteredIndices, prevFilteredCount)
+			render(items, selected, maxNameLen, maxTagLen, prompt, "", false, "", filteredIndices, prevFilteredCount, help)
 			prevFilteredCount = len(items)
 			continue
 
-		case buf[0] == 'x', buf[0] == 'X': // x - delete
+		case !opts.SelectOnly && k.is('x', 'X'): // x - delete
 			confirmDelete = true
 			actualIdx := selected
 			if filteredIndices != nil && len(filteredIndices) > 0 {
 				actualIdx = filteredIndices[selected]
 			}
-			render(items, selected, maxNameLen, maxTagLen, prompt, fmt.Sprintf("Delete '%s'? (y/n)", items[actualIdx].Name), false, filterText, filteredIndices, prevFilteredCount)
+			render(items, selected, maxNameLen, maxTagLen, prompt, fmt.Sprintf("Delete '%s'? (y/n)", items[actualIdx].Name), false, filter.display(), filteredIndices, prevFilteredCount, help)
 
-		case buf[0] == 'm', buf[0] == 'M': // m - modify
+		case !opts.SelectOnly && k.is('m', 'M'): // m - modify
 			actualIdx := selected
 			if filteredIndices != nil && len(filteredIndices) > 0 {
 				actualIdx = filteredIndices[selected]
@@ -299,14 +317,14 @@ func Pick(items []Item, prompt string) PickResult {
 			// Prompt for new name
 			newName, cancelled := PromptInput("Name: ", item.Name)
 			if cancelled {
-				render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filterText, filteredIndices, prevFilteredCount)
+				render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filter.display(), filteredIndices, prevFilteredCount, help)
 				continue
 			}
 
 			// Prompt for new command
 			newCmd, cancelled := PromptInput("Command: ", item.Command)
 			if cancelled {
-				render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filterText, filteredIndices, prevFilteredCount)
+				render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filter.display(), filteredIndices, prevFilteredCount, help)
 				continue
 			}
 
@@ -314,7 +332,7 @@ func Pick(items []Item, prompt string) PickResult {
 			currentTags := strings.Join(item.Tags, ",")
 			newTags, cancelled := PromptInput("Tags: ", currentTags)
 			if cancelled {
-				render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filterText, filteredIndices, prevFilteredCount)
+				render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filter.display(), filteredIndices, prevFilteredCount, help)
 				continue
 			}
 
@@ -326,12 +344,34 @@ func Pick(items []Item, prompt string) PickResult {
 				NewTags:    newTags,
 			}
 
-		case buf[0] == 'e', buf[0] == 'E': // e - extra args
+		case !opts.SelectOnly && k.is('v', 'V'): // v - open in $EDITOR
+			clearLines(prevFilteredCount + 2)
+			actualIdx := selected
+			if filteredIndices != nil && len(filteredIndices) > 0 {
+				actualIdx = filteredIndices[selected]
+			}
+			return PickResult{
+				Action: ActionEdit,
+				Value:  items[actualIdx].Name,
+			}
+
+		case !opts.SelectOnly && k.is('b', 'B'): // b - rebuild with the interactive builder
+			clearLines(prevFilteredCount + 2)
+			actualIdx := selected
+			if filteredIndices != nil && len(filteredIndices) > 0 {
+				actualIdx = filteredIndices[selected]
+			}
+			return PickResult{
+				Action: ActionRebuild,
+				Value:  items[actualIdx].Name,
+			}
+
+		case !opts.SelectOnly && k.is('e', 'E'): // e - extra args
 			clearLines(prevFilteredCount + 2)
 			extra, cancelled := PromptInput("Extra arguments: ", "")
 			if cancelled {
 				// User cancelled extra input, go back to picker
-				render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filterText, filteredIndices, prevFilteredCount)
+				render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filter.display(), filteredIndices, prevFilteredCount, help)
 				continue
 			}
 			actualIdx := selected
@@ -344,7 +384,7 @@ func Pick(items []Item, prompt string) PickResult {
 				Extra:  extra,
 			}
 
-		case buf[0] == 13 || buf[0] == 10: // Enter
+		case k.kind == keyEnter: // Enter
 			clearLines(prevFilteredCount + 2)
 			actualIdx := selected
 			if filteredIndices != nil && len(filteredIndices) > 0 {
@@ -355,38 +395,20 @@ func Pick(items []Item, prompt string) PickResult {
 				Value:  items[actualIdx].Name,
 			}
 
-		case buf[0] == 'k', buf[0] == 'K': // k - up
+		case k.is('k', 'K'), k.kind == keyUp: // k or Up
 			if selected > 0 {
 				selected--
-				render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filterText, filteredIndices, prevFilteredCount)
+				render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filter.display(), filteredIndices, prevFilteredCount, help)
 			}
 
-		case buf[0] == 'j', buf[0] == 'J': // j - down
+		case k.is('j', 'J'), k.kind == keyDown: // j or Down
 			displayCount := len(items)
 			if filteredIndices != nil {
 				displayCount = len(filteredIndices)
 			}
 			if selected < displayCount-1 {
 				selected++
-				render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filterText, filteredIndices, prevFilteredCount)
-			}
-
-		case n == 3 && buf[0] == 27 && buf[1] == 91: // Arrow keys
-			displayCount := len(items)
-			if filteredIndices != nil {
-				displayCount = len(filteredIndices)
-			}
-			switch buf[2] {
-			case 65: // Up
-				if selected > 0 {
-					selected--
-					render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filterText, filteredIndices, prevFilteredCount)
-				}
-			case 66: // Down
-				if selected < displayCount-1 {
-					selected++
-					render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filterText, filteredIndices, prevFilteredCount)
-				}
+				render(items, selected, maxNameLen, maxTagLen, prompt, "", false, filter.display(), filteredIndices, prevFilteredCount, help)
 			}
 		}
 	}
@@ -401,15 +423,29 @@ func getTerminalWidth() int {
 	return width
 }
 
-// truncateString truncates a string to maxLen, adding "..." if truncated
+// truncateString truncates a string to maxLen columns, adding "..." if truncated
+// Counts display width so multi-byte and wide characters are never split
 func truncateString(s string, maxLen int) string {
+	if stringWidth(s) <= maxLen {
+		return s
+	}
+	limit := maxLen - 3
+	suffix := "..."
 	if maxLen <= 3 {
-		return s[:min(len(s), maxLen)]
+		limit = maxLen
+		suffix = ""
 	}
-	if len(s) <= maxLen {
-		return s
+	used := 0
+	var sb strings.Builder
+	for _, r := range s {
+		w := runeWidth(r)
+		if used+w > limit {
+			break
+		}
+		used += w
+		sb.WriteRune(r)
 	}
-	return s[:maxLen-3] + "..."
+	return sb.String() + suffix
 }
 
 // render draws the picker UI
@@ -418,7 +454,8 @@ func truncateString(s string, maxLen int) string {
 // filterText is the current filter (empty if not filtering)
 // filteredIndices contains indices into items of matching items (nil means show all)
 // totalItems is the total count of items (for clearing correct number of lines when filtered)
-func render(items []Item, selected int, maxNameLen int, maxTagLen int, prompt string, confirmMsg string, firstRender bool, filterText string, filteredIndices []int, totalItems int) {
+// help is the key help shown when not filtering or confirming
+func render(items []Item, selected int, maxNameLen int, maxTagLen int, prompt string, confirmMsg string, firstRender bool, filterText string, filteredIndices []int, totalItems int, help string) {
 	// Determine how many lines to clear
 	// When filtering, we need to clear based on what was previously rendered
 	linesToClear := totalItems + 2
@@ -463,9 +500,9 @@ func render(items []Item, selected int, maxNameLen int, maxTagLen int, prompt st
 			tagStr := formatTagsDisplay(item.Tags)
 			cmdDisplay := truncateString(item.Command, maxCmdWidth)
 			if i == selected {
-				fmt.Printf("  \033[7m> %-*s  %-*s  %s\033[0m\r\n", maxNameLen, item.Name, maxTagLen, tagStr, cmdDisplay)
+				fmt.Printf("  \033[7m> %-*s  %-*s  %s\033[0m\r\n", maxNameLen, item.label(), maxTagLen, tagStr, cmdDisplay)
 			} else {
-				fmt.Printf("    %-*s  %-*s  %s\r\n", maxNameLen, item.Name, maxTagLen, tagStr, cmdDisplay)
+				fmt.Printf("    %-*s  %-*s  %s\r\n", maxNameLen, item.label(), maxTagLen, tagStr, cmdDisplay)
 			}
 		}
 	}
@@ -481,7 +518,7 @@ func render(items []Item, selected int, maxNameLen int, maxTagLen int, prompt st
 	} else if filterText != "" {
 		fmt.Printf("\033[2m  [↑/↓] navigate  [Enter] select  [Esc] clear filter  [Ctrl+C] cancel\033[0m")
 	} else {
-		fmt.Printf("\033[2m  [↑/↓/j/k] navigate  [Enter] select  [/] filter  [e] extra  [m] modify  [x] delete  [q] cancel\033[0m")
+		fmt.Printf("\033[2m  %s\033[0m", help)
 	}
 }
 
@@ -499,9 +536,34 @@ func clearLines(n int) {
 // PickString displays an interactive picker for a list of strings
 // Returns PickResult with action (Cancel, Select, Skip, or Custom)
 func PickString(items []string, prompt string, optional bool, allowCustom bool) PickResult {
+	return PickStringWith(items, prompt, StringOptions{Optional: optional, AllowCustom: allowCustom})
+}
+
+// StringOptions configures PickStringWith
+type StringOptions struct {
+	Optional     bool     // Offer [Skip]
+	AllowCustom  bool     // Offer typing a value
+	CustomLabel  string   // Label of the custom entry, "[Custom]" if empty
+	CustomPrompt string   // Prompt for the custom value, the picker prompt if empty
+	Hints        []string // Shown dimmed after the item at the same index, and matched by the filter
+	Default      string   // Item the cursor starts on, if listed
+}
+
+// PickStringWith is PickString with more options
+func PickStringWith(items []string, prompt string, opts StringOptions) PickResult {
+	optional, allowCustom := opts.Optional, opts.AllowCustom
+	customLabel := opts.CustomLabel
+	if customLabel == "" {
+		customLabel = "[Custom]"
+	}
+	customPrompt := opts.CustomPrompt
+	if customPrompt == "" {
+		customPrompt = prompt + " "
+	}
+
 	// If allowCustom with no predefined values, go straight to input
 	if allowCustom && len(items) == 0 {
-		value, cancelled := PromptInput(prompt+" ", "")
+		value, cancelled := PromptInput(customPrompt, "")
 		if cancelled {
 			if optional {
 				return PickResult{Action: ActionSkip}
@@ -524,10 +586,30 @@ func PickString(items []string, prompt string, optional bool, allowCustom bool)
 		skipOffset = 1
 	}
 
-	displayItems = append(displayItems, items...)
+	// Labels are cut to the terminal width, since wrapped lines break redrawing
+	labels := make([]string, len(items))
+	for i, item := range items {
+		labels[i] = truncateString(item, getTerminalWidth()-6)
+	}
+	displayItems = append(displayItems, withHints(labels, opts.Hints)...)
+
+	if allowCustom {
+		displayItems = append(displayItems, customLabel)
+	}
 
+	// The filter matches the whole item and its hint, even where they're cut short
+	searchItems := make([]string, 0, len(displayItems))
+	if optional {
+		searchItems = append(searchItems, "[Skip]")
+	}
+	for i, item := range items {
+		if i < len(opts.Hints) && opts.Hints[i] != "" {
+			item += "  " + opts.Hints[i]
+		}
+		searchItems = append(searchItems, item)
+	}
 	if allowCustom {
-		displayItems = append(displayItems, "[Custom]")
+		searchItems = append(searchItems, customLabel)
 	}
 
 	// Get terminal file descriptor
@@ -547,274 +629,280 @@ func PickString(items []string, prompt string, optional bool, allowCustom bool)
 	}
 	defer term.Restore(fd, oldState)
 
+	enableBracketedPaste()
+	defer disableBracketedPaste()
+
 	selected := 0
+	for i, item := range items {
+		if opts.Default != "" && item == opts.Default {
+			selected = i + skipOffset
+			break
+		}
+	}
+
+	// Long lists scroll within a window that fits the terminal
+	window := max(min(15, getTerminalHeight()-4), 3)
+	offset, drawn := 0, 0
 
 	// Filter state
 	filterMode := false
 	filterText := ""
-	var filteredIndices []int
-	prevFilteredCount := len(displayItems)
+	filter := newLineEditor("")
+	var filteredIndices []int // Indices into displayItems, nil when not filtering
+
+	// shown returns how many rows are listed
+	shown := func() int {
+		if filteredIndices != nil {
+			return len(filteredIndices)
+		}
+		return len(displayItems)
+	}
+
+	draw := func() {
+		// Keep the cursor inside the window
+		if selected < offset {
+			offset = selected
+		} else if selected >= offset+window {
+			offset = selected - window + 1
+		}
+		clearLines(drawn)
+		drawn = renderStrings(displayItems, selected, offset, window, prompt, optional, allowCustom, filterMode, filter.display(), filteredIndices)
+	}
+
+	// pick returns the result for a row of displayItems, or false if
+	// custom input was cancelled and the picker goes on
+	pick := func(idx int) (PickResult, bool) {
+		clearLines(drawn)
+		drawn = 0
+		if optional && idx == 0 {
+			return PickResult{Action: ActionSkip}, true
+		}
+		if allowCustom && idx == len(displayItems)-1 {
+			value, cancelled := PromptInput(customPrompt, "")
+			if cancelled {
+				return PickResult{}, false
+			}
+			return PickResult{Action: ActionCustom, Value: value}, true
+		}
+		return PickResult{Action: ActionSelect, Value: items[idx-skipOffset]}, true
+	}
 
 	// Initial render
-	renderStrings(displayItems, selected, prompt, optional, allowCustom, true, "", nil, len(displayItems))
+	draw()
 
 	// Input loop
-	buf := make([]byte, 3)
+	keys := newKeyReader(os.Stdin)
 	for {
-		n, err := os.Stdin.Read(buf)
+		k, err := keys.readKey()
 		if err != nil {
 			return PickResult{Action: ActionCancel}
 		}
 
-		if n == 0 {
-			continue
-		}
-
 		// Handle filter mode input
 		if filterMode {
 			switch {
-			case buf[0] == 27 && n == 1: // Esc - clear filter and exit filter mode
+			case k.kind == keyEsc: // Esc - clear filter and exit filter mode
 				filterMode = false
 				filterText = ""
+				filter = newLineEditor("")
 				filteredIndices = nil
 				selected = 0
-				renderStrings(displayItems, selected, prompt, optional, allowCustom, false, "", nil, prevFilteredCount+1)
-				prevFilteredCount = len(displayItems)
-				continue
 
-			case buf[0] == 3: // Ctrl+C - cancel picker entirely
-				clearLines(prevFilteredCount + 3)
+			case k.kind == keyCtrlC: // Ctrl+C - cancel picker entirely
+				clearLines(drawn)
 				return PickResult{Action: ActionCancel}
 
-			case buf[0] == 13 || buf[0] == 10: // Enter - select current item
-				if filteredIndices != nil && len(filteredIndices) > 0 {
-					clearLines(len(filteredIndices) + 3)
-					actualIdx := filteredIndices[selected]
-					// Check if [Skip] was selected
-					if optional && actualIdx == 0 {
-						return PickResult{Action: ActionSkip}
-					}
-					// Check if [Custom] was selected
-					if allowCustom && actualIdx == len(displayItems)-1 {
-						value, cancelled := PromptInput(prompt+" ", "")
-						if cancelled {
-							renderStrings(displayItems, selected, prompt, optional, allowCustom, false, filterText, filteredIndices, prevFilteredCount)
-							continue
-						}
-						return PickResult{Action: ActionCustom, Value: value}
-					}
-					// Return the actual item
-					itemIdx := actualIdx - skipOffset
-					return PickResult{
-						Action: ActionSelect,
-						Value:  items[itemIdx],
-					}
+			case k.kind == keyEnter: // Enter - select current item
+				if shown() == 0 {
+					continue // No matches
+				}
+				if result, ok := pick(filteredIndices[selected]); ok {
+					return result
 				}
-				continue
 
-			case buf[0] == 127: // Backspace
-				if len(filterText) > 0 {
-					filterText = filterText[:len(filterText)-1]
-					filteredIndices = filterStrings(displayItems, filterText)
-					selected = 0
-					renderStrings(displayItems, selected, prompt, optional, allowCustom, false, filterText, filteredIndices, prevFilteredCount)
-					prevFilteredCount = len(filteredIndices)
-					if prevFilteredCount == 0 {
-						prevFilteredCount = 1
-					}
+			case k.kind == keyUp: // Arrow keys
+				if selected > 0 {
+					selected--
 				}
-				continue
 
-			case buf[0] >= 32 && buf[0] < 127: // Printable ASCII
-				filterText += string(buf[0])
-				filteredIndices = filterStrings(displayItems, filterText)
-				selected = 0
-				renderStrings(displayItems, selected, prompt, optional, allowCustom, false, filterText, filteredIndices, prevFilteredCount)
-				prevFilteredCount = len(filteredIndices)
-				if prevFilteredCount == 0 {
-					prevFilteredCount = 1
+			case k.kind == keyDown:
+				if selected < shown()-1 {
+					selected++
 				}
-				continue
 
-			case n == 3 && buf[0] == 27 && buf[1] == 91: // Arrow keys
-				displayCount := len(filteredIndices)
-				if displayCount == 0 {
+			case k.kind == keyPageUp:
+				selected = max(selected-window, 0)
+
+			case k.kind == keyPageDown:
+				selected = max(min(selected+window, shown()-1), 0)
+
+			default: // Editing keys go to the filter line
+				if !filter.handle(k) {
 					continue
 				}
-				switch buf[2] {
-				case 65: // Up
-					if selected > 0 {
-						selected--
-						renderStrings(displayItems, selected, prompt, optional, allowCustom, false, filterText, filteredIndices, prevFilteredCount)
-					}
-				case 66: // Down
-					if selected < displayCount-1 {
-						selected++
-						renderStrings(displayItems, selected, prompt, optional, allowCustom, false, filterText, filteredIndices, prevFilteredCount)
-					}
+				if filter.String() != filterText {
+					filterText = filter.String()
+					filteredIndices = filterStrings(searchItems, filterText)
+					selected = 0
 				}
-				continue
 			}
+			draw()
 			continue
 		}
 
 		// Handle normal mode input
 		switch {
-		case buf[0] == 'q', buf[0] == 27 && n == 1: // q or Esc
-			clearLines(prevFilteredCount + 2)
+		case k.is('q'), k.kind == keyEsc, k.kind == keyCtrlC: // q, Esc or Ctrl+C
+			clearLines(drawn)
 			return PickResult{Action: ActionCancel}
 
-		case buf[0] == 3: // Ctrl+C
-			clearLines(prevFilteredCount + 2)
-			return PickResult{Action: ActionCancel}
-
-		case buf[0] == '/': // Enter filter mode
+		case k.is('/'): // Enter filter mode
 			filterMode = true
 			filterText = ""
-			filteredIndices = filterStrings(displayItems, "")
-			renderStrings(displayItems, selected, prompt, optional, allowCustom, false, filterText, filteredIndices, prevFilteredCount)
-			prevFilteredCount = len(displayItems)
-			continue
+			filter = newLineEditor("")
+			filteredIndices = filterStrings(searchItems, "")
 
-		case buf[0] == 's', buf[0] == 'S': // s - skip (only for optional)
-			if optional {
-				clearLines(prevFilteredCount + 2)
-				return PickResult{Action: ActionSkip}
-			}
+		case k.is('s', 'S') && optional: // s - skip
+			clearLines(drawn)
+			return PickResult{Action: ActionSkip}
 
-		case buf[0] == 'c', buf[0] == 'C': // c - custom input (only if allowCustom)
-			if allowCustom {
-				clearLines(prevFilteredCount + 2)
-				value, cancelled := PromptInput(prompt+" ", "")
-				if cancelled {
-					// User cancelled, go back to picker
-					renderStrings(displayItems, selected, prompt, optional, allowCustom, false, "", nil, len(displayItems))
-					continue
-				}
-				return PickResult{Action: ActionCustom, Value: value}
+		case k.is('c', 'C') && allowCustom: // c - custom input
+			if result, ok := pick(len(displayItems) - 1); ok {
+				return result
 			}
 
-		case buf[0] == 13 || buf[0] == 10: // Enter
-			clearLines(prevFilteredCount + 2)
-			// Check if [Skip] was selected
-			if optional && selected == 0 {
-				return PickResult{Action: ActionSkip}
-			}
-			// Check if [Custom] was selected
-			if allowCustom && selected == len(displayItems)-1 {
-				value, cancelled := PromptInput(prompt+" ", "")
-				if cancelled {
-					// User cancelled, go back to picker
-					renderStrings(displayItems, selected, prompt, optional, allowCustom, false, "", nil, len(displayItems))
-					continue
-				}
-				return PickResult{Action: ActionCustom, Value: value}
-			}
-			// Return the actual item (accounting for skip offset)
-			actualIndex := selected - skipOffset
-			return PickResult{
-				Action: ActionSelect,
-				Value:  items[actualIndex],
+		case k.kind == keyEnter: // Enter
+			if result, ok := pick(selected); ok {
+				return result
 			}
 
-		case buf[0] == 'k', buf[0] == 'K': // k - up
+		case k.is('k', 'K'), k.kind == keyUp: // k or Up
 			if selected > 0 {
 				selected--
-				renderStrings(displayItems, selected, prompt, optional, allowCustom, false, "", nil, prevFilteredCount)
 			}
 
-		case buf[0] == 'j', buf[0] == 'J': // j - down
+		case k.is('j', 'J'), k.kind == keyDown: // j or Down
 			if selected < len(displayItems)-1 {
 				selected++
-				renderStrings(displayItems, selected, prompt, optional, allowCustom, false, "", nil, prevFilteredCount)
 			}
 
-		case n == 3 && buf[0] == 27 && buf[1] == 91: // Arrow keys
-			switch buf[2] {
-			case 65: // Up
-				if selected > 0 {
-					selected--
-					renderStrings(displayItems, selected, prompt, optional, allowCustom, false, "", nil, prevFilteredCount)
-				}
-			case 66: // Down
-				if selected < len(displayItems)-1 {
-					selected++
-					renderStrings(displayItems, selected, prompt, optional, allowCustom, false, "", nil, prevFilteredCount)
-				}
-			}
+		case k.kind == keyPageUp: // Page Up
+			selected = max(selected-window, 0)
+
+		case k.kind == keyPageDown: // Page Down
+			selected = min(selected+window, len(displayItems)-1)
+
+		case k.is('g'), k.kind == keyHome: // g or Home
+			selected = 0
+
+		case k.is('G'), k.kind == keyEnd: // G or End
+			selected = len(displayItems) - 1
+
+		default:
+			continue
 		}
+		draw()
 	}
 }
 
-// renderStrings draws the picker UI for string items
-// firstRender should be true on the initial render to skip clearing non-existent lines
-// filterText is the current filter (empty if not filtering)
-// filteredIndices contains indices into items of matching items (nil means show all)
-// totalItems is the total count for clearing
-func renderStrings(items []string, selected int, prompt string, optional bool, allowCustom bool, firstRender bool, filterText string, filteredIndices []int, totalItems int) {
-	// Determine how many lines to clear
-	linesToClear := totalItems + 2
-	if filterText != "" {
-		linesToClear = totalItems + 3 // +1 for filter line
-	}
+// hintStart and hintEnd dim a hint without ending the highlight of the selected row
+const (
+	hintStart = "\033[2m"
+	hintEnd   = "\033[22m"
+)
 
-	// Move cursor to start and clear (skip on first render - nothing to clear yet)
-	if !firstRender {
-		clearLines(linesToClear)
+// withHints returns items with their hints aligned after them
+func withHints(items, hints []string) []string {
+	if len(hints) == 0 {
+		return items
 	}
+	width := 0
+	for _, item := range items {
+		width = max(width, stringWidth(item))
+	}
+	// Hints are cut short rather than wrapping, which would break redrawing
+	room := getTerminalWidth() - width - 8
+	labels := make([]string, len(items))
+	for i, item := range items {
+		labels[i] = item
+		if i < len(hints) && hints[i] != "" && room >= 5 {
+			labels[i] += strings.Repeat(" ", width-stringWidth(item)+2) + hintStart + truncateString(hints[i], room) + hintEnd
+		}
+	}
+	return labels
+}
 
-	// Print prompt
-	fmt.Printf("%s\r\n", prompt)
-
+// renderStrings draws the visible window of a string picker and returns
+// the number of lines drawn
+// filteredIndices contains indices into items of matching items (nil means show all)
+func renderStrings(items []string, selected, offset, window int, prompt string, optional, allowCustom, filterMode bool, filterText string, filteredIndices []int) int {
 	// Determine which items to display
-	var displayIndices []int
-	if filteredIndices != nil {
-		displayIndices = filteredIndices
-	} else {
+	displayIndices := filteredIndices
+	if displayIndices == nil {
 		displayIndices = make([]int, len(items))
 		for i := range items {
 			displayIndices[i] = i
 		}
 	}
 
+	lines := 0
+	position := ""
+	if len(displayIndices) > window {
+		position = fmt.Sprintf(" \033[2m(%d/%d)\033[0m", selected+1, len(displayIndices))
+	}
+	fmt.Printf("%s%s\r\n", prompt, position)
+	lines++
+
 	// Print items
 	if len(displayIndices) == 0 {
 		fmt.Printf("  \033[2m(no matches)\033[0m\r\n")
-	} else {
-		for i, idx := range displayIndices {
-			item := items[idx]
-			if i == selected {
-				fmt.Printf("  \033[7m> %s\033[0m\r\n", item)
-			} else {
-				fmt.Printf("    %s\r\n", item)
-			}
+		lines++
+	}
+	for i := offset; i < offset+window && i < len(displayIndices); i++ {
+		item := items[displayIndices[i]]
+		if i == selected {
+			fmt.Printf("  \033[7m> %s\033[0m\r\n", item)
+		} else {
+			fmt.Printf("    %s\r\n", item)
 		}
+		lines++
 	}
 
 	// Print filter line if filtering
-	if filterText != "" {
+	if filterMode {
 		fmt.Printf("  \033[36m/%s\033[0m\r\n", filterText) // Cyan color for filter
+		lines++
 	}
 
 	// Build help line based on available options
-	if filterText != "" {
+	if filterMode {
 		fmt.Printf("\033[2m  [↑/↓] navigate  [Enter] select  [Esc] clear filter  [Ctrl+C] cancel\033[0m")
-	} else {
-		helpParts := []string{"[↑/↓/j/k] navigate", "[Enter] select", "[/] filter"}
-		if allowCustom {
-			helpParts = append(helpParts, "[c] custom")
-		}
-		if optional {
-			helpParts = append(helpParts, "[s] skip")
-		}
-		helpParts = append(helpParts, "[q/Esc] cancel")
-		fmt.Printf("\033[2m  %s\033[0m", strings.Join(helpParts, "  "))
+		return lines + 1
+	}
+	helpParts := []string{"[↑/↓/j/k] navigate"}
+	if len(displayIndices) > window {
+		helpParts = append(helpParts, "[g/G] first/last")
 	}
+	helpParts = append(helpParts, "[Enter] select", "[/] filter")
+	if allowCustom {
+		helpParts = append(helpParts, "[c] custom")
+	}
+	if optional {
+		helpParts = append(helpParts, "[s] skip")
+	}
+	helpParts = append(helpParts, "[q/Esc] cancel")
+	fmt.Printf("\033[2m  %s\033[0m", strings.Join(helpParts, "  "))
+	return lines + 1
 }
 
 // PickOption displays a simple picker for a list of options and returns the selected index
 // Returns -1 if cancelled
 func PickOption(prompt string, options []string) int {
+	return PickOptionDefault(prompt, options, 0)
+}
+
+// PickOptionDefault is PickOption with the cursor starting on option selected
+func PickOptionDefault(prompt string, options []string, selected int) int {
 	if len(options) == 0 {
 		return -1
 	}
@@ -835,56 +923,40 @@ func PickOption(prompt string, options []string) int {
 	// Flush any pending input from previous prompts
 	flushStdin(fd)
 
-	selected := 0
+	if selected < 0 || selected >= len(options) {
+		selected = 0
+	}
 
 	// Initial render
 	renderOptions(options, selected, prompt, true)
 
-	buf := make([]byte, 3)
+	keys := newKeyReader(os.Stdin)
 	for {
-		n, err := os.Stdin.Read(buf)
+		k, err := keys.readKey()
 		if err != nil {
 			return -1
 		}
 
-		if n == 0 {
-			continue
-		}
-
 		switch {
-		case buf[0] == 'q', buf[0] == 27 && n == 1, buf[0] == 3: // q, Esc, Ctrl+C
+		case k.is('q'), k.kind == keyEsc, k.kind == keyCtrlC: // q, Esc, Ctrl+C
 			clearLines(len(options) + 2)
 			return -1
 
-		case buf[0] == 13 || buf[0] == 10: // Enter
+		case k.kind == keyEnter: // Enter
 			clearLines(len(options) + 2)
 			return selected
 
-		case buf[0] == 'k', buf[0] == 'K': // k - up
+		case k.is('k', 'K'), k.kind == keyUp: // k or Up
 			if selected > 0 {
 				selected--
 				renderOptions(options, selected, prompt, false)
 			}
 
-		case buf[0] == 'j', buf[0] == 'J': // j - down
+		case k.is('j', 'J'), k.kind == keyDown: // j or Down
 			if selected < len(options)-1 {
 				selected++
 				renderOptions(options, selected, prompt, false)
 			}
-
-		case n == 3 && buf[0] == 27 && buf[1] == 91: // Arrow keys
-			switch buf[2] {
-			case 65: // Up
-				if selected > 0 {
-					selected--
-					renderOptions(options, selected, prompt, false)
-				}
-			case 66: // Down
-				if selected < len(options)-1 {
-					selected++
-					renderOptions(options, selected, prompt, false)
-				}
-			}
 		}
 	}
 }
@@ -969,18 +1041,68 @@ func PromptYesNo(prompt string) (bool, bool) {
 	}
 }
 
-// PromptInput displays an inline input prompt with optional default value
-// Returns (value, cancelled) where cancelled is true if user pressed Esc/Ctrl+C
-func PromptInput(prompt string, defaultValue string) (string, bool) {
+// PromptYesNoDefault asks a yes/no question where Enter accepts def
+// Returns (answer, ok) where ok is false if cancelled
+func PromptYesNoDefault(prompt string, def bool) (bool, bool) {
 	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		fmt.Fprintln(os.Stderr, "Cannot show prompt: not a terminal")
+		return false, false
+	}
 
-	// Check if we're in a terminal
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to enable raw mode: %v\n", err)
+		return false, false
+	}
+	defer term.Restore(fd, oldState)
+
+	if def {
+		fmt.Printf("%s (Y/n): ", prompt)
+	} else {
+		fmt.Printf("%s (y/N): ", prompt)
+	}
+
+	buf := make([]byte, 1)
+	for {
+		_, err := os.Stdin.Read(buf)
+		if err != nil {
+			fmt.Print("\r\n")
+			return false, false
+		}
+
+		answer := def
+		switch buf[0] {
+		case 'y', 'Y':
+			answer = true
+		case 'n', 'N':
+			answer = false
+		case '\r', '\n': // Enter
+		case 27, 3: // Esc, Ctrl+C
+			fmt.Print("\r\n")
+			return false, false
+		default:
+			continue
+		}
+
+		if answer {
+			fmt.Print("yes\r\n")
+		} else {
+			fmt.Print("no\r\n")
+		}
+		return answer, true
+	}
+}
+
+// PromptSecret reads a line with echo disabled, for passwords and tokens
+// Returns (value, cancelled) like PromptInput.
+func PromptSecret(prompt string) (string, bool) {
+	fd := int(os.Stdin.Fd())
 	if !term.IsTerminal(fd) {
 		fmt.Fprintln(os.Stderr, "Cannot show input prompt: not a terminal")
 		return "", true
 	}
 
-	// Save terminal state and enable raw mode
 	oldState, err := term.MakeRaw(fd)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to enable raw mode: %v\n", err)
@@ -988,49 +1110,98 @@ func PromptInput(prompt string, defaultValue string) (string, bool) {
 	}
 	defer term.Restore(fd, oldState)
 
-	// Initialize with default value
-	input := []rune(defaultValue)
+	enableBracketedPaste()
+	defer disableBracketedPaste()
 
-	// Display prompt with default value
-	fmt.Printf("%s%s", prompt, defaultValue)
-
-	buf := make([]byte, 3)
+	// Edits work as usual but nothing is drawn after the prompt
+	editor := newLineEditor("")
+	fmt.Print(prompt)
 
+	keys := newKeyReader(os.Stdin)
 	for {
-		n, err := os.Stdin.Read(buf)
+		k, err := keys.readKey()
 		if err != nil {
-			fmt.Println()
+			fmt.Print("\r\n")
 			return "", true
 		}
 
-		if n == 0 {
-			continue
+		switch k.kind {
+		case keyEsc, keyCtrlC: // Esc, Ctrl+C
+			fmt.Print("\r\n")
+			return "", true
+
+		case keyEnter: // Enter
+			fmt.Print("\r\n")
+			return editor.String(), false
+
+		default:
+			editor.handle(k)
 		}
+	}
+}
 
-		switch {
-		case buf[0] == 27 && n == 1: // Esc
+// PromptInput displays an inline input prompt with optional default value
+// Returns (value, cancelled) where cancelled is true if user pressed Esc/Ctrl+C
+func PromptInput(prompt string, defaultValue string) (string, bool) {
+	return PromptValidated(prompt, defaultValue, nil)
+}
+
+// PromptValidated is PromptInput that only accepts values validate allows
+// On Enter, a value validate rejects shows its error below the prompt and
+// editing continues. A nil validate accepts anything.
+func PromptValidated(prompt string, defaultValue string, validate func(string) error) (string, bool) {
+	fd := int(os.Stdin.Fd())
+
+	// Check if we're in a terminal
+	if !term.IsTerminal(fd) {
+		fmt.Fprintln(os.Stderr, "Cannot show input prompt: not a terminal")
+		return "", true
+	}
+
+	// Save terminal state and enable raw mode
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to enable raw mode: %v\n", err)
+		return "", true
+	}
+	defer term.Restore(fd, oldState)
+
+	enableBracketedPaste()
+	defer disableBracketedPaste()
+
+	// Initialize with default value
+	editor := newLineEditor(defaultValue)
+	width := getTerminalWidth()
+	fmt.Print(editor.render(prompt, width))
+
+	keys := newKeyReader(os.Stdin)
+	for {
+		k, err := keys.readKey()
+		if err != nil {
 			fmt.Print("\r\n")
 			return "", true
+		}
 
-		case buf[0] == 3: // Ctrl+C
+		switch k.kind {
+		case keyEsc, keyCtrlC: // Esc, Ctrl+C
 			fmt.Print("\r\n")
 			return "", true
 
-		case buf[0] == 13 || buf[0] == 10: // Enter
+		case keyEnter: // Enter
 			fmt.Print("\r\n")
-			return string(input), false
-
-		case buf[0] == 127: // Backspace
-			if len(input) > 0 {
-				input = input[:len(input)-1]
-				// Clear line and reprint
-				fmt.Print("\r\033[K")
-				fmt.Printf("%s%s", prompt, string(input))
+			if validate != nil {
+				if err := validate(editor.String()); err != nil {
+					fmt.Printf("\033[31m  %v\033[0m\r\n", err) // Red for the error
+					fmt.Print(editor.render(prompt, width))
+					continue
+				}
 			}
+			return editor.String(), false
 
-		case buf[0] >= 32 && buf[0] < 127: // Printable ASCII
-			input = append(input, rune(buf[0]))
-			fmt.Printf("%c", buf[0])
+		default:
+			if editor.handle(k) {
+				fmt.Print(editor.render(prompt, width))
+			}
 		}
 	}
 }
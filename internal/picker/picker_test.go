@@ -0,0 +1,55 @@
+package picker
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureStdout returns what f prints
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	f()
+	os.Stdout = stdout
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestRenderStringsWindow(t *testing.T) {
+	items := make([]string, 500)
+	for i := range items {
+		items[i] = fmt.Sprintf("item %d", i)
+	}
+
+	var drawn int
+	out := captureStdout(t, func() {
+		drawn = renderStrings(items, 250, 240, 15, "Pick:", false, false, false, "", nil)
+	})
+	// Prompt, the window and the help line, never the whole list
+	if drawn != 17 || strings.Count(out, "\r\n") != drawn-1 {
+		t.Errorf("renderStrings() drew %d lines:\n%s", drawn, out)
+	}
+	if !strings.Contains(out, "(251/500)") || !strings.Contains(out, "> item 250") ||
+		!strings.Contains(out, "item 240") || !strings.Contains(out, "item 254") || strings.Contains(out, "item 255") {
+		t.Errorf("renderStrings() showed the wrong window:\n%s", out)
+	}
+
+	out = captureStdout(t, func() {
+		drawn = renderStrings(items, 0, 0, 15, "Pick:", false, false, true, "zzz", []int{})
+	})
+	if drawn != 4 || !strings.Contains(out, "(no matches)") {
+		t.Errorf("renderStrings() with no matches drew %d lines:\n%s", drawn, out)
+	}
+}
@@ -0,0 +1,276 @@
+package project
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Task is an entry point found in a project file
+type Task struct {
+	Name        string // Task name as written, e.g. "build:prod"
+	Command     string // Command that runs it, e.g. "npm run build:prod"
+	Description string // Doc comment or script body, if any
+	Tool        string // Detector that found it: make, npm, just, python
+}
+
+// detector finds tasks in one kind of project file
+type detector struct {
+	tool  string
+	files []string // Candidate file names, first existing one is used
+	parse func(dir string, data []byte) ([]Task, error)
+}
+
+var detectors = []detector{
+	{"make", []string{"GNUmakefile", "makefile", "Makefile"}, parseMakefile},
+	{"npm", []string{"package.json"}, parsePackageJSON},
+	{"just", []string{"justfile", "Justfile", ".justfile"}, parseJustfile},
+	{"python", []string{"pyproject.toml"}, parsePyproject},
+}
+
+// Detect returns the tasks of every supported project file in dir
+// Files that fail to parse are reported in the error but don't stop the others.
+func Detect(dir string) ([]Task, error) {
+	var tasks []Task
+	var errs []string
+
+	for _, d := range detectors {
+		for _, name := range d.files {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				continue
+			}
+			found, err := d.parse(dir, data)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			}
+			for i := range found {
+				found[i].Tool = d.tool
+			}
+			tasks = append(tasks, found...)
+			break
+		}
+	}
+
+	if len(errs) > 0 {
+		return tasks, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return tasks, nil
+}
+
+// makeTarget matches "target other: deps ## description" rule lines
+var makeTarget = regexp.MustCompile(`^([A-Za-z0-9_][\w./ -]*?)\s*:([^=].*)?$`)
+
+// parseMakefile lists explicit targets, skipping special (.PHONY), pattern
+// (%.o) and variable-like targets. A "## text" after the prerequisites or a
+// comment line just above the rule becomes the description.
+func parseMakefile(_ string, data []byte) ([]Task, error) {
+	var tasks []Task
+	seen := make(map[string]bool)
+	comment := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "#") {
+			comment = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			continue
+		}
+		if line == "" || line[0] == '\t' || line[0] == ' ' {
+			comment = ""
+			continue
+		}
+
+		m := makeTarget.FindStringSubmatch(line)
+		if m == nil || strings.Contains(line, ":=") || strings.HasPrefix(m[2], ":") {
+			comment = ""
+			continue
+		}
+
+		desc := comment
+		if _, doc, ok := strings.Cut(m[2], "##"); ok {
+			desc = strings.TrimSpace(doc)
+		}
+		comment = ""
+
+		for _, target := range strings.Fields(m[1]) {
+			if strings.ContainsAny(target, "%/") || strings.HasSuffix(target, ".o") || seen[target] {
+				continue
+			}
+			seen[target] = true
+			tasks = append(tasks, Task{Name: target, Command: "make " + target, Description: desc})
+		}
+	}
+	return tasks, scanner.Err()
+}
+
+// parsePackageJSON lists "scripts", run with the package manager whose
+// lockfile is present (npm by default)
+func parsePackageJSON(dir string, data []byte) ([]Task, error) {
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+
+	runner := "npm run"
+	for _, lock := range []struct{ file, runner string }{
+		{"pnpm-lock.yaml", "pnpm run"},
+		{"yarn.lock", "yarn run"},
+		{"bun.lockb", "bun run"},
+		{"bun.lock", "bun run"},
+	} {
+		if _, err := os.Stat(filepath.Join(dir, lock.file)); err == nil {
+			runner = lock.runner
+			break
+		}
+	}
+
+	names := make([]string, 0, len(pkg.Scripts))
+	for name := range pkg.Scripts {
+		// Lifecycle hooks run implicitly around other scripts
+		if strings.HasPrefix(name, "pre") || strings.HasPrefix(name, "post") {
+			base := strings.TrimPrefix(strings.TrimPrefix(name, "pre"), "post")
+			if _, ok := pkg.Scripts[base]; ok {
+				continue
+			}
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tasks := make([]Task, 0, len(names))
+	for _, name := range names {
+		tasks = append(tasks, Task{Name: name, Command: runner + " " + name, Description: pkg.Scripts[name]})
+	}
+	return tasks, nil
+}
+
+// justRecipe matches a recipe header: "[@]name param='default' +rest: deps"
+var justRecipe = regexp.MustCompile(`^@?([A-Za-z][\w-]*)((?:\s+[^:=\s]+(?:=(?:'[^']*'|"[^"]*"|[^\s:]+))?)*)\s*:([^=].*)?$`)
+
+// justKeywords start lines that look like recipes but aren't
+var justKeywords = map[string]bool{"set": true, "alias": true, "export": true, "import": true, "mod": true}
+
+// parseJustfile lists public recipes; parameters become bindings:
+// required ones ask for input, ones with a default are optional, and
+// variadic ones (*args optional, +args required) take free input
+func parseJustfile(_ string, data []byte) ([]Task, error) {
+	var tasks []Task
+	comment := ""
+	private := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "#") {
+			comment = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			// Attributes like [private] or [linux] keep the comment
+			private = private || strings.Contains(line, "private")
+			continue
+		}
+		if line == "" || line[0] == '\t' || line[0] == ' ' {
+			comment, private = "", false
+			continue
+		}
+
+		m := justRecipe.FindStringSubmatch(line)
+		if m == nil || justKeywords[m[1]] || private {
+			comment, private = "", false
+			continue
+		}
+
+		command := "just " + m[1]
+		for _, param := range strings.Fields(m[2]) {
+			name, def, hasDefault := strings.Cut(param, "=")
+			name = strings.TrimPrefix(name, "$") // Exported parameter
+			def = strings.Trim(def, `'"`)
+
+			switch {
+			case strings.HasPrefix(name, "*"):
+				command += " {%?[...]%}"
+			case strings.HasPrefix(name, "+"):
+				command += " {%[...]%}"
+			case hasDefault && def != "" && !strings.ContainsAny(def, ",[]%"):
+				command += " {%?[" + def + ",...]%}"
+			case hasDefault:
+				command += " {%?[...]%}"
+			default:
+				command += " {%[...]%}"
+			}
+		}
+
+		tasks = append(tasks, Task{Name: m[1], Command: command, Description: comment})
+		comment = ""
+	}
+	return tasks, scanner.Err()
+}
+
+// pyprojectTable matches a TOML table header
+var pyprojectTable = regexp.MustCompile(`^\[([^\]]+)\]\s*$`)
+
+// pyprojectEntry matches "name = ..." inside a table
+var pyprojectEntry = regexp.MustCompile(`^["']?([\w.-]+)["']?\s*=\s*(.+)$`)
+
+// parsePyproject lists [project.scripts], [tool.poetry.scripts] and
+// [tool.pdm.scripts], run through the project's environment manager
+func parsePyproject(dir string, data []byte) ([]Task, error) {
+	runner := ""
+	for _, lock := range []struct{ file, runner string }{
+		{"uv.lock", "uv run "},
+		{"poetry.lock", "poetry run "},
+		{"pdm.lock", "pdm run "},
+	} {
+		if _, err := os.Stat(filepath.Join(dir, lock.file)); err == nil {
+			runner = lock.runner
+			break
+		}
+	}
+
+	var tasks []Task
+	seen := make(map[string]bool)
+	table := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if m := pyprojectTable.FindStringSubmatch(line); m != nil {
+			table = strings.TrimSpace(m[1])
+			continue
+		}
+
+		m := pyprojectEntry.FindStringSubmatch(line)
+		if m == nil || seen[m[1]] {
+			continue
+		}
+
+		value := strings.Trim(strings.TrimSpace(m[2]), `"'`)
+		switch table {
+		case "project.scripts", "tool.poetry.scripts":
+			// Console scripts: entry point "module:function"
+			tasks = append(tasks, Task{Name: m[1], Command: runner + m[1], Description: value})
+		case "tool.pdm.scripts":
+			// PDM scripts are shell commands run by pdm
+			tasks = append(tasks, Task{Name: m[1], Command: "pdm run " + m[1], Description: value})
+		default:
+			continue
+		}
+		seen[m[1]] = true
+	}
+	return tasks, scanner.Err()
+}
@@ -0,0 +1,126 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseMakefile(t *testing.T) {
+	makefile := `.PHONY: build test
+VERSION := 1.0
+CC = gcc
+
+# Build the binary
+build: deps
+	go build ./...
+
+test lint: ## Run checks
+	go test ./...
+
+%.o: %.c
+	$(CC) -c $<
+
+bin/app: build
+`
+	got, err := parseMakefile("", []byte(makefile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Task{
+		{Name: "build", Command: "make build", Description: "Build the binary"},
+		{Name: "test", Command: "make test", Description: "Run checks"},
+		{Name: "lint", Command: "make lint", Description: "Run checks"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("parseMakefile() =\n%+v\nexpected\n%+v", got, expected)
+	}
+}
+
+func TestParsePackageJSON(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "pnpm-lock.yaml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg := `{"name": "web", "scripts": {"build": "vite build", "prebuild": "rm -rf dist", "dev": "vite", "preview": "vite preview"}}`
+	got, err := parsePackageJSON(dir, []byte(pkg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Task{
+		{Name: "build", Command: "pnpm run build", Description: "vite build"},
+		{Name: "dev", Command: "pnpm run dev", Description: "vite"},
+		{Name: "preview", Command: "pnpm run preview", Description: "vite preview"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("parsePackageJSON() =\n%+v\nexpected\n%+v", got, expected)
+	}
+}
+
+func TestParseJustfile(t *testing.T) {
+	justfile := `set dotenv-load
+alias b := build
+
+# Build everything
+build:
+    cargo build
+
+deploy env target='web' +flags:
+    ./deploy.sh {{env}} {{target}} {{flags}}
+
+[private]
+helper:
+    echo hi
+
+_hidden:
+    echo hidden
+
+test *args: build
+    cargo test {{args}}
+`
+	got, err := parseJustfile("", []byte(justfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Task{
+		{Name: "build", Command: "just build", Description: "Build everything"},
+		{Name: "deploy", Command: "just deploy {%[...]%} {%?[web,...]%} {%[...]%}"},
+		{Name: "test", Command: "just test {%?[...]%}"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("parseJustfile() =\n%+v\nexpected\n%+v", got, expected)
+	}
+}
+
+func TestParsePyproject(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "uv.lock"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pyproject := `[project]
+name = "trainer"
+version = "0.1.0"
+
+[project.scripts]
+train = "trainer.cli:train"
+"eval-model" = "trainer.cli:evaluate"
+
+[tool.pdm.scripts]
+lint = "ruff check ."
+`
+	got, err := parsePyproject(dir, []byte(pyproject))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Task{
+		{Name: "train", Command: "uv run train", Description: "trainer.cli:train"},
+		{Name: "eval-model", Command: "uv run eval-model", Description: "trainer.cli:evaluate"},
+		{Name: "lint", Command: "pdm run lint", Description: "ruff check ."},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("parsePyproject() =\n%+v\nexpected\n%+v", got, expected)
+	}
+}
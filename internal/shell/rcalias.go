@@ -0,0 +1,327 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"laziest/internal/flagparse"
+)
+
+// RCAlias is an alias or one-line function defined in a shell rc file
+type RCAlias struct {
+	Name     string // Name as defined in the rc file
+	Command  string // Command it runs
+	Function bool   // Defined as a function rather than an alias
+	File     string // rc file it was found in
+	Line     int    // 1-based line number in File
+	Shared   bool   // The line does more than define aliases that can be imported
+}
+
+// importMarker is prepended to lines commented out by CommentOutAliases
+const importMarker = "# imported into lz: "
+
+// RCFiles returns the rc files that exist among the usual alias locations
+func RCFiles() ([]string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, name := range []string{".bashrc", ".bash_aliases", ".bash_profile", ".zshrc", ".zsh_aliases", ".aliases"} {
+		path := filepath.Join(home, name)
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
+
+// ReadRCAliases parses the aliases and one-line functions in an rc file
+func ReadRCAliases(path string) ([]RCAlias, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRCAliases(string(data), path), nil
+}
+
+// functionPattern matches one-line functions in either syntax:
+// name() { body; }, function name { body; }, function name() { body }
+var functionPattern = regexp.MustCompile(`^(?:function\s+([A-Za-z_][\w.-]*)\s*(?:\(\s*\))?|([A-Za-z_][\w.-]*)\s*\(\s*\))\s*\{\s*(.+?)\s*;?\s*\}\s*$`)
+
+// positionalPattern matches argument references a saved command can't express
+var positionalPattern = regexp.MustCompile(`\$\{?[0-9#*@]`)
+
+// ParseRCAliases extracts alias definitions and simple one-line functions
+// Functions are only kept when they pass their arguments through at the end
+// ("$@"), since lz appends extra arguments the same way. file is recorded in
+// each result.
+func ParseRCAliases(text, file string) []RCAlias {
+	var aliases []RCAlias
+
+	for i, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "alias ") {
+			// Each statement on the line, e.g. alias a=b; alias c=d
+			var defs []RCAlias
+			shared := false
+			for _, statement := range splitStatements(trimmed) {
+				parsed, complete := parseAliasLine(statement)
+				defs = append(defs, parsed...)
+				shared = shared || !complete
+			}
+			for _, a := range defs {
+				a.File, a.Line, a.Shared = file, i+1, shared
+				aliases = append(aliases, a)
+			}
+			continue
+		}
+
+		m := functionPattern.FindStringSubmatch(trimmed)
+		if m == nil {
+			continue
+		}
+		name := m[1] + m[2]
+		body := strings.TrimSpace(m[3])
+
+		// Trailing "$@" is how lz passes extra arguments anyway
+		for _, suffix := range []string{` "$@"`, ` $@`, ` "${@}"`} {
+			body = strings.TrimSuffix(body, suffix)
+		}
+		if body == "" || positionalPattern.MatchString(body) {
+			continue
+		}
+
+		aliases = append(aliases, RCAlias{Name: name, Command: body, Function: true, File: file, Line: i + 1})
+	}
+
+	return aliases
+}
+
+// parseAliasLine parses one statement, "alias a='x' b=y"
+// zsh global and suffix aliases (-g, -s) aren't commands and are skipped.
+// complete is false when the statement does anything besides defining the
+// aliases returned, so commenting it out would lose something.
+func parseAliasLine(statement string) (aliases []RCAlias, complete bool) {
+	rest, ok := strings.CutPrefix(statement, "alias ")
+	if !ok {
+		return nil, false
+	}
+	complete = true
+	for _, word := range shellWords(rest) {
+		if strings.HasPrefix(word, "-") {
+			if strings.ContainsAny(word, "gs") {
+				return nil, false
+			}
+			continue
+		}
+		name, value, ok := strings.Cut(word, "=")
+		if !ok || name == "" {
+			complete = false // Prints an alias rather than defining one
+			continue
+		}
+		command := strings.TrimSpace(flagparse.Unquote(value))
+		if command == "" || strings.Contains(command, ".config/laziest") || strings.HasPrefix(command, "lz ") {
+			complete = false // Empty, or one of lz's own aliases
+			continue
+		}
+		aliases = append(aliases, RCAlias{Name: name, Command: command})
+	}
+	return aliases, complete
+}
+
+// splitStatements splits a line at unquoted ;, &, &&, | and ||, dropping an
+// unquoted trailing comment and empty statements
+func splitStatements(line string) []string {
+	var statements []string
+	start := 0
+	quote := byte(0)
+	wordStart := true
+	add := func(end int) {
+		if s := strings.TrimSpace(line[start:end]); s != "" {
+			statements = append(statements, s)
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '\\':
+			i++
+		case c == '#' && wordStart:
+			add(i)
+			return statements
+		case c == ';' || c == '&' || c == '|':
+			add(i)
+			if i+1 < len(line) && line[i+1] == c {
+				i++
+			}
+			start = i + 1
+		}
+		wordStart = c == ' ' || c == '\t' || c == ';' || c == '&' || c == '|'
+	}
+	add(len(line))
+	return statements
+}
+
+// shellWords splits a statement into raw words, respecting quotes and
+// stopping at an unquoted comment or command separator
+func shellWords(line string) []string {
+	var words []string
+	var cur strings.Builder
+	inWord := false
+	quote := byte(0)
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			cur.WriteByte(c)
+			if c == '\\' && quote == '"' && i+1 < len(line) {
+				i++
+				cur.WriteByte(line[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+			cur.WriteByte(c)
+		case c == '\\' && i+1 < len(line):
+			inWord = true
+			cur.WriteByte(c)
+			i++
+			cur.WriteByte(line[i])
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		case (c == '#' && !inWord) || c == ';' || c == '&' || c == '|':
+			if inWord {
+				words = append(words, cur.String())
+			}
+			return words
+		default:
+			inWord = true
+			cur.WriteByte(c)
+		}
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words
+}
+
+// ImportName turns an rc alias name into a valid lz name
+// e.g. "k-logs" -> "k_logs", "1up" -> "a_1up"
+func ImportName(name string) string {
+	var sb strings.Builder
+	for _, c := range name {
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' {
+			sb.WriteRune(c)
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+	result := sb.String()
+	if result == "" || !(result[0] >= 'a' && result[0] <= 'z' || result[0] >= 'A' && result[0] <= 'Z' || result[0] == '_') {
+		result = "a_" + result
+	}
+	return result
+}
+
+// ImportedLines works out which rc lines to comment out once the names in
+// imported are saved in lz: every line defining one of them, in any file, so
+// no earlier definition becomes active again. imported maps rc names to the
+// names they were saved under; renamed entries don't count, since commenting
+// them out would leave the rc name undefined. Lines that also do something
+// else are left alone and their definitions of imported names returned as kept.
+func ImportedLines(defs []RCAlias, imported map[string]string) (lines map[string][]int, kept []RCAlias) {
+	isImported := func(name string) bool {
+		return imported[name] == name
+	}
+
+	type location struct {
+		file string
+		line int
+	}
+
+	complete := make(map[location]bool)
+	var order []location
+	for _, a := range defs {
+		loc := location{a.File, a.Line}
+		if _, seen := complete[loc]; !seen {
+			complete[loc] = true
+			order = append(order, loc)
+		}
+		if a.Shared || !isImported(a.Name) {
+			complete[loc] = false
+		}
+	}
+
+	lines = make(map[string][]int)
+	for _, loc := range order {
+		if complete[loc] {
+			lines[loc.file] = append(lines[loc.file], loc.line)
+		}
+	}
+	for _, a := range defs {
+		if isImported(a.Name) && !complete[location{a.File, a.Line}] {
+			kept = append(kept, a)
+		}
+	}
+	return lines, kept
+}
+
+// CommentOutAliases comments out the given lines of an rc file
+// Each line is prefixed with a marker so it is easy to find and restore.
+// Indented lines become ": # imported into lz: ...", so blocks stay valid.
+func CommentOutAliases(path string, lines []int) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	content := strings.Split(string(data), "\n")
+	for _, n := range lines {
+		if n < 1 || n > len(content) {
+			return fmt.Errorf("%s has no line %d", path, n)
+		}
+		line := content[n-1]
+		trimmed := strings.TrimLeft(line, " \t")
+		switch {
+		case strings.HasPrefix(trimmed, importMarker), strings.HasPrefix(trimmed, ": "+importMarker):
+			// Already done
+		case trimmed == line:
+			content[n-1] = importMarker + line
+		default:
+			// Indented lines may be the only statement of an if or function
+			// body, which can't be empty: keep a no-op command in their place
+			content[n-1] = line[:len(line)-len(trimmed)] + ": " + importMarker + trimmed
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(content, "\n")), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to update %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseRCAliases(t *testing.T) {
+	rc := `# aliases
+alias gs='git status'
+alias ll="ls -la" # long listing
+alias -g G='| grep'
+alias k=kubectl kgp='kubectl get pods'
+alias say='echo '\''hi'\'''
+[ -f "$HOME/.config/laziest/aliases.sh" ] && source "$HOME/.config/laziest/aliases.sh"
+alias t='lz run train'
+mkcd() { mkdir -p "$1" && cd "$1"; }
+function dps { docker ps --format '{{.Names}}'; }
+gl() { git log --oneline "$@"; }
+if true; then
+  alias indented='echo in'
+fi
+alias a=b; alias c='d;e' # two statements
+alias x=y && export PATH="$PATH:/opt/bin"
+alias p=q r
+`
+	got := ParseRCAliases(rc, "rc")
+	expected := []RCAlias{
+		{Name: "gs", Command: "git status", File: "rc", Line: 2},
+		{Name: "ll", Command: "ls -la", File: "rc", Line: 3},
+		{Name: "k", Command: "kubectl", File: "rc", Line: 5},
+		{Name: "kgp", Command: "kubectl get pods", File: "rc", Line: 5},
+		{Name: "say", Command: "echo 'hi'", File: "rc", Line: 6},
+		{Name: "dps", Command: "docker ps --format '{{.Names}}'", Function: true, File: "rc", Line: 10},
+		{Name: "gl", Command: "git log --oneline", Function: true, File: "rc", Line: 11},
+		{Name: "indented", Command: "echo in", File: "rc", Line: 13},
+		{Name: "a", Command: "b", File: "rc", Line: 15},
+		{Name: "c", Command: "d;e", File: "rc", Line: 15},
+		{Name: "x", Command: "y", File: "rc", Line: 16, Shared: true},
+		{Name: "p", Command: "q", File: "rc", Line: 17, Shared: true},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ParseRCAliases() =\n%+v\nexpected\n%+v", got, expected)
+	}
+}
+
+func TestImportName(t *testing.T) {
+	tests := map[string]string{
+		"gs":     "gs",
+		"k-logs": "k_logs",
+		"1up":    "a_1up",
+		"..":     "__",
+	}
+	for in, expected := range tests {
+		if got := ImportName(in); got != expected {
+			t.Errorf("ImportName(%q) = %q, expected %q", in, got, expected)
+		}
+	}
+}
+
+func TestCommentOutAliases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".bashrc")
+	rc := "alias gs='git status'\nif true; then\n  alias indented='echo in'\nfi\n"
+	if err := os.WriteFile(path, []byte(rc), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Twice, to check lines already commented out are left alone
+	for i := 0; i < 2; i++ {
+		if err := CommentOutAliases(path, []int{1, 3}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# imported into lz: alias gs='git status'\nif true; then\n  : # imported into lz: alias indented='echo in'\nfi\n"
+	if string(data) != expected {
+		t.Errorf("CommentOutAliases() wrote:\n%s\nexpected:\n%s", data, expected)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("CommentOutAliases() changed the file mode: %v", info.Mode())
+	}
+	if got := ParseRCAliases(string(data), path); len(got) != 0 {
+		t.Errorf("ParseRCAliases() found %+v after commenting out", got)
+	}
+
+	if err := CommentOutAliases(path, []int{9}); err == nil {
+		t.Error("expected error for a line past the end")
+	}
+}
+
+// Every definition of an imported name is commented out, and lines that do
+// anything else are kept whole so the rc files stay valid
+func TestCommentOutImported(t *testing.T) {
+	dir := t.TempDir()
+	bashrc := filepath.Join(dir, ".bashrc")
+	bashAliases := filepath.Join(dir, ".bash_aliases")
+	files := map[string]string{
+		bashAliases: "alias gs='git status'\nalias a=b; alias c=d\nalias k=kubectl; alias keep=me\n",
+		bashrc:      "[ -f ~/.bash_aliases ] && . ~/.bash_aliases\nalias gs='git status -sb'\nalias x=y && export PATH=\"$PATH:/opt/bin\"\n",
+	}
+	var defs []RCAlias
+	for _, path := range []string{bashAliases, bashrc} {
+		if err := os.WriteFile(path, []byte(files[path]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		aliases, err := ReadRCAliases(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defs = append(defs, aliases...)
+	}
+
+	imported := map[string]string{"gs": "gs", "a": "a", "c": "c", "k": "k", "x": "x"}
+	lines, kept := ImportedLines(defs, imported)
+	expectedLines := map[string][]int{bashAliases: {1, 2}, bashrc: {2}}
+	if !reflect.DeepEqual(lines, expectedLines) {
+		t.Errorf("ImportedLines() lines = %v, expected %v", lines, expectedLines)
+	}
+	var keptNames []string
+	for _, a := range kept {
+		keptNames = append(keptNames, a.Name)
+	}
+	if !reflect.DeepEqual(keptNames, []string{"k", "x"}) {
+		t.Errorf("ImportedLines() kept = %v, expected [k x]", keptNames)
+	}
+
+	for path, n := range lines {
+		if err := CommentOutAliases(path, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := map[string]string{
+		bashAliases: "# imported into lz: alias gs='git status'\n# imported into lz: alias a=b; alias c=d\nalias k=kubectl; alias keep=me\n",
+		bashrc:      "[ -f ~/.bash_aliases ] && . ~/.bash_aliases\n# imported into lz: alias gs='git status -sb'\nalias x=y && export PATH=\"$PATH:/opt/bin\"\n",
+	}
+	bash, _ := exec.LookPath("bash")
+	for path, want := range expected {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("%s =\n%s\nexpected\n%s", filepath.Base(path), data, want)
+		}
+		if bash != "" {
+			if out, err := exec.Command(bash, "-n", path).CombinedOutput(); err != nil {
+				t.Errorf("%s is no longer valid: %v\n%s", filepath.Base(path), err, out)
+			}
+		}
+	}
+}
+
+// Names saved in lz under another name keep their rc definitions, so the
+// shell doesn't lose them
+func TestImportedLinesRenamed(t *testing.T) {
+	defs := ParseRCAliases("alias gs='git status'\nalias k-logs='kubectl logs'\nalias ll='ls -l'\nalias a=b; alias c=d\n", "rc")
+
+	// gs was saved as gst after a conflict, k-logs as k_logs by ImportName
+	imported := map[string]string{"gs": "gst", "k-logs": ImportName("k-logs"), "ll": "ll", "a": "a", "c": "cc"}
+	lines, kept := ImportedLines(defs, imported)
+	if expected := map[string][]int{"rc": {3}}; !reflect.DeepEqual(lines, expected) {
+		t.Errorf("ImportedLines() lines = %v, expected %v", lines, expected)
+	}
+	var keptNames []string
+	for _, a := range kept {
+		keptNames = append(keptNames, a.Name)
+	}
+	if !reflect.DeepEqual(keptNames, []string{"a"}) {
+		t.Errorf("ImportedLines() kept = %v, expected [a]", keptNames)
+	}
+}
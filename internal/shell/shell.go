@@ -57,26 +57,56 @@ func DetectShell() ShellType {
 }
 
 // GenerateAliases creates alias definitions for all commands
-func GenerateAliases(cfg *config.Config) string {
+// It also returns a message for each library alias skipped because an
+// earlier library command already has it.
+func GenerateAliases(cfg *config.Config) (string, []string) {
 	var sb strings.Builder
 	sb.WriteString("# Managed by lz - do not edit manually\n")
 	sb.WriteString("# Run 'lz' to manage your command aliases\n\n")
 
+	personal := make(map[string]bool)
 	for _, cmd := range cfg.Commands {
-		if binding.HasBindings(cmd.Command) {
-			// Commands with bindings invoke lz run for interactive resolution
-			sb.WriteString(fmt.Sprintf("alias %s='lz run %s'\n", cmd.Name, cmd.Name))
+		if cmd.Library == "" {
+			personal[cmd.Name] = true
+		}
+	}
+
+	var skipped []string
+	libraryAliases := make(map[string]string) // Alias to the library command it runs
+	for _, cmd := range cfg.Commands {
+		// Library commands are aliased as library_command ("ml/train" -> ml_train),
+		// unless a personal command or an earlier library command already has
+		// that name ("ml_x/train" and "ml/x_train" both give ml_x_train)
+		alias := cmd.Name
+		if cmd.Library != "" {
+			alias = cmd.Library + "_" + cmd.BaseName()
+			if personal[alias] {
+				continue
+			}
+			if first, ok := libraryAliases[alias]; ok {
+				skipped = append(skipped, fmt.Sprintf("no alias for '%s': %s is already '%s'", cmd.Name, alias, first))
+				continue
+			}
+			libraryAliases[alias] = cmd.Name
+		}
+
+		if binding.HasBindings(cmd.Command) || cmd.Library != "" || cmd.Cwd != "" || len(cmd.Env) > 0 {
+			// Commands with bindings invoke lz run for interactive resolution,
+			// as do library commands so library updates apply without lz init,
+			// and commands with a working directory or environment to apply
+			sb.WriteString(fmt.Sprintf("alias %s='lz run %s'\n", alias, cmd.Name))
 		} else {
 			// Regular alias - escape single quotes in the command
 			escaped := strings.ReplaceAll(cmd.Command, "'", "'\\''")
-			sb.WriteString(fmt.Sprintf("alias %s='%s'\n", cmd.Name, escaped))
+			sb.WriteString(fmt.Sprintf("alias %s='%s'\n", alias, escaped))
 		}
 	}
 
-	return sb.String()
+	return sb.String(), skipped
 }
 
 // UpdateAliases writes all aliases to the alias file
+// Library aliases skipped for a clash are returned as an error after writing.
 func UpdateAliases(cfg *config.Config) error {
 	aliasPath, err := GetAliasFilePath()
 	if err != nil {
@@ -89,12 +119,16 @@ func UpdateAliases(cfg *config.Config) error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	content := GenerateAliases(cfg)
+	content, skipped := GenerateAliases(cfg)
 
 	if err := os.WriteFile(aliasPath, []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to write alias file: %w", err)
 	}
 
+	// Written, but worth knowing
+	if len(skipped) > 0 {
+		return fmt.Errorf("%s", strings.Join(skipped, "; "))
+	}
 	return nil
 }
 
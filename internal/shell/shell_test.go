@@ -0,0 +1,50 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+
+	"laziest/internal/config"
+)
+
+func TestGenerateAliases(t *testing.T) {
+	cfg := &config.Config{Commands: []config.Command{
+		{Name: "gs", Command: "git status"},
+		{Name: "say", Command: "echo 'hi'"},
+		{Name: "train", Command: "python train.py {%[a,b]%}"},
+		{Name: "proj_build", Command: "make build", Cwd: "~/src/proj"},
+		{Name: "dev", Command: "npm run dev", Env: map[string]string{"PORT": "3000"}},
+		{Name: "ml/train", Command: "python train.py", Library: "ml"},
+		{Name: "ml/eval", Command: "python eval.py", Library: "ml"},
+		{Name: "ml_eval", Command: "python my_eval.py"},
+		{Name: "ml_x/train", Command: "python x.py", Library: "ml_x"},
+		{Name: "ml/x_train", Command: "python ml_x.py", Library: "ml"},
+	}}
+
+	got, skipped := GenerateAliases(cfg)
+	for _, expected := range []string{
+		"alias gs='git status'\n",
+		`alias say='echo '\''hi'\'''` + "\n",
+		"alias train='lz run train'\n",
+		"alias proj_build='lz run proj_build'\n",
+		"alias dev='lz run dev'\n",
+		"alias ml_train='lz run ml/train'\n",
+		"alias ml_eval='python my_eval.py'\n",
+		"alias ml_x_train='lz run ml_x/train'\n",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("GenerateAliases() missing %q in:\n%s", expected, got)
+		}
+	}
+	if strings.Contains(got, "lz run ml/eval") {
+		t.Error("library command shadowed by a personal command was aliased")
+	}
+
+	// Two libraries giving the same alias: the first keeps it
+	if strings.Contains(got, "lz run ml/x_train") {
+		t.Error("second library command with the same alias was aliased")
+	}
+	if len(skipped) != 1 || !strings.Contains(skipped[0], "ml/x_train") || !strings.Contains(skipped[0], "ml_x/train") {
+		t.Errorf("GenerateAliases() skipped = %q", skipped)
+	}
+}
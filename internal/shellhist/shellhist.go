@@ -0,0 +1,280 @@
+package shellhist
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Format is the layout of a history file
+type Format int
+
+const (
+	FormatBash Format = iota
+	FormatZsh
+	FormatFish
+)
+
+// File is a located history file
+type File struct {
+	Path   string
+	Format Format
+}
+
+// Locate finds the current user's history file
+// $HISTFILE wins when exported; otherwise the file for $SHELL is tried first,
+// then the other shells' default locations.
+func Locate() (File, error) {
+	shell := filepath.Base(os.Getenv("SHELL"))
+
+	if histfile := os.Getenv("HISTFILE"); histfile != "" {
+		if _, err := os.Stat(histfile); err == nil {
+			return File{Path: histfile, Format: formatFor(shell, histfile)}, nil
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return File{}, err
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+
+	bash := []File{{filepath.Join(home, ".bash_history"), FormatBash}}
+	zsh := []File{
+		{filepath.Join(home, ".zsh_history"), FormatZsh},
+		{filepath.Join(home, ".zhistory"), FormatZsh},
+	}
+	fish := []File{{filepath.Join(dataHome, "fish", "fish_history"), FormatFish}}
+
+	var candidates []File
+	switch shell {
+	case "zsh":
+		candidates = append(append(append(candidates, zsh...), bash...), fish...)
+	case "fish":
+		candidates = append(append(append(candidates, fish...), zsh...), bash...)
+	default:
+		candidates = append(append(append(candidates, bash...), zsh...), fish...)
+	}
+
+	for _, f := range candidates {
+		if _, err := os.Stat(f.Path); err == nil {
+			return f, nil
+		}
+	}
+	return File{}, fmt.Errorf("no shell history file found (tried %s)", candidates[0].Path)
+}
+
+// formatFor guesses a history file's format from the shell and file name
+func formatFor(shell, path string) Format {
+	name := filepath.Base(path)
+	switch {
+	case shell == "fish" || strings.Contains(name, "fish"):
+		return FormatFish
+	case shell == "zsh" || strings.Contains(name, "zsh") || name == ".zhistory":
+		return FormatZsh
+	default:
+		return FormatBash
+	}
+}
+
+// Load reads the located history file and returns its entries, oldest first
+func Load() ([]string, error) {
+	f, err := Locate()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return Parse(data, f.Format), nil
+}
+
+// Parse splits history file contents into entries, oldest first
+// Multi-line entries are returned with embedded newlines.
+func Parse(data []byte, format Format) []string {
+	switch format {
+	case FormatZsh:
+		return parseZsh(data)
+	case FormatFish:
+		return parseFish(data)
+	default:
+		return parseBash(data)
+	}
+}
+
+// bashTimestamp matches the "#1700000000" lines written with HISTTIMEFORMAT
+var bashTimestamp = regexp.MustCompile(`^#\d+$`)
+
+// parseBash reads ~/.bash_history
+// With timestamps, everything up to the next timestamp is one entry, which
+// keeps multi-line commands saved with lithist together.
+func parseBash(data []byte) []string {
+	lines := splitLines(string(data))
+
+	timestamped := false
+	for _, line := range lines {
+		if bashTimestamp.MatchString(line) {
+			timestamped = true
+			break
+		}
+	}
+
+	var entries []string
+	var current []string
+	flush := func() {
+		if entry := strings.TrimSpace(strings.Join(current, "\n")); entry != "" {
+			entries = append(entries, entry)
+		}
+		current = nil
+	}
+
+	for _, line := range lines {
+		if timestamped {
+			if bashTimestamp.MatchString(line) {
+				flush()
+				continue
+			}
+			current = append(current, line)
+			continue
+		}
+
+		// Without timestamps, only backslash continuations span lines
+		current = append(current, line)
+		if !strings.HasSuffix(line, `\`) {
+			flush()
+		}
+	}
+	flush()
+
+	return entries
+}
+
+// zshExtended matches the ": <start>:<elapsed>;" prefix of extended history
+var zshExtended = regexp.MustCompile(`^: *\d+:\d+;`)
+
+// parseZsh reads zsh history, plain or EXTENDED_HISTORY
+// zsh stores newlines inside a command as a backslash at the end of the line.
+func parseZsh(data []byte) []string {
+	var entries []string
+	var current []string
+
+	for _, line := range splitLines(string(unmetafy(data))) {
+		if len(current) == 0 {
+			line = zshExtended.ReplaceAllString(line, "")
+		}
+
+		if strings.HasSuffix(line, `\`) {
+			current = append(current, strings.TrimSuffix(line, `\`))
+			continue
+		}
+
+		current = append(current, line)
+		if entry := strings.TrimSpace(strings.Join(current, "\n")); entry != "" {
+			entries = append(entries, entry)
+		}
+		current = nil
+	}
+	if entry := strings.TrimSpace(strings.Join(current, "\n")); entry != "" {
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// unmetafy decodes zsh's metafied bytes: 0x83 followed by the byte XOR 32
+func unmetafy(data []byte) []byte {
+	if bytes.IndexByte(data, 0x83) == -1 {
+		return data
+	}
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		if data[i] == 0x83 && i+1 < len(data) {
+			i++
+			out = append(out, data[i]^32)
+			continue
+		}
+		out = append(out, data[i])
+	}
+	return out
+}
+
+// parseFish reads fish's YAML-like history
+//
+//   - cmd: git commit -m "msg"
+//     when: 1700000000
+func parseFish(data []byte) []string {
+	var entries []string
+	for _, line := range splitLines(string(data)) {
+		cmd, ok := strings.CutPrefix(line, "- cmd: ")
+		if !ok {
+			continue
+		}
+		if entry := strings.TrimSpace(unescapeFish(cmd)); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// unescapeFish decodes the \n and \\ escapes fish uses in history
+func unescapeFish(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case 'n':
+				sb.WriteByte('\n')
+				i++
+				continue
+			case '\\':
+				sb.WriteByte('\\')
+				i++
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// splitLines splits text into lines without trailing carriage returns
+func splitLines(data string) []string {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	return lines
+}
+
+// Recent returns up to limit entries, newest first, without duplicates
+// Invocations of lz itself are left out.
+func Recent(entries []string, limit int) []string {
+	seen := make(map[string]bool)
+	var recent []string
+	for i := len(entries) - 1; i >= 0 && len(recent) < limit; i-- {
+		entry := entries[i]
+		if seen[entry] || isLz(entry) {
+			continue
+		}
+		seen[entry] = true
+		recent = append(recent, entry)
+	}
+	return recent
+}
+
+// isLz reports whether a history entry runs lz
+func isLz(entry string) bool {
+	fields := strings.Fields(entry)
+	return len(fields) > 0 && (fields[0] == "lz" || fields[0] == "laziest")
+}
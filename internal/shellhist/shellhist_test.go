@@ -0,0 +1,68 @@
+package shellhist
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		data     string
+		expected []string
+	}{
+		{
+			name:     "bash plain",
+			format:   FormatBash,
+			data:     "ls -la\ngit status\n\necho a \\\n  b\n",
+			expected: []string{"ls -la", "git status", "echo a \\\n  b"},
+		},
+		{
+			name:     "bash timestamps",
+			format:   FormatBash,
+			data:     "#1700000000\nls\n#1700000001\nfor f in *; do\n  echo $f\ndone\n",
+			expected: []string{"ls", "for f in *; do\n  echo $f\ndone"},
+		},
+		{
+			name:     "zsh extended",
+			format:   FormatZsh,
+			data:     ": 1700000000:0;git status\n: 1700000001:3;python train.py \\\n--epochs 10\nplain entry\n",
+			expected: []string{"git status", "python train.py \n--epochs 10", "plain entry"},
+		},
+		{
+			name:     "zsh metafied",
+			format:   FormatZsh,
+			data:     ": 1700000000:0;echo caf\xc3\x83\xa9\n",
+			expected: []string{"echo caf\xc3\x89"},
+		},
+		{
+			name:     "fish",
+			format:   FormatFish,
+			data:     "- cmd: git status\n  when: 1700000000\n- cmd: echo a\\nb \\\\\n  when: 1700000001\n  paths:\n    - a\n",
+			expected: []string{"git status", "echo a\nb \\"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse([]byte(tt.data), tt.format)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Parse() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRecent(t *testing.T) {
+	entries := []string{"ls", "git status", "lz add foo", "ls", "make"}
+	got := Recent(entries, 10)
+	expected := []string{"make", "ls", "git status"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Recent() = %q, expected %q", got, expected)
+	}
+
+	if got := Recent(entries, 1); !reflect.DeepEqual(got, []string{"make"}) {
+		t.Errorf("Recent() with limit 1 = %q", got)
+	}
+}
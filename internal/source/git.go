@@ -0,0 +1,158 @@
+package source
+
+import (
+	"fmt"
+	"strings"
+)
+
+// gitKinds are what the git source lists, from the repository in the
+// current directory
+var gitKinds = map[string]kind{
+	"branches":        {"branch", func() ([]Item, error) { return gitRefs("refs/heads", "committerdate") }},
+	"remote-branches": {"remote branch", func() ([]Item, error) { return gitRefs("refs/remotes", "committerdate") }},
+	"tags":            {"tag", func() ([]Item, error) { return gitRefs("refs/tags", "creatordate") }},
+	"remotes":         {"remote", gitRemotes},
+	"changed":         {"changed file", gitChanged},
+	"stashes":         {"stash", gitStashes},
+}
+
+// emptyTree is git's hash of the empty tree, to diff against before the first commit
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// git runs a git command in the current directory
+func git(args ...string) (string, error) {
+	out, err := run("git", args...)
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "not a git repository") {
+		return "", fmt.Errorf("not in a git repository")
+	}
+	return out, err
+}
+
+// gitRefs lists the refs under prefix, most recent first
+func gitRefs(prefix, dateField string) ([]Item, error) {
+	format := fmt.Sprintf("%%(refname)%%09%%(refname:short)%%09%%(%s:relative)%%09%%(subject)", dateField)
+	out, err := git("for-each-ref", "--sort=-"+dateField, "--format="+format, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return parseRefs(out), nil
+}
+
+// parseRefs parses for-each-ref output of full name, short name, date and subject
+// Symbolic refs such as origin/HEAD are left out.
+func parseRefs(out string) []Item {
+	var items []Item
+	for _, line := range lines(out) {
+		fields := strings.SplitN(line, "\t", 4)
+		if len(fields) < 2 || strings.HasSuffix(fields[0], "/HEAD") {
+			continue
+		}
+		items = append(items, Item{Value: fields[1], Hint: joinHint(fields[2:]...)})
+	}
+	return items
+}
+
+// gitRemotes lists the remotes with their fetch URLs
+func gitRemotes() ([]Item, error) {
+	out, err := git("remote", "-v")
+	if err != nil {
+		return nil, err
+	}
+	return parseRemotes(out), nil
+}
+
+// parseRemotes parses "git remote -v" output
+func parseRemotes(out string) []Item {
+	var items []Item
+	for _, line := range lines(out) {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[2] == "(fetch)" {
+			items = append(items, Item{Value: fields[0], Hint: fields[1]})
+		}
+	}
+	return items
+}
+
+// gitChanged lists the files changed since HEAD and untracked files, relative
+// to the current directory and limited to it
+func gitChanged() ([]Item, error) {
+	base := "HEAD"
+	if _, err := git("rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		base = emptyTree // No commits yet
+	}
+	diff, err := git("-c", "core.quotepath=off", "diff", "--name-status", "--relative", "-z", base)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git("ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+	return parseChanged(diff, untracked), nil
+}
+
+// gitStatus names diff --name-status letters
+var gitStatus = map[byte]string{
+	'A': "added",
+	'C': "copied",
+	'D': "deleted",
+	'M': "modified",
+	'R': "renamed",
+	'T': "type changed",
+	'U': "unmerged",
+}
+
+// parseChanged parses "diff --name-status -z" and "ls-files -z" output
+func parseChanged(diff, untracked string) []Item {
+	var items []Item
+	fields := strings.Split(diff, "\x00")
+	for i := 0; i+1 < len(fields); i++ {
+		status := fields[i]
+		if status == "" {
+			continue
+		}
+		path := fields[i+1]
+		i++
+		// Renames and copies list the old path, then the new one
+		if (status[0] == 'R' || status[0] == 'C') && i+1 < len(fields) {
+			path = fields[i+1]
+			i++
+		}
+		hint, ok := gitStatus[status[0]]
+		if !ok {
+			hint = status
+		}
+		items = append(items, Item{Value: path, Hint: hint})
+	}
+	for _, path := range strings.Split(untracked, "\x00") {
+		if path != "" {
+			items = append(items, Item{Value: path, Hint: "untracked"})
+		}
+	}
+	return items
+}
+
+// gitStashes lists the stashes, newest first
+func gitStashes() ([]Item, error) {
+	out, err := git("stash", "list", "--format=%gd%x09%cr%x09%gs")
+	if err != nil {
+		return nil, err
+	}
+	var items []Item
+	for _, line := range lines(out) {
+		fields := strings.SplitN(line, "\t", 3)
+		items = append(items, Item{Value: fields[0], Hint: joinHint(fields[1:]...)})
+	}
+	return items, nil
+}
+
+// joinHint joins the non-empty parts of a hint
+func joinHint(parts ...string) string {
+	var kept []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, "  ")
+}
@@ -0,0 +1,305 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"laziest/internal/paths"
+)
+
+// kubeKinds are what the kube source lists, read from the kubeconfig
+// without contacting a cluster
+var kubeKinds = map[string]kind{
+	"contexts":   {"context", kubeContexts},
+	"clusters":   {"cluster", kubeClusters},
+	"users":      {"user", kubeUsers},
+	"namespaces": {"namespace", kubeNamespaces},
+}
+
+// kubeConfig is the part of a kubeconfig the kube source uses
+type kubeConfig struct {
+	current  string
+	contexts []kubeContext
+	clusters []kubeCluster
+	users    []string
+}
+
+// kubeContext is a named context
+type kubeContext struct {
+	name, cluster, user, namespace string
+}
+
+// kubeCluster is a named cluster
+type kubeCluster struct {
+	name, server string
+}
+
+// context returns the context with the given name
+func (k *kubeConfig) context(name string) (kubeContext, bool) {
+	for _, c := range k.contexts {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return kubeContext{}, false
+}
+
+// kubeConfigPaths returns the kubeconfig files kubectl would read:
+// those listed in $KUBECONFIG, or ~/.kube/config
+func kubeConfigPaths() []string {
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		var paths []string
+		for _, p := range filepath.SplitList(env) {
+			if p != "" {
+				paths = append(paths, p)
+			}
+		}
+		return paths
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return []string{filepath.Join(home, ".kube", "config")}
+}
+
+// loadKubeConfig reads and merges the kubeconfig files
+// As with kubectl, the first file to set a value or name an entry wins,
+// and files that don't exist are skipped.
+func loadKubeConfig() (*kubeConfig, error) {
+	merged := &kubeConfig{}
+	seen := make(map[string]bool)
+	found := false
+
+	for _, path := range kubeConfigPaths() {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		k, err := parseKubeConfig(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		found = true
+
+		if merged.current == "" {
+			merged.current = k.current
+		}
+		for _, c := range k.contexts {
+			if !seen["context/"+c.name] {
+				seen["context/"+c.name] = true
+				merged.contexts = append(merged.contexts, c)
+			}
+		}
+		for _, c := range k.clusters {
+			if !seen["cluster/"+c.name] {
+				seen["cluster/"+c.name] = true
+				merged.clusters = append(merged.clusters, c)
+			}
+		}
+		for _, u := range k.users {
+			if !seen["user/"+u] {
+				seen["user/"+u] = true
+				merged.users = append(merged.users, u)
+			}
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no kubeconfig found (set $KUBECONFIG or create ~/.kube/config)")
+	}
+	return merged, nil
+}
+
+// parseKubeConfig parses a kubeconfig written in YAML or JSON
+func parseKubeConfig(data string) (*kubeConfig, error) {
+	var doc any
+	if strings.HasPrefix(strings.TrimSpace(data), "{") {
+		if err := json.Unmarshal([]byte(data), &doc); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if doc, err = parseYAML(data); err != nil {
+			return nil, err
+		}
+	}
+
+	k := &kubeConfig{current: text(field(doc, "current-context"))}
+	for _, entry := range list(field(doc, "contexts")) {
+		ctx := field(entry, "context")
+		k.contexts = append(k.contexts, kubeContext{
+			name:      text(field(entry, "name")),
+			cluster:   text(field(ctx, "cluster")),
+			user:      text(field(ctx, "user")),
+			namespace: text(field(ctx, "namespace")),
+		})
+	}
+	for _, entry := range list(field(doc, "clusters")) {
+		k.clusters = append(k.clusters, kubeCluster{
+			name:   text(field(entry, "name")),
+			server: text(field(field(entry, "cluster"), "server")),
+		})
+	}
+	for _, entry := range list(field(doc, "users")) {
+		k.users = append(k.users, text(field(entry, "name")))
+	}
+	return k, nil
+}
+
+// field returns a mapping's value for key, or nil
+func field(v any, key string) any {
+	if m, ok := v.(map[string]any); ok {
+		return m[key]
+	}
+	return nil
+}
+
+// list returns a sequence's items, or nil
+func list(v any) []any {
+	items, _ := v.([]any)
+	return items
+}
+
+// text returns a scalar as a string, or "" for anything else
+func text(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case nil, map[string]any, []any:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// kubeContexts lists the contexts, with the current one pre-selected
+func kubeContexts() ([]Item, error) {
+	k, err := loadKubeConfig()
+	if err != nil {
+		return nil, err
+	}
+	var items []Item
+	for _, c := range k.contexts {
+		namespace := ""
+		if c.namespace != "" {
+			namespace = "namespace " + c.namespace
+		}
+		items = append(items, Item{Value: c.name, Hint: joinHint(c.cluster, c.user, namespace), Current: c.name == k.current})
+	}
+	return items, nil
+}
+
+// kubeClusters lists the clusters, with the current context's pre-selected
+func kubeClusters() ([]Item, error) {
+	k, err := loadKubeConfig()
+	if err != nil {
+		return nil, err
+	}
+	current, _ := k.context(k.current)
+	var items []Item
+	for _, c := range k.clusters {
+		items = append(items, Item{Value: c.name, Hint: c.server, Current: c.name == current.cluster})
+	}
+	return items, nil
+}
+
+// kubeUsers lists the users, with the current context's pre-selected
+func kubeUsers() ([]Item, error) {
+	k, err := loadKubeConfig()
+	if err != nil {
+		return nil, err
+	}
+	current, _ := k.context(k.current)
+	var items []Item
+	for _, u := range k.users {
+		items = append(items, Item{Value: u, Current: u == current.user})
+	}
+	return items, nil
+}
+
+// kubeNamespaces lists the namespaces the kubeconfig's contexts use, and
+// those in the namespace cache file, with the current context's pre-selected
+func kubeNamespaces() ([]Item, error) {
+	k, err := loadKubeConfig()
+	if err != nil {
+		return nil, err
+	}
+	cached, err := readNamespaceCache()
+	if err != nil {
+		return nil, err
+	}
+
+	contexts := make(map[string][]string) // Namespace to the contexts using it
+	for _, c := range k.contexts {
+		if c.namespace != "" {
+			contexts[c.namespace] = append(contexts[c.namespace], c.name)
+		}
+	}
+	for _, ns := range cached {
+		if _, ok := contexts[ns]; !ok {
+			contexts[ns] = nil
+		}
+	}
+
+	current, _ := k.context(k.current)
+	if current.namespace == "" {
+		current.namespace = "default" // What kubectl uses when the context sets none
+	}
+	var items []Item
+	for ns, names := range contexts {
+		hint := "cached"
+		if len(names) > 0 {
+			hint = "context " + strings.Join(names, ", ")
+		}
+		items = append(items, Item{Value: ns, Hint: hint, Current: ns == current.namespace})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Value < items[j].Value })
+	return items, nil
+}
+
+// namespaceCachePath returns the file kube:namespaces reads extra namespaces
+// from: $LZ_KUBE_NAMESPACES, or kube-namespaces in the config directory
+func namespaceCachePath() (string, error) {
+	if path := os.Getenv("LZ_KUBE_NAMESPACES"); path != "" {
+		return path, nil
+	}
+	dir, err := paths.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "kube-namespaces"), nil
+}
+
+// readNamespaceCache reads the namespace cache file, one namespace per line
+// Lines may be written as "namespace/name", as "kubectl get ns -o name"
+// prints them. A missing file is empty.
+func readNamespaceCache() ([]string, error) {
+	path, err := namespaceCachePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var namespaces []string
+	for _, line := range lines(string(data)) {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		namespaces = append(namespaces, strings.TrimPrefix(line, "namespace/"))
+	}
+	return namespaces, nil
+}
@@ -0,0 +1,275 @@
+package source
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// procKinds are what the proc source lists, read from /proc on Linux
+var procKinds = map[string]kind{
+	"pids":      {"process", procPIDs},
+	"ports":     {"port", func() ([]Item, error) { return procPorts("tcp", "udp") }},
+	"tcp-ports": {"TCP port", func() ([]Item, error) { return procPorts("tcp") }},
+	"udp-ports": {"UDP port", func() ([]Item, error) { return procPorts("udp") }},
+}
+
+// procRoot is where the proc filesystem is mounted
+var procRoot = "/proc"
+
+// process is a running process as read from /proc
+type process struct {
+	pid     int
+	name    string // From comm, e.g. "python3"
+	user    string
+	cmdline string // Arguments joined with spaces
+}
+
+// checkProc returns an error if there is no proc filesystem to read
+func checkProc() error {
+	if _, err := os.Stat(filepath.Join(procRoot, "self")); err != nil {
+		return fmt.Errorf("%s not available (process and port sources need Linux)", procRoot)
+	}
+	return nil
+}
+
+// readProcesses reads the processes with a command line, skipping kernel
+// threads, lz itself and processes that exit while being read
+func readProcesses() ([]process, error) {
+	if err := checkProc(); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(map[string]string) // uid to user name
+	self := os.Getpid()
+	var procs []process
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || pid == self {
+			continue
+		}
+		dir := filepath.Join(procRoot, e.Name())
+		cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+		if err != nil || len(cmdline) == 0 {
+			continue
+		}
+		comm, _ := os.ReadFile(filepath.Join(dir, "comm"))
+		procs = append(procs, process{
+			pid:     pid,
+			name:    strings.TrimSpace(string(comm)),
+			user:    userName(procUID(dir), users),
+			cmdline: strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " ")),
+		})
+	}
+	sort.Slice(procs, func(i, j int) bool { return procs[i].pid < procs[j].pid })
+	return procs, nil
+}
+
+// procUID returns the real user ID of the process whose /proc directory is dir
+func procUID(dir string) string {
+	status, err := os.ReadFile(filepath.Join(dir, "status"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(status), "\n") {
+		if rest, ok := strings.CutPrefix(line, "Uid:"); ok {
+			if fields := strings.Fields(rest); len(fields) > 0 {
+				return fields[0]
+			}
+		}
+	}
+	return ""
+}
+
+// userName looks up a uid's user name, remembering answers in cache
+func userName(uid string, cache map[string]string) string {
+	if uid == "" {
+		return "?"
+	}
+	if name, ok := cache[uid]; ok {
+		return name
+	}
+	name := uid
+	if u, err := user.LookupId(uid); err == nil {
+		name = u.Username
+	}
+	cache[uid] = name
+	return name
+}
+
+// procPIDs lists running processes by PID, with their user and command line
+// A pattern matches the process name or the program's file name.
+func procPIDs() ([]Item, error) {
+	procs, err := readProcesses()
+	if err != nil {
+		return nil, err
+	}
+	// Pad user names so command lines line up in a column
+	width := 0
+	for _, p := range procs {
+		width = max(width, len(p.user))
+	}
+	items := make([]Item, len(procs))
+	for i, p := range procs {
+		names := []string{p.name}
+		if fields := strings.Fields(p.cmdline); len(fields) > 0 {
+			names = append(names, filepath.Base(fields[0]))
+		}
+		items[i] = Item{Value: strconv.Itoa(p.pid), Hint: fmt.Sprintf("%-*s  %s", width, p.user, p.cmdline), Names: names}
+	}
+	return items, nil
+}
+
+// socket is a listening socket from /proc/net
+type socket struct {
+	proto string // "tcp" or "udp"
+	addr  string
+	port  int
+	inode string
+}
+
+// Socket states in /proc/net: listening for TCP, unconnected for UDP
+const (
+	tcpListen      = "0A"
+	udpUnconnected = "07"
+)
+
+// procPorts lists the ports with a listening socket for the given protocols,
+// with the address and, where it can be read, the process listening
+func procPorts(protos ...string) ([]Item, error) {
+	if err := checkProc(); err != nil {
+		return nil, err
+	}
+
+	var sockets []socket
+	for _, proto := range protos {
+		for _, file := range []string{proto, proto + "6"} {
+			data, err := os.ReadFile(filepath.Join(procRoot, "net", file))
+			if err != nil {
+				continue // No IPv6, for example
+			}
+			sockets = append(sockets, parseSockets(proto, string(data))...)
+		}
+	}
+
+	owners := socketOwners()
+	type port struct {
+		number int
+		parts  []string
+		seen   map[string]bool
+	}
+	ports := make(map[int]*port)
+	for _, s := range sockets {
+		p, ok := ports[s.port]
+		if !ok {
+			p = &port{number: s.port, seen: make(map[string]bool)}
+			ports[s.port] = p
+		}
+		part := s.proto + " " + s.addr
+		if owner, ok := owners[s.inode]; ok {
+			part += " " + owner
+		}
+		if !p.seen[part] {
+			p.seen[part] = true
+			p.parts = append(p.parts, part)
+		}
+	}
+
+	items := make([]Item, 0, len(ports))
+	for _, p := range ports {
+		items = append(items, Item{Value: strconv.Itoa(p.number), Hint: strings.Join(p.parts, ", ")})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, _ := strconv.Atoi(items[i].Value)
+		b, _ := strconv.Atoi(items[j].Value)
+		return a < b
+	})
+	return items, nil
+}
+
+// parseSockets parses a /proc/net/{tcp,udp}[6] table, keeping listening sockets
+func parseSockets(proto, data string) []socket {
+	state := tcpListen
+	if proto == "udp" {
+		state = udpUnconnected
+	}
+	var sockets []socket
+	for i, line := range lines(data) {
+		fields := strings.Fields(line)
+		if i == 0 || len(fields) < 10 || fields[3] != state {
+			continue // Header, or not listening
+		}
+		host, portHex, ok := strings.Cut(fields[1], ":")
+		port, err := strconv.ParseInt(portHex, 16, 32)
+		addr, addrErr := parseProcAddr(host)
+		if !ok || err != nil || addrErr != nil {
+			continue
+		}
+		sockets = append(sockets, socket{proto: proto, addr: addr, port: int(port), inode: fields[9]})
+	}
+	return sockets
+}
+
+// parseProcAddr decodes an address from /proc/net, written as hex in
+// groups of four bytes in host (little-endian) order
+func parseProcAddr(text string) (string, error) {
+	raw, err := hex.DecodeString(text)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return "", fmt.Errorf("invalid address '%s'", text)
+	}
+	for i := 0; i < len(raw); i += 4 {
+		raw[i], raw[i+1], raw[i+2], raw[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+	}
+	ip := net.IP(raw)
+	if len(raw) == net.IPv6len && ip.To4() == nil {
+		return "[" + ip.String() + "]", nil
+	}
+	return ip.String(), nil
+}
+
+// socketOwners maps socket inodes to the process holding them, e.g.
+// "nginx (812)". Only processes whose file descriptors can be read are found,
+// which without root means your own.
+func socketOwners() map[string]string {
+	owners := make(map[string]string)
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return owners
+	}
+	for _, e := range entries {
+		if _, err := strconv.Atoi(e.Name()); err != nil {
+			continue
+		}
+		dir := filepath.Join(procRoot, e.Name())
+		fds, err := os.ReadDir(filepath.Join(dir, "fd"))
+		if err != nil {
+			continue
+		}
+		var owner string
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(dir, "fd", fd.Name()))
+			if err != nil || !strings.HasPrefix(target, "socket:[") {
+				continue
+			}
+			if owner == "" {
+				comm, _ := os.ReadFile(filepath.Join(dir, "comm"))
+				owner = fmt.Sprintf("%s (%s)", strings.TrimSpace(string(comm)), e.Name())
+			}
+			inode := strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]")
+			if _, ok := owners[inode]; !ok {
+				owners[inode] = owner
+			}
+		}
+	}
+	return owners
+}
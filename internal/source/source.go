@@ -0,0 +1,154 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Item is a value offered by a source, with a hint shown next to it
+type Item struct {
+	Value   string
+	Hint    string   // e.g. "2 days ago  Fix login" for a branch
+	Current bool     // In use now, such as the current kube context, so picked by default
+	Names   []string // Also matched by a spec's pattern, e.g. a process's name
+}
+
+// Spec names a source and what to list, e.g. "git:branches" or, keeping
+// only the items matching a glob, "proc:pids:python*"
+type Spec struct {
+	Source  string // e.g. "git"
+	Kind    string // e.g. "branches"
+	Pattern string // Glob items must match, "" for all
+	Text    string // The text it was parsed from
+}
+
+// kind is one kind of thing a source lists
+type kind struct {
+	noun string // What one item is, for prompts, e.g. "branch"
+	list func() ([]Item, error)
+}
+
+// sources maps each source name to its kinds
+var sources = map[string]map[string]kind{
+	"git":  gitKinds,
+	"ssh":  sshKinds,
+	"kube": kubeKinds,
+	"proc": procKinds,
+}
+
+// IsSpec reports whether content names a source and one of its kinds, e.g.
+// "git:branches"; anything else, such as "kube:*.yaml", is left to be a
+// directory binding, as it was before sources existed
+func IsSpec(content string) bool {
+	name, rest, _ := strings.Cut(content, ":")
+	what, _, _ := strings.Cut(rest, ":")
+	_, known := sources[name][what]
+	return known
+}
+
+// Parse parses a source spec such as "git:tags" or "git:branches:feature/*"
+func Parse(text string) (*Spec, error) {
+	name, rest, _ := strings.Cut(text, ":")
+	what, pattern, hasPattern := strings.Cut(rest, ":")
+	kinds, ok := sources[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown source '%s'", name)
+	}
+	if _, ok := kinds[what]; !ok {
+		return nil, fmt.Errorf("unknown %s source '%s' (use %s)", name, what, strings.Join(Kinds(name), ", "))
+	}
+	if _, err := path.Match(pattern, ""); hasPattern && (pattern == "" || err != nil) {
+		return nil, fmt.Errorf("invalid pattern '%s'", pattern)
+	}
+	return &Spec{Source: name, Kind: what, Pattern: pattern, Text: text}, nil
+}
+
+// Noun names what the spec lists, e.g. "branch" for git:branches
+func (s *Spec) Noun() string {
+	return sources[s.Source][s.Kind].noun
+}
+
+// Kinds returns the sorted kinds a source lists
+func Kinds(name string) []string {
+	var kinds []string
+	for kind := range sources[name] {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// List returns the items a spec offers right now
+func List(spec *Spec) ([]Item, error) {
+	items, err := sources[spec.Source][spec.Kind].list()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", spec.Text, err)
+	}
+	if spec.Pattern == "" {
+		return items, nil
+	}
+	var matched []Item
+	for _, item := range items {
+		if item.matches(spec.Pattern) {
+			matched = append(matched, item)
+		}
+	}
+	return matched, nil
+}
+
+// matches reports whether the item's value, the last part of its value
+// (a file name or branch name) or one of its names matches a glob
+func (item Item) matches(pattern string) bool {
+	for _, name := range append([]string{item.Value, path.Base(item.Value)}, item.Names...) {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// runTimeout bounds each external command a source runs
+const runTimeout = 10 * time.Second
+
+// run runs a program and returns its output, with its error output as the
+// error message when it fails
+func run(name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("'%s' timed out after %s", name, runTimeout)
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", fmt.Errorf("'%s' not found in PATH", name)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(strings.SplitN(msg, "\n", 2)[0])
+		}
+		return "", fmt.Errorf("'%s' failed: %w", name, err)
+	}
+	return string(out), nil
+}
+
+// lines splits output into its non-empty lines
+func lines(out string) []string {
+	var result []string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) != "" {
+			result = append(result, strings.TrimRight(line, "\r"))
+		}
+	}
+	return result
+}
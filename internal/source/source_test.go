@@ -0,0 +1,544 @@
+package source
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	spec, err := Parse("git:remote-branches")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Source != "git" || spec.Kind != "remote-branches" || spec.Noun() != "remote branch" {
+		t.Errorf("Parse() = %+v", spec)
+	}
+
+	spec, err = Parse("proc:pids:python*")
+	if err != nil || spec.Kind != "pids" || spec.Pattern != "python*" {
+		t.Errorf("Parse() = %+v, %v", spec, err)
+	}
+
+	for _, text := range []string{"git:", "git:branch", "svn:branches", "proc:pids:", "git:tags:[v"} {
+		if _, err := Parse(text); err == nil {
+			t.Errorf("expected error for %q", text)
+		}
+	}
+
+	if !IsSpec("git:tags") || !IsSpec("proc:pids:python*") || IsSpec("git") || IsSpec("/tmp:*.yaml") || IsSpec("kube:*.yaml") {
+		t.Error("IsSpec() misidentified a spec")
+	}
+}
+
+func TestParseRefs(t *testing.T) {
+	out := "refs/remotes/origin/HEAD\torigin\t2 days ago\tMerge\n" +
+		"refs/remotes/origin/main\torigin/main\t2 days ago\tMerge pull request #4\n" +
+		"refs/remotes/origin/wip\torigin/wip\t3 weeks ago\t\n"
+	expected := []Item{
+		{Value: "origin/main", Hint: "2 days ago  Merge pull request #4"},
+		{Value: "origin/wip", Hint: "3 weeks ago"},
+	}
+	if got := parseRefs(out); !reflect.DeepEqual(got, expected) {
+		t.Errorf("parseRefs() = %+v, expected %+v", got, expected)
+	}
+}
+
+func TestParseRemotes(t *testing.T) {
+	out := "origin\tgit@example.com:team/app.git (fetch)\norigin\tgit@example.com:team/app.git (push)\nfork\thttps://example.com/me/app (fetch)\n"
+	expected := []Item{
+		{Value: "origin", Hint: "git@example.com:team/app.git"},
+		{Value: "fork", Hint: "https://example.com/me/app"},
+	}
+	if got := parseRemotes(out); !reflect.DeepEqual(got, expected) {
+		t.Errorf("parseRemotes() = %+v, expected %+v", got, expected)
+	}
+}
+
+func TestParseChanged(t *testing.T) {
+	diff := "M\x00main.go\x00R087\x00old name.go\x00new name.go\x00D\x00gone.txt\x00"
+	untracked := "notes.md\x00"
+	expected := []Item{
+		{Value: "main.go", Hint: "modified"},
+		{Value: "new name.go", Hint: "renamed"},
+		{Value: "gone.txt", Hint: "deleted"},
+		{Value: "notes.md", Hint: "untracked"},
+	}
+	if got := parseChanged(diff, untracked); !reflect.DeepEqual(got, expected) {
+		t.Errorf("parseChanged() = %+v, expected %+v", got, expected)
+	}
+}
+
+func TestGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	if _, err := List(&Spec{Source: "git", Kind: "branches", Text: "git:branches"}); err == nil {
+		t.Fatal("expected error outside a repository")
+	}
+
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"-c", "user.name=T", "-c", "user.email=t@example.com", "commit", "-q", "--allow-empty", "-m", "First"},
+		{"branch", "feature"},
+		{"tag", "v1.0"},
+	} {
+		if _, err := git(args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "new.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	values := func(text string) []string {
+		spec, err := Parse(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items, err := List(spec)
+		if err != nil {
+			t.Fatalf("List(%s) = %v", text, err)
+		}
+		var result []string
+		for _, item := range items {
+			result = append(result, item.Value)
+		}
+		return result
+	}
+
+	if got := values("git:branches"); len(got) != 2 {
+		t.Errorf("git:branches = %v", got)
+	}
+	if got := values("git:tags"); !reflect.DeepEqual(got, []string{"v1.0"}) {
+		t.Errorf("git:tags = %v", got)
+	}
+	if got := values("git:changed"); !reflect.DeepEqual(got, []string{"new.txt"}) {
+		t.Errorf("git:changed = %v", got)
+	}
+	if got := values("git:stashes"); len(got) != 0 {
+		t.Errorf("git:stashes = %v", got)
+	}
+}
+
+func TestSSHConfigHosts(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config": `# Personal hosts
+Include conf.d/*.conf
+Include missing.conf
+
+Host web web-alias
+    HostName 10.0.0.5
+    User deploy
+    Port 2222
+
+Host *.internal !bastion db?
+    User admin
+
+Host bastion
+  hostname=bastion.example.com
+  HostName ignored.example.com
+
+Match host web exec "true"
+    User someone-else
+
+Host "quoted"
+    Port = 2200
+`,
+		"conf.d/work.conf": `Host build
+    HostName build.example.com
+Host web
+    User not-first
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := sshConfigHosts(filepath.Join(dir, "config"), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Item{
+		{Value: "build", Hint: "build.example.com"},
+		{Value: "web", Hint: "not-first@10.0.0.5:2222"},
+		{Value: "web-alias", Hint: "deploy@10.0.0.5:2222"},
+		{Value: "bastion", Hint: "bastion.example.com"},
+		{Value: "quoted", Hint: "quoted:2200"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("sshConfigHosts() = %+v, expected %+v", got, expected)
+	}
+
+	// An Include that includes itself stops rather than looping
+	loop := filepath.Join(dir, "loop")
+	if err := os.WriteFile(loop, []byte("Include loop\nHost a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := sshConfigHosts(loop, dir); err != nil || len(got) != 1 {
+		t.Errorf("sshConfigHosts(loop) = %+v, %v", got, err)
+	}
+}
+
+func TestKnownHosts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	content := `github.com,140.82.121.4 ssh-ed25519 AAAA
+|1|F1E1KeoE/eEWhi10WpGv4OdiO6Y=|3988QV0VE8wmZL7suNrYQLITLCg= ssh-rsa AAAA
+[git.example.com]:2222 ssh-ed25519 AAAA
+@cert-authority *.example.com ssh-rsa AAAA
+# comment
+github.com ssh-rsa AAAA
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := knownHosts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Item{
+		{Value: "140.82.121.4", Hint: "known_hosts"},
+		{Value: "git.example.com", Hint: "known_hosts, port 2222"},
+		{Value: "github.com", Hint: "known_hosts"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("knownHosts() = %+v, expected %+v", got, expected)
+	}
+}
+
+func TestSSHHosts(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if _, err := sshHosts(false); err == nil {
+		t.Error("expected error without ~/.ssh/config")
+	}
+
+	dir := filepath.Join(home, ".ssh")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "known_hosts"), []byte("web ssh-ed25519 AAAA\nother ssh-ed25519 AAAA\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := sshHosts(true); err != nil || len(got) != 2 {
+		t.Errorf("sshHosts(true) without config = %+v, %v", got, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "config"), []byte("Host web\n  HostName 10.0.0.5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := sshHosts(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Item{
+		{Value: "web", Hint: "10.0.0.5"},
+		{Value: "other", Hint: "known_hosts"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("sshHosts(true) = %+v, expected %+v", got, expected)
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	doc := `# A comment
+apiVersion: v1
+empty:
+list:
+- plain
+- "double \"quoted\""
+- 'single ''quoted'''
+- name: first
+  nested:
+    key: value # trailing comment
+-
+  name: second
+indented:
+  - a
+  - b
+flow: {}
+args: [eks, "get-token", '--region', ] # trailing comment
+none: []
+"quoted key": x
+block: |
+  line one
+  line two
+url: https://example.com:6443/path
+`
+	expected := map[string]any{
+		"apiVersion": "v1",
+		"empty":      "",
+		"list": []any{
+			"plain",
+			`double "quoted"`,
+			"single 'quoted'",
+			map[string]any{"name": "first", "nested": map[string]any{"key": "value"}},
+			map[string]any{"name": "second"},
+		},
+		"indented":   []any{"a", "b"},
+		"flow":       map[string]any{},
+		"args":       []any{"eks", "get-token", "--region"},
+		"none":       []any{},
+		"quoted key": "x",
+		"block":      "line one\nline two",
+		"url":        "https://example.com:6443/path",
+	}
+	got, err := parseYAML(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("parseYAML() = %#v, expected %#v", got, expected)
+	}
+
+	// Unsupported constructs fail rather than reading as something else
+	unsupported := []string{
+		"key: value\n  stray: indent\n",
+		"just text\n",
+		"key: \"open\n",
+		"key: \"open\n  close\"\n",
+		"key: plain\n  continued\n",
+		"context: {cluster: a, user: b}\n",
+		"contexts: [{name: a}]\n",
+		"args: [a,\n  b]\n",
+		"base: &base\n  cluster: a\n",
+		"context: *base\n",
+		"context:\n  <<: *base\n",
+		"name: !!str a\n",
+	}
+	for _, bad := range unsupported {
+		if _, err := parseYAML(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestKube(t *testing.T) {
+	dir := t.TempDir()
+	work := filepath.Join(dir, "work.yaml")
+	personal := filepath.Join(dir, "personal.json")
+	files := map[string]string{
+		work: `apiVersion: v1
+kind: Config
+current-context: prod
+clusters:
+- cluster:
+    certificate-authority-data: LS0tLS1CRUdJTi==
+    server: https://prod.example.com:6443
+  name: prod-cluster
+- name: staging-cluster
+  cluster:
+    server: https://staging.example.com
+contexts:
+- context:
+    cluster: prod-cluster
+    user: admin
+    namespace: web
+  name: prod
+- context:
+    cluster: staging-cluster
+    user: admin
+  name: staging
+users:
+- name: admin
+  user:
+    token: secret
+`,
+		personal: `{"current-context": "kind", "contexts": [
+  {"name": "kind", "context": {"cluster": "kind", "user": "kind", "namespace": "dev"}},
+  {"name": "prod", "context": {"cluster": "other"}}
+], "clusters": [{"name": "kind", "cluster": {"server": "https://127.0.0.1:6443"}}], "users": [{"name": "kind"}]}`,
+		filepath.Join(dir, "namespaces"): "# from kubectl get ns -o name\nnamespace/default\nnamespace/web\nmonitoring\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("KUBECONFIG", work+string(filepath.ListSeparator)+filepath.Join(dir, "missing")+string(filepath.ListSeparator)+personal)
+	t.Setenv("LZ_KUBE_NAMESPACES", filepath.Join(dir, "namespaces"))
+
+	list := func(text string) []Item {
+		spec, err := Parse(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items, err := List(spec)
+		if err != nil {
+			t.Fatalf("List(%s) = %v", text, err)
+		}
+		return items
+	}
+
+	tests := []struct {
+		text     string
+		expected []Item
+	}{
+		{"kube:contexts", []Item{
+			{Value: "prod", Hint: "prod-cluster  admin  namespace web", Current: true},
+			{Value: "staging", Hint: "staging-cluster  admin"},
+			{Value: "kind", Hint: "kind  kind  namespace dev"},
+		}},
+		{"kube:clusters", []Item{
+			{Value: "prod-cluster", Hint: "https://prod.example.com:6443", Current: true},
+			{Value: "staging-cluster", Hint: "https://staging.example.com"},
+			{Value: "kind", Hint: "https://127.0.0.1:6443"},
+		}},
+		{"kube:users", []Item{
+			{Value: "admin", Current: true},
+			{Value: "kind"},
+		}},
+		{"kube:namespaces", []Item{
+			{Value: "default", Hint: "cached"},
+			{Value: "dev", Hint: "context kind"},
+			{Value: "monitoring", Hint: "cached"},
+			{Value: "web", Hint: "context prod", Current: true},
+		}},
+	}
+	for _, tt := range tests {
+		if got := list(tt.text); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s = %+v, expected %+v", tt.text, got, tt.expected)
+		}
+	}
+
+	t.Setenv("KUBECONFIG", filepath.Join(dir, "missing"))
+	if _, err := List(&Spec{Source: "kube", Kind: "contexts", Text: "kube:contexts"}); err == nil {
+		t.Error("expected error without a kubeconfig")
+	}
+}
+
+func TestItemMatches(t *testing.T) {
+	item := Item{Value: "origin/feature/login", Names: []string{"python3"}}
+	for _, pattern := range []string{"origin/*/*", "login", "py*"} {
+		if !item.matches(pattern) {
+			t.Errorf("expected %q to match", pattern)
+		}
+	}
+	if item.matches("feature/*") {
+		t.Error("expected feature/* not to match")
+	}
+}
+
+func TestParseProcAddr(t *testing.T) {
+	tests := map[string]string{
+		"0100007F":                         "127.0.0.1",
+		"00000000":                         "0.0.0.0",
+		"00000000000000000000000000000000": "[::]",
+		"00000000000000000000000001000000": "[::1]",
+		"0000000000000000FFFF00000100007F": "127.0.0.1",
+	}
+	for text, expected := range tests {
+		if got, err := parseProcAddr(text); err != nil || got != expected {
+			t.Errorf("parseProcAddr(%q) = %q, %v, expected %q", text, got, err, expected)
+		}
+	}
+	if _, err := parseProcAddr("0100"); err == nil {
+		t.Error("expected error for a short address")
+	}
+}
+
+func TestProc(t *testing.T) {
+	root := t.TempDir()
+	procRoot = root
+	t.Cleanup(func() { procRoot = "/proc" })
+
+	write := func(name, content string) {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, "self"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	write("812/cmdline", "nginx: master process /usr/sbin/nginx\x00")
+	write("812/comm", "nginx\n")
+	write("812/status", "Name:\tnginx\nUid:\t4000000\t4000000\t4000000\t4000000\n")
+	write("1234/cmdline", "/usr/bin/python3\x00app.py\x00--port\x008000\x00")
+	write("1234/comm", "python3\n")
+	write("1234/status", "Name:\tpython3\nUid:\t40000\t0\t0\t0\n")
+	write("2/cmdline", "") // A kernel thread
+	write("2/comm", "kthreadd\n")
+	if err := os.MkdirAll(filepath.Join(root, "812", "fd"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for fd, target := range map[string]string{"0": "/dev/null", "6": "socket:[1001]", "7": "socket:[1002]"} {
+		if err := os.Symlink(target, filepath.Join(root, "812", "fd", fd)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	header := "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+	write("net/tcp", header+
+		"   0: 00000000:0050 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1001 1 0 100 0 0 10 0\n"+
+		"   1: 0100007F:1F40 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1003 1 0 100 0 0 10 0\n"+
+		"   2: 0100007F:1F40 0100007F:D431 01 00000000:00000000 00:00000000 00000000  1000        0 1004 1 0 100 0 0 10 0\n")
+	write("net/tcp6", header+
+		"   0: 00000000000000000000000000000000:0050 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1002 1 0 100 0 0 10 0\n")
+	write("net/udp", header+
+		"   0: 00000000:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 1005 2 0 0\n")
+
+	list := func(text string) []Item {
+		spec, err := Parse(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items, err := List(spec)
+		if err != nil {
+			t.Fatalf("List(%s) = %v", text, err)
+		}
+		return items
+	}
+
+	tests := []struct {
+		text     string
+		expected []Item
+	}{
+		{"proc:pids", []Item{
+			{Value: "812", Hint: "4000000  nginx: master process /usr/sbin/nginx", Names: []string{"nginx", "nginx:"}},
+			{Value: "1234", Hint: "40000    /usr/bin/python3 app.py --port 8000", Names: []string{"python3", "python3"}},
+		}},
+		{"proc:pids:python*", []Item{
+			{Value: "1234", Hint: "40000    /usr/bin/python3 app.py --port 8000", Names: []string{"python3", "python3"}},
+		}},
+		{"proc:ports", []Item{
+			{Value: "53", Hint: "udp 0.0.0.0"},
+			{Value: "80", Hint: "tcp 0.0.0.0 nginx (812), tcp [::] nginx (812)"},
+			{Value: "8000", Hint: "tcp 127.0.0.1"},
+		}},
+		{"proc:udp-ports", []Item{
+			{Value: "53", Hint: "udp 0.0.0.0"},
+		}},
+	}
+	for _, tt := range tests {
+		if got := list(tt.text); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s = %+v, expected %+v", tt.text, got, tt.expected)
+		}
+	}
+
+	procRoot = filepath.Join(root, "missing")
+	if _, err := List(&Spec{Source: "proc", Kind: "pids", Text: "proc:pids"}); err == nil {
+		t.Error("expected error without /proc")
+	}
+}
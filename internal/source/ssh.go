@@ -0,0 +1,262 @@
+package source
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// sshKinds are what the ssh source lists
+var sshKinds = map[string]kind{
+	"hosts":     {"host", func() ([]Item, error) { return sshHosts(false) }},
+	"all-hosts": {"host", func() ([]Item, error) { return sshHosts(true) }},
+}
+
+// maxIncludeDepth stops Include directives that include each other
+const maxIncludeDepth = 16
+
+// sshHosts lists the hosts named in ~/.ssh/config, and with known also
+// the plain (unhashed) names in ~/.ssh/known_hosts
+func sshHosts(known bool) ([]Item, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(home, ".ssh")
+
+	config := filepath.Join(dir, "config")
+	items, err := sshConfigHosts(config, dir)
+	if err != nil && !(known && os.IsNotExist(err)) {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s not found", config)
+		}
+		return nil, err
+	}
+	if !known {
+		return items, nil
+	}
+
+	names, err := knownHosts(filepath.Join(dir, "known_hosts"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	listed := make(map[string]bool)
+	for _, item := range items {
+		listed[item.Value] = true
+	}
+	for _, name := range names {
+		if !listed[name.Value] {
+			items = append(items, name)
+			listed[name.Value] = true
+		}
+	}
+	return items, nil
+}
+
+// sshHost is a host alias and the options set for it
+type sshHost struct {
+	alias, hostName, user, port string
+}
+
+// sshConfig collects hosts while reading a config and the files it includes
+type sshConfig struct {
+	sshDir  string     // Relative Include paths are relative to this
+	hosts   []*sshHost // In the order first seen
+	byAlias map[string]*sshHost
+	current []*sshHost // Hosts the options being read apply to
+}
+
+// sshConfigHosts lists the concrete hosts in an ssh config file
+// Host patterns with wildcards or negation are left out, and Match blocks
+// are ignored. Like ssh, the first value given for an option wins.
+func sshConfigHosts(path, sshDir string) ([]Item, error) {
+	c := &sshConfig{sshDir: sshDir, byAlias: make(map[string]*sshHost)}
+	if err := c.read(path, 0); err != nil {
+		return nil, err
+	}
+
+	items := make([]Item, len(c.hosts))
+	for i, h := range c.hosts {
+		items[i] = Item{Value: h.alias, Hint: h.hint()}
+	}
+	return items, nil
+}
+
+// read reads one config file
+func (c *sshConfig) read(path string, depth int) error {
+	if depth > maxIncludeDepth {
+		return fmt.Errorf("%s: Include nested too deeply", path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		keyword, args := splitSSHLine(scanner.Text())
+		switch keyword {
+		case "host":
+			c.current = nil
+			for _, alias := range args {
+				if strings.ContainsAny(alias, "*?!") {
+					continue // A pattern, not a host to connect to
+				}
+				h, ok := c.byAlias[alias]
+				if !ok {
+					h = &sshHost{alias: alias}
+					c.byAlias[alias] = h
+					c.hosts = append(c.hosts, h)
+				}
+				c.current = append(c.current, h)
+			}
+		case "match":
+			c.current = nil
+		case "include":
+			for _, pattern := range args {
+				c.include(pattern, depth)
+			}
+		case "hostname", "user", "port":
+			if len(args) == 0 {
+				continue
+			}
+			for _, h := range c.current {
+				h.set(keyword, args[0])
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// include reads the files an Include pattern names; missing ones are skipped, as ssh does
+func (c *sshConfig) include(pattern string, depth int) {
+	if strings.HasPrefix(pattern, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			pattern = filepath.Join(home, pattern[1:])
+		}
+	}
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(c.sshDir, pattern)
+	}
+	matches, _ := filepath.Glob(pattern)
+	sort.Strings(matches)
+	for _, match := range matches {
+		// Options before the first Host of an included file apply to the including block
+		current := c.current
+		c.read(match, depth+1)
+		c.current = current
+	}
+}
+
+// set records an option unless it was already given
+func (h *sshHost) set(keyword, value string) {
+	switch {
+	case keyword == "hostname" && h.hostName == "":
+		h.hostName = value
+	case keyword == "user" && h.user == "":
+		h.user = value
+	case keyword == "port" && h.port == "":
+		h.port = value
+	}
+}
+
+// hint shows where a host connects to, e.g. "deploy@10.0.0.5:2222"
+func (h *sshHost) hint() string {
+	target := h.hostName
+	if target == "" && (h.user != "" || h.port != "") {
+		target = h.alias
+	}
+	if target == "" {
+		return ""
+	}
+	if h.user != "" {
+		target = h.user + "@" + target
+	}
+	if h.port != "" {
+		target += ":" + h.port
+	}
+	return target
+}
+
+// splitSSHLine splits a config line into its lowercased keyword and arguments
+// Keywords may be followed by spaces or "=", and arguments may be quoted.
+func splitSSHLine(line string) (string, []string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", nil
+	}
+	end := strings.IndexAny(line, " \t=")
+	if end == -1 {
+		return strings.ToLower(line), nil
+	}
+	keyword := strings.ToLower(line[:end])
+	rest := strings.TrimLeft(line[end:], " \t")
+	rest = strings.TrimLeft(strings.TrimPrefix(rest, "="), " \t")
+
+	var args []string
+	for rest != "" {
+		var arg string
+		if rest[0] == '"' {
+			closing := strings.IndexByte(rest[1:], '"')
+			if closing == -1 {
+				arg, rest = rest[1:], ""
+			} else {
+				arg, rest = rest[1:closing+1], rest[closing+2:]
+			}
+		} else if i := strings.IndexAny(rest, " \t"); i != -1 {
+			arg, rest = rest[:i], rest[i:]
+		} else {
+			arg, rest = rest, ""
+		}
+		if arg != "" {
+			args = append(args, arg)
+		}
+		rest = strings.TrimLeft(rest, " \t")
+	}
+	return keyword, args
+}
+
+// knownHosts lists the host names in a known_hosts file, sorted
+// Hashed entries can't be read back and are left out; "[host]:port" entries
+// are listed as the host with the port in the hint.
+func knownHosts(path string) ([]Item, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	seen := make(map[string]bool)
+	var items []Item
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 0 && strings.HasPrefix(fields[0], "@") {
+			fields = fields[1:] // @cert-authority or @revoked
+		}
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "|") {
+			continue
+		}
+		for _, name := range strings.Split(fields[0], ",") {
+			hint := "known_hosts"
+			if strings.HasPrefix(name, "[") {
+				host, port, ok := strings.Cut(strings.TrimPrefix(name, "["), "]:")
+				if !ok {
+					continue
+				}
+				name, hint = host, "known_hosts, port "+port
+			}
+			if name == "" || strings.ContainsAny(name, "*?!") || seen[name] {
+				continue
+			}
+			seen[name] = true
+			items = append(items, Item{Value: name, Hint: hint})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Value < items[j].Value })
+	return items, scanner.Err()
+}
@@ -0,0 +1,309 @@
+package source
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlLine is a non-blank line of a YAML document
+type yamlLine struct {
+	indent int
+	text   string // Without indentation
+	number int    // 1-based, for errors
+}
+
+// parseYAML parses the block-style YAML subset that kubeconfig files use:
+// nested mappings, sequences, and plain, quoted or block scalars
+// Mappings become map[string]any, sequences []any and scalars string.
+// Flow sequences of scalars, such as [a, "b"], are read too. What isn't
+// supported fails with an error naming the line, rather than reading as
+// something else and losing entries: flow mappings other than {}, nested
+// flow collections, anchors and aliases (&a, *a, <<: *a), tags (!!str),
+// and plain or quoted scalars continued over several lines. Block scalars
+// (| and >) work, but drop blank lines inside them.
+func parseYAML(data string) (any, error) {
+	var docLines []yamlLine
+	for i, raw := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimLeft(raw, " ")
+		if strings.TrimSpace(trimmed) == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			// Blank lines are kept inside block scalars, which are rare enough to ignore them
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs can't indent YAML", i+1)
+		}
+		docLines = append(docLines, yamlLine{indent: len(raw) - len(trimmed), text: strings.TrimRight(trimmed, " \t"), number: i + 1})
+	}
+	if len(docLines) == 0 {
+		return map[string]any{}, nil
+	}
+	p := &yamlParser{lines: docLines}
+	value, err := p.block(docLines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].number)
+	}
+	return value, nil
+}
+
+// yamlParser walks the lines of a document
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// block parses the mapping or sequence whose lines start at indent
+func (p *yamlParser) block(indent int) (any, error) {
+	if strings.HasPrefix(p.lines[p.pos].text, "- ") || p.lines[p.pos].text == "-" {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+// sequence parses "- item" lines at indent
+func (p *yamlParser) sequence(indent int) (any, error) {
+	items := []any{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent || !(strings.HasPrefix(line.text, "- ") || line.text == "-") {
+			break
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if rest == "" {
+			// The item is the block below
+			p.pos++
+			if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+				value, err := p.block(p.lines[p.pos].indent)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, value)
+			} else {
+				items = append(items, "")
+			}
+			continue
+		}
+
+		// "- key: value" starts a mapping indented to where "key" is
+		itemIndent := indent + len(line.text) - len(rest)
+		if _, _, isKey := splitKey(rest); isKey {
+			p.lines[p.pos] = yamlLine{indent: itemIndent, text: rest, number: line.number}
+			value, err := p.mapping(itemIndent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+			continue
+		}
+
+		p.pos++
+		value, err := p.scalar(rest, indent, line.number)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, value)
+	}
+	return items, nil
+}
+
+// mapping parses "key: value" lines at indent
+func (p *yamlParser) mapping(indent int) (any, error) {
+	values := map[string]any{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent || strings.HasPrefix(line.text, "- ") || line.text == "-" {
+			break
+		}
+		key, rest, ok := splitKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected 'key: value'", line.number)
+		}
+		p.pos++
+
+		if rest != "" {
+			value, err := p.scalar(rest, indent, line.number)
+			if err != nil {
+				return nil, err
+			}
+			values[key] = value
+			continue
+		}
+
+		// The value is the block below, or a sequence at the same indentation
+		switch {
+		case p.pos < len(p.lines) && p.lines[p.pos].indent > indent:
+			value, err := p.block(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			values[key] = value
+		case p.pos < len(p.lines) && p.lines[p.pos].indent == indent && strings.HasPrefix(p.lines[p.pos].text, "-"):
+			value, err := p.sequence(indent)
+			if err != nil {
+				return nil, err
+			}
+			values[key] = value
+		default:
+			values[key] = ""
+		}
+	}
+	return values, nil
+}
+
+// scalar parses a value written after "key:" or "- "
+// Block scalars (| and >) take the more indented lines that follow.
+func (p *yamlParser) scalar(text string, indent, number int) (any, error) {
+	switch {
+	case text == "{}":
+		return map[string]any{}, nil
+	case strings.HasPrefix(text, "{"):
+		return nil, fmt.Errorf("line %d: flow mappings aren't supported, write it in block style", number)
+	case strings.HasPrefix(text, "["):
+		return flowSequence(text, number)
+	case strings.HasPrefix(text, "&") || strings.HasPrefix(text, "*"):
+		return nil, fmt.Errorf("line %d: anchors and aliases aren't supported", number)
+	case strings.HasPrefix(text, "!"):
+		return nil, fmt.Errorf("line %d: tags aren't supported", number)
+	case strings.HasPrefix(text, "|") || strings.HasPrefix(text, ">"):
+		var parts []string
+		for p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+			parts = append(parts, p.lines[p.pos].text)
+			p.pos++
+		}
+		sep := "\n"
+		if text[0] == '>' {
+			sep = " "
+		}
+		return strings.Join(parts, sep), nil
+	case strings.HasPrefix(text, `"`):
+		end := closingQuote(text)
+		if end == -1 {
+			return nil, fmt.Errorf("line %d: unterminated string", number)
+		}
+		value, err := strconv.Unquote(text[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid string: %v", number, err)
+		}
+		return value, nil
+	case strings.HasPrefix(text, "'"):
+		for i := 1; i < len(text); i++ {
+			if text[i] != '\'' {
+				continue
+			}
+			if i+1 < len(text) && text[i+1] == '\'' {
+				i++ // '' is an escaped quote
+				continue
+			}
+			return strings.ReplaceAll(text[1:i], "''", "'"), nil
+		}
+		return nil, fmt.Errorf("line %d: unterminated string", number)
+	}
+	if i := strings.Index(text, " #"); i != -1 {
+		text = strings.TrimRight(text[:i], " ")
+	}
+	return text, nil
+}
+
+// flowSequence parses a one-line flow sequence of scalars, e.g. [a, "b c"]
+func flowSequence(text string, number int) (any, error) {
+	if i := strings.Index(text, " #"); i != -1 && !strings.HasSuffix(text, "]") {
+		text = strings.TrimRight(text[:i], " ")
+	}
+	if !strings.HasSuffix(text, "]") {
+		return nil, fmt.Errorf("line %d: flow sequences must end on the same line", number)
+	}
+	inner := strings.TrimSpace(text[1 : len(text)-1])
+	items := []any{}
+	if inner == "" {
+		return items, nil
+	}
+
+	// Split at commas outside quotes
+	var parts []string
+	start, quote := 0, byte(0)
+	for i := 0; i < len(inner); i++ {
+		c := inner[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			return nil, fmt.Errorf("line %d: nested flow collections aren't supported", number)
+		case c == ',':
+			parts = append(parts, inner[start:i])
+			start = i + 1
+		}
+	}
+	parts = append(parts, inner[start:])
+
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue // Trailing comma
+		}
+		var value any = part
+		if strings.HasPrefix(part, `"`) || strings.HasPrefix(part, "'") || strings.HasPrefix(part, "&") || strings.HasPrefix(part, "*") || strings.HasPrefix(part, "!") {
+			var err error
+			if value, err = (&yamlParser{}).scalar(part, 0, number); err != nil {
+				return nil, err
+			}
+		}
+		items = append(items, value)
+	}
+	return items, nil
+}
+
+// closingQuote returns the index of the quote ending a double-quoted string
+func closingQuote(text string) int {
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// splitKey splits "key: value" (or "key:" ending a line), allowing a quoted key
+func splitKey(text string) (string, string, bool) {
+	key := ""
+	rest := text
+	if strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "'") {
+		end := strings.IndexByte(text[1:], text[0])
+		if end == -1 {
+			return "", "", false
+		}
+		key, rest = text[1:end+1], text[end+2:]
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", false
+		}
+		rest = rest[1:]
+	} else {
+		i := strings.Index(text, ": ")
+		if i == -1 {
+			if !strings.HasSuffix(text, ":") {
+				return "", "", false
+			}
+			i = len(text) - 1
+		}
+		key, rest = text[:i], text[i+1:]
+	}
+	if rest != "" && rest[0] != ' ' {
+		return "", "", false
+	}
+	rest = strings.TrimSpace(rest)
+	if strings.HasPrefix(rest, "#") {
+		rest = "" // Only a comment
+	}
+	return key, rest, true
+}
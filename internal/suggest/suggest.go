@@ -0,0 +1,381 @@
+package suggest
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"laziest/internal/flagparse"
+)
+
+// Options tunes which history clusters become suggestions
+type Options struct {
+	MinCount  int // Times a command shape must appear (default 3)
+	MinLength int // Shortest command worth saving, in characters (default 20)
+	MaxValues int // Distinct values kept in a value list before falling back to custom input (default 8)
+	Limit     int // Maximum suggestions returned (default 10)
+}
+
+// Suggestion is a proposed saved command built from similar history entries
+type Suggestion struct {
+	Command  string   // Command with bindings for the varying positions
+	Name     string   // Proposed alias name
+	Count    int      // Number of history entries in the cluster
+	Examples []string // Distinct entries from the cluster, most recent first
+}
+
+// slot is a position whose value may vary between entries of a cluster
+type slot struct {
+	flag   string // Flag name, or "" for a positional argument
+	joined bool   // Flag written as --flag=value
+}
+
+// shape is the parsed form of one history entry
+// Fixed text and slots alternate via parts: a nil *slot part is fixed text.
+type shape struct {
+	key    string   // Identifies entries with the same base, flags and arguments
+	parts  []part   // Output pieces in order
+	values []string // Raw value for each slot, in order
+}
+
+type part struct {
+	text string
+	slot *slot
+}
+
+// Analyze clusters history entries (oldest first) by base command and flag
+// set, and proposes a command for each frequent cluster. Positions that differ
+// between entries become value-list or directory bindings pre-filled with the
+// observed values. Entries already saved (in existing) are skipped.
+func Analyze(entries []string, existing []string, opts Options) []Suggestion {
+	if opts.MinCount == 0 {
+		opts.MinCount = 3
+	}
+	if opts.MinLength == 0 {
+		opts.MinLength = 20
+	}
+	if opts.MaxValues == 0 {
+		opts.MaxValues = 8
+	}
+	if opts.Limit == 0 {
+		opts.Limit = 10
+	}
+
+	saved := make(map[string]bool, len(existing))
+	for _, cmd := range existing {
+		saved[strings.TrimSpace(cmd)] = true
+	}
+
+	type cluster struct {
+		shapes  []shape // Newest first
+		entries []string
+	}
+	clusters := make(map[string]*cluster)
+	var order []string
+
+	// Newest first, so value lists and examples favour recent use
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := strings.TrimSpace(entries[i])
+		if entry == "" || strings.Contains(entry, "{%") || saved[entry] {
+			continue
+		}
+		s, ok := parseShape(entry)
+		if !ok {
+			continue
+		}
+		c := clusters[s.key]
+		if c == nil {
+			c = &cluster{}
+			clusters[s.key] = c
+			order = append(order, s.key)
+		}
+		c.shapes = append(c.shapes, s)
+		c.entries = append(c.entries, entry)
+	}
+
+	var suggestions []Suggestion
+	for _, key := range order {
+		c := clusters[key]
+		if len(c.shapes) < opts.MinCount || len(c.entries[0]) < opts.MinLength {
+			continue
+		}
+
+		command := template(c.shapes, opts.MaxValues)
+		if saved[command] {
+			continue
+		}
+
+		suggestions = append(suggestions, Suggestion{
+			Command:  command,
+			Name:     nameFor(c.shapes[0]),
+			Count:    len(c.shapes),
+			Examples: distinct(c.entries, 3),
+		})
+	}
+
+	// Favour commands that are both long and frequent
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return score(suggestions[i]) > score(suggestions[j])
+	})
+	if len(suggestions) > opts.Limit {
+		suggestions = suggestions[:opts.Limit]
+	}
+	return suggestions
+}
+
+// score ranks suggestions by how much typing they save
+func score(s Suggestion) int {
+	return s.Count * len(s.Examples[0])
+}
+
+// parseShape splits an entry into fixed text and value slots
+// Entries without any flag or argument have nothing to bind and are skipped.
+func parseShape(entry string) (shape, bool) {
+	segments := flagparse.ParseSegments(entry)
+	commandWords := flagparse.CommandWords(segments)
+
+	var s shape
+	var key []string
+	fixed := func(text string) {
+		s.parts = append(s.parts, part{text: text})
+		key = append(key, text)
+	}
+	variable := func(sl slot, value string) {
+		s.parts = append(s.parts, part{slot: &sl})
+		s.values = append(s.values, value)
+		key = append(key, fmt.Sprintf("<%s%v>", sl.flag, sl.joined))
+	}
+
+	for i, seg := range segments {
+		switch seg.Type {
+		case flagparse.SegmentOperator:
+			fixed(seg.Static)
+		case flagparse.SegmentStatic:
+			for j, arg := range seg.Args {
+				if j < commandWords[i] || arg == "--" {
+					fixed(arg)
+				} else {
+					variable(slot{}, arg)
+				}
+			}
+		case flagparse.SegmentFlag:
+			if seg.Flag.Value == "" {
+				fixed(seg.Flag.Name)
+			} else {
+				variable(slot{flag: seg.Flag.Name, joined: seg.Flag.Joined}, seg.Flag.Value)
+			}
+		}
+	}
+
+	if len(s.values) == 0 {
+		return shape{}, false
+	}
+	s.key = strings.Join(key, "\x00")
+	return s, true
+}
+
+// template builds the suggested command for a cluster
+func template(shapes []shape, maxValues int) string {
+	first := shapes[0]
+	var out []string
+	slotIdx := 0
+
+	for _, p := range first.parts {
+		if p.slot == nil {
+			out = append(out, p.text)
+			continue
+		}
+
+		// Observed values for this slot, most frequent (then most recent) first
+		var values []string
+		counts := make(map[string]int)
+		for _, s := range shapes {
+			v := s.values[slotIdx]
+			if counts[v] == 0 {
+				values = append(values, v)
+			}
+			counts[v]++
+		}
+		sort.SliceStable(values, func(i, j int) bool {
+			return counts[values[i]] > counts[values[j]]
+		})
+		slotIdx++
+
+		out = append(out, bindSlot(*p.slot, values, maxValues))
+	}
+
+	return strings.Join(out, " ")
+}
+
+// bindSlot emits a slot as static text when it never varies, otherwise as a
+// directory or value-list binding
+func bindSlot(sl slot, values []string, maxValues int) string {
+	if len(values) == 1 {
+		switch {
+		case sl.flag == "":
+			return values[0]
+		case sl.joined:
+			return sl.flag + "=" + values[0]
+		default:
+			return sl.flag + " " + values[0]
+		}
+	}
+
+	prefix := ""
+	if sl.flag != "" {
+		prefix = sl.flag + ":"
+		if sl.joined {
+			prefix = sl.flag + "="
+		}
+	}
+
+	if dir, filter, ok := commonDirectory(values); ok {
+		if filter != "" {
+			return fmt.Sprintf("{%%%s%s:%s%%}", prefix, dir, filter)
+		}
+		return fmt.Sprintf("{%%%s%s%%}", prefix, dir)
+	}
+
+	// Values that can't be written in a [a,b] list, or too many to list,
+	// become free input
+	listable := len(values) <= maxValues
+	for _, v := range values {
+		if strings.ContainsAny(v, ",[]%") {
+			listable = false
+		}
+	}
+	if !listable {
+		return fmt.Sprintf("{%%%s[...]%%}", prefix)
+	}
+	return fmt.Sprintf("{%%%s[%s,...]%%}", prefix, strings.Join(values, ","))
+}
+
+// numberPattern matches numeric values, which are never paths
+var numberPattern = regexp.MustCompile(`^[-+]?\d*\.?\d+([eE][-+]?\d+)?$`)
+
+// extPattern matches a short file extension
+var extPattern = regexp.MustCompile(`\.[A-Za-z0-9]{1,6}$`)
+
+// commonDirectory reports whether all values are file paths, and if so the
+// directory containing them and a *.ext filter when they share an extension
+func commonDirectory(values []string) (string, string, bool) {
+	var dirs []string
+	ext := ""
+	for i, raw := range values {
+		v := flagparse.Unquote(raw)
+		if numberPattern.MatchString(v) || strings.ContainsAny(v, " :") {
+			return "", "", false
+		}
+		if !strings.Contains(v, "/") || !extPattern.MatchString(v) {
+			return "", "", false
+		}
+
+		dirs = append(dirs, filepath.Dir(v))
+		e := filepath.Ext(v)
+		if i == 0 {
+			ext = e
+		} else if e != ext {
+			ext = ""
+		}
+	}
+
+	dir := commonPrefix(dirs)
+	if dir == "" {
+		return "", "", false
+	}
+	if ext != "" {
+		return dir, "*" + ext, true
+	}
+	return dir, "", true
+}
+
+// commonPrefix returns the deepest directory shared by all dirs
+func commonPrefix(dirs []string) string {
+	parts := strings.Split(dirs[0], "/")
+	for _, d := range dirs[1:] {
+		other := strings.Split(d, "/")
+		n := 0
+		for n < len(parts) && n < len(other) && parts[n] == other[n] {
+			n++
+		}
+		parts = parts[:n]
+	}
+
+	// Paths sharing only the root (or nothing) have no useful directory
+	return strings.Join(parts, "/")
+}
+
+// aliasChars matches runs of characters not allowed in alias names
+var aliasChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// nameFor proposes an alias from the command words
+// "python scripts/train.py" -> "train", "kubectl logs" -> "kubectl_logs"
+func nameFor(s shape) string {
+	// The command name is in the fixed text before the first slot, except
+	// an interpreter's script, which is the first slot
+	var prefix []string
+	for i, p := range s.parts {
+		if p.slot != nil {
+			if p.slot.flag == "" {
+				prefix = append(prefix, s.values[slotIndex(s, i)])
+			}
+			break
+		}
+		prefix = append(prefix, p.text)
+	}
+	words := flagparse.CommandName(flagparse.ParseSegments(strings.Join(prefix, " ")))
+	if len(words) == 0 {
+		return "cmd"
+	}
+
+	var name string
+	switch {
+	case len(words) >= 2 && (words[len(words)-2] == "-m" || strings.Contains(words[len(words)-1], ".")):
+		// Interpreter script or module: name after the script
+		base := filepath.Base(words[len(words)-1])
+		name = strings.TrimSuffix(base, filepath.Ext(base))
+	default:
+		for i, w := range words {
+			words[i] = filepath.Base(w)
+		}
+		name = strings.Join(words, "_")
+	}
+
+	name = strings.Trim(aliasChars.ReplaceAllString(name, "_"), "_")
+	if name == "" {
+		return "cmd"
+	}
+	if c := name[0]; !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+		name = "cmd_" + name
+	}
+	return name
+}
+
+// slotIndex returns the index in s.values of the slot at s.parts[i]
+func slotIndex(s shape, i int) int {
+	n := 0
+	for _, p := range s.parts[:i] {
+		if p.slot != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// distinct returns up to n unique entries in order
+func distinct(entries []string, n int) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, e := range entries {
+		if !seen[e] {
+			seen[e] = true
+			out = append(out, e)
+			if len(out) == n {
+				break
+			}
+		}
+	}
+	return out
+}
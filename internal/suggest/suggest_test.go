@@ -0,0 +1,79 @@
+package suggest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	history := []string{
+		"python train.py --config configs/base.yaml --epochs 10",
+		"ls",
+		"python train.py --config configs/large.yaml --epochs 50",
+		"kubectl logs -n prod api-7f9c --tail=100",
+		"python train.py --config configs/base.yaml --epochs 10",
+		"kubectl logs -n prod api-8a1d --tail=100",
+		"python train.py --config configs/small.yaml --epochs 10",
+		"kubectl logs -n staging api-7f9c --tail=100",
+		"git status",
+		"git status",
+		"git status",
+	}
+
+	got := Analyze(history, nil, Options{})
+	expected := []Suggestion{
+		{
+			Command:  "python train.py {%--config:configs:*.yaml%} {%--epochs:[10,50,...]%}",
+			Name:     "train",
+			Count:    4,
+			Examples: []string{"python train.py --config configs/small.yaml --epochs 10", "python train.py --config configs/base.yaml --epochs 10", "python train.py --config configs/large.yaml --epochs 50"},
+		},
+		{
+			Command:  "kubectl logs {%-n:[prod,staging,...]%} {%[api-7f9c,api-8a1d,...]%} --tail=100",
+			Name:     "kubectl_logs",
+			Count:    3,
+			Examples: []string{"kubectl logs -n staging api-7f9c --tail=100", "kubectl logs -n prod api-8a1d --tail=100", "kubectl logs -n prod api-7f9c --tail=100"},
+		},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Analyze() =\n%#v\nexpected\n%#v", got, expected)
+	}
+}
+
+func TestAnalyzeSkipsSaved(t *testing.T) {
+	history := []string{
+		"kubectl logs -n prod api --tail=100",
+		"kubectl logs -n prod api --tail=100",
+		"kubectl logs -n prod api --tail=100",
+	}
+
+	if got := Analyze(history, nil, Options{}); len(got) != 1 {
+		t.Fatalf("expected 1 suggestion, got %d", len(got))
+	}
+	if got := Analyze(history, []string{"kubectl logs -n prod api --tail=100"}, Options{}); len(got) != 0 {
+		t.Errorf("expected saved command to be skipped, got %v", got)
+	}
+}
+
+func TestBindSlot(t *testing.T) {
+	tests := []struct {
+		slot     slot
+		values   []string
+		expected string
+	}{
+		{slot{flag: "--lr"}, []string{"0.1"}, "--lr 0.1"},
+		{slot{flag: "--lr", joined: true}, []string{"0.1", "0.01"}, "{%--lr=[0.1,0.01,...]%}"},
+		{slot{}, []string{"/data/a/x.csv", "/data/b/y.csv"}, "{%/data:*.csv%}"},
+		{slot{}, []string{"/a/x.csv", "/b/y.txt"}, "{%[/a/x.csv,/b/y.txt,...]%}"},
+		{slot{flag: "-m"}, []string{"'fix: a, b'", "'wip'"}, "{%-m:[...]%}"},
+		{slot{}, []string{"a", "b", "c"}, "{%[...]%}"},
+	}
+
+	for _, tt := range tests {
+		got := bindSlot(tt.slot, tt.values, 2)
+		if got != tt.expected {
+			t.Errorf("bindSlot(%v, %v) = %q, expected %q", tt.slot, tt.values, got, tt.expected)
+		}
+	}
+}